@@ -20,6 +20,11 @@ type Config struct {
 	AdminUsername      string        `default:"vitta"                                           env:"ADMIN_USERNAME"`
 	AdminPassword      string        `default:"vittaT3st!"                                      env:"ADMIN_PASSWORD"`
 	AdaptersConfigPath string        `default:"adapters.csv"                                    env:"ADAPTERS_PATH"`
+	AttachmentsPath    string        `default:"attachments"                                     env:"ATTACHMENTS_PATH"`
+	SchedulerInterval  time.Duration `default:"1h"                                              env:"SCHEDULER_INTERVAL"`
+	SchedulerHorizon   time.Duration `default:"168h"                                            env:"SCHEDULER_HORIZON"`
+	TrashRetention     time.Duration `default:"720h"                                            env:"TRASH_RETENTION"`
+	PurgeInterval      time.Duration `default:"24h"                                             env:"PURGE_INTERVAL"`
 }
 
 func New() (*Config, error) {
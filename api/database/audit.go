@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Audit identifies who makes the changes recorded in the audit log.
+type Audit struct {
+	Actor     string
+	RequestID string
+}
+
+type auditKey struct{}
+
+// querySetAudit stores the audit details on the connection, where the audit triggers read them from.
+const querySetAudit = `SELECT set_config('vitta.actor', $1, false), set_config('vitta.request_id', $2, false)`
+
+// WithAudit returns a context whose database changes are recorded in the audit log with the given audit details.
+func WithAudit(ctx context.Context, audit Audit) context.Context {
+	return context.WithValue(ctx, auditKey{}, audit)
+}
+
+// AuditFrom returns the audit details of a context.
+func AuditFrom(ctx context.Context) Audit {
+	audit, _ := ctx.Value(auditKey{}).(Audit)
+
+	return audit
+}
+
+// auditDataKey keeps, in the custom data of a connection, the audit details last set on it.
+const auditDataKey = "vitta.audit"
+
+// setAudit runs before a connection is acquired from the pool, so that changes made through it are attributed to the
+// audit details of the acquiring context. The details are only set when they differ from those already on the
+// connection, so reads, which carry none, skip the round trip unless they must clear the details of an earlier write.
+// Connections failing to set them are destroyed.
+func setAudit(ctx context.Context, conn *pgx.Conn) bool {
+	audit := AuditFrom(ctx)
+	data := conn.PgConn().CustomData()
+
+	if current, _ := data[auditDataKey].(Audit); current == audit {
+		return true
+	}
+
+	_, err := conn.Exec(ctx, querySetAudit, audit.Actor, audit.RequestID)
+	if err != nil {
+		slog.Error("error setting audit details on connection", "error", err)
+
+		return false
+	}
+
+	data[auditDataKey] = audit
+
+	return true
+}
@@ -20,6 +20,7 @@ type DBIface interface {
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
 	Begin(ctx context.Context) (pgx.Tx, error)
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
 	Close()
 }
 
@@ -27,7 +28,14 @@ func New(cfg *Config) (DBIface, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
 	defer cancel()
 
-	conn, err := pgxpool.New(ctx, cfg.URL)
+	poolCfg, err := pgxpool.ParseConfig(cfg.URL)
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+
+	poolCfg.BeforeAcquire = setAudit
+
+	conn, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
 		return nil, err //nolint: wrapcheck
 	}
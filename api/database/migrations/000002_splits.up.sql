@@ -0,0 +1,16 @@
+CREATE TABLE splits (
+    id UUID PRIMARY KEY,
+    transaction_id UUID NOT NULL,
+    category_id UUID,
+    payee_id UUID,
+    credit DOUBLE PRECISION,
+    debit DOUBLE PRECISION,
+    notes VARCHAR(512),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE,
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL,
+    FOREIGN KEY (payee_id) REFERENCES payees(id) ON DELETE SET NULL
+);
+
+CREATE INDEX splits_transaction_id_idx ON splits (transaction_id);
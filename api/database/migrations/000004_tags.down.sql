@@ -0,0 +1,2 @@
+DROP table transaction_tags;
+DROP table tags;
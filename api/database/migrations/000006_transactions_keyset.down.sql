@@ -0,0 +1 @@
+DROP INDEX transactions_account_id_cleared_at_id_idx;
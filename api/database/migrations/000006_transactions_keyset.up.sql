@@ -0,0 +1 @@
+CREATE INDEX transactions_account_id_cleared_at_id_idx ON transactions (account_id, COALESCE(cleared_at, 'infinity'), id);
@@ -0,0 +1,2 @@
+DROP table scheduled_transactions;
+DROP table recurring_transactions;
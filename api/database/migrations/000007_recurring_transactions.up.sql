@@ -0,0 +1,32 @@
+CREATE TABLE recurring_transactions (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL,
+    category_id UUID,
+    payee_id UUID,
+    name VARCHAR(255),
+    credit DOUBLE PRECISION,
+    debit DOUBLE PRECISION,
+    notes VARCHAR(512),
+    frequency VARCHAR(16) NOT NULL,
+    interval INTEGER NOT NULL DEFAULT 1,
+    day_of_month INTEGER,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    next_date DATE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE,
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL,
+    FOREIGN KEY (payee_id) REFERENCES payees(id) ON DELETE SET NULL
+);
+
+CREATE INDEX recurring_transactions_next_date_idx ON recurring_transactions (next_date);
+
+CREATE TABLE scheduled_transactions (
+    transaction_id UUID PRIMARY KEY,
+    recurring_id UUID NOT NULL,
+    scheduled_on DATE NOT NULL,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+    FOREIGN KEY (recurring_id) REFERENCES recurring_transactions(id) ON DELETE CASCADE,
+    UNIQUE (recurring_id, scheduled_on)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE transactions DROP COLUMN reconciliation_id;
+DROP table reconciliations;
@@ -0,0 +1,14 @@
+CREATE TABLE reconciliations (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL,
+    statement_date DATE NOT NULL,
+    statement_balance DOUBLE PRECISION NOT NULL,
+    cleared_balance DOUBLE PRECISION NOT NULL,
+    transactions INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX reconciliations_account_id_idx ON reconciliations (account_id);
+
+ALTER TABLE transactions ADD COLUMN reconciliation_id UUID REFERENCES reconciliations(id) ON DELETE SET NULL;
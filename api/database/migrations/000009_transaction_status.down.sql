@@ -0,0 +1,5 @@
+DROP INDEX transactions_account_id_date_id_idx;
+CREATE INDEX transactions_account_id_cleared_at_id_idx ON transactions (account_id, COALESCE(cleared_at, 'infinity'), id);
+ALTER TABLE transactions ALTER COLUMN cleared_at SET DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE transactions DROP COLUMN status;
+ALTER TABLE transactions DROP COLUMN date;
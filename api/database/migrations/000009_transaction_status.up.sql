@@ -0,0 +1,14 @@
+ALTER TABLE transactions ADD COLUMN date DATE;
+ALTER TABLE transactions ADD COLUMN status TEXT NOT NULL DEFAULT 'uncleared'
+    CHECK (status IN ('uncleared', 'cleared', 'reconciled'));
+
+UPDATE transactions SET date = COALESCE(cleared_at, created_at, CURRENT_TIMESTAMP)::date,
+    status = CASE WHEN reconciliation_id IS NOT NULL THEN 'reconciled'
+        WHEN cleared_at IS NOT NULL THEN 'cleared' ELSE 'uncleared' END;
+
+ALTER TABLE transactions ALTER COLUMN date SET DEFAULT CURRENT_DATE;
+ALTER TABLE transactions ALTER COLUMN date SET NOT NULL;
+ALTER TABLE transactions ALTER COLUMN cleared_at DROP DEFAULT;
+
+DROP INDEX transactions_account_id_cleared_at_id_idx;
+CREATE INDEX transactions_account_id_date_id_idx ON transactions (account_id, date, id);
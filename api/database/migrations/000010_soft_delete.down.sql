@@ -0,0 +1,22 @@
+DELETE FROM transactions WHERE deleted_at IS NOT NULL;
+DELETE FROM categories WHERE deleted_at IS NOT NULL;
+DELETE FROM groups WHERE deleted_at IS NOT NULL;
+DELETE FROM payees WHERE deleted_at IS NOT NULL;
+DELETE FROM accounts WHERE deleted_at IS NOT NULL;
+
+DROP INDEX transactions_deleted_at_idx;
+
+DROP INDEX payees_name_idx;
+ALTER TABLE payees ADD CONSTRAINT payees_name_key UNIQUE (name);
+DROP INDEX categories_group_id_name_idx;
+ALTER TABLE categories ADD CONSTRAINT categories_group_id_name_key UNIQUE (group_id, name);
+DROP INDEX groups_name_idx;
+ALTER TABLE groups ADD CONSTRAINT groups_name_key UNIQUE (name);
+DROP INDEX accounts_name_idx;
+ALTER TABLE accounts ADD CONSTRAINT accounts_name_key UNIQUE (name);
+
+ALTER TABLE transactions DROP COLUMN deleted_at;
+ALTER TABLE payees DROP COLUMN deleted_at;
+ALTER TABLE categories DROP COLUMN deleted_at;
+ALTER TABLE groups DROP COLUMN deleted_at;
+ALTER TABLE accounts DROP COLUMN deleted_at;
@@ -0,0 +1,17 @@
+ALTER TABLE accounts ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE groups ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE categories ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE payees ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE transactions ADD COLUMN deleted_at TIMESTAMP;
+
+-- Names only have to be unique outside of the trash.
+ALTER TABLE accounts DROP CONSTRAINT accounts_name_key;
+CREATE UNIQUE INDEX accounts_name_idx ON accounts (name) WHERE deleted_at IS NULL;
+ALTER TABLE groups DROP CONSTRAINT groups_name_key;
+CREATE UNIQUE INDEX groups_name_idx ON groups (name) WHERE deleted_at IS NULL;
+ALTER TABLE categories DROP CONSTRAINT categories_group_id_name_key;
+CREATE UNIQUE INDEX categories_group_id_name_idx ON categories (group_id, name) WHERE deleted_at IS NULL;
+ALTER TABLE payees DROP CONSTRAINT payees_name_key;
+CREATE UNIQUE INDEX payees_name_idx ON payees (name) WHERE deleted_at IS NULL;
+
+CREATE INDEX transactions_deleted_at_idx ON transactions (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,15 @@
+DROP TRIGGER reconciliations_audit ON reconciliations;
+DROP TRIGGER recurring_transactions_audit ON recurring_transactions;
+DROP TRIGGER budgets_audit ON budgets;
+DROP TRIGGER attachments_audit ON attachments;
+DROP TRIGGER transaction_tags_audit ON transaction_tags;
+DROP TRIGGER tags_audit ON tags;
+DROP TRIGGER splits_audit ON splits;
+DROP TRIGGER transactions_audit ON transactions;
+DROP TRIGGER payees_audit ON payees;
+DROP TRIGGER categories_audit ON categories;
+DROP TRIGGER groups_audit ON groups;
+DROP TRIGGER accounts_audit ON accounts;
+DROP FUNCTION audit_change();
+DROP TABLE audit_log;
+DROP FUNCTION audit_log_immutable();
@@ -0,0 +1,78 @@
+CREATE TABLE audit_log (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    entity VARCHAR(64) NOT NULL,
+    entity_id UUID NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    before JSONB,
+    after JSONB,
+    actor VARCHAR(255),
+    request_id VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity, entity_id, id);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+CREATE INDEX audit_log_request_id_idx ON audit_log (request_id);
+
+-- The audit log is append-only.
+CREATE FUNCTION audit_log_immutable() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_immutable BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_immutable();
+
+-- audit_change records a row change along with the actor and request id set on the connection. The column holding
+-- the entity id is passed as the trigger argument.
+CREATE FUNCTION audit_change() RETURNS TRIGGER AS $$
+DECLARE
+    old_row JSONB := CASE WHEN TG_OP = 'INSERT' THEN NULL ELSE to_jsonb(OLD) END;
+    new_row JSONB := CASE WHEN TG_OP = 'DELETE' THEN NULL ELSE to_jsonb(NEW) END;
+    action VARCHAR(16) := lower(TG_OP);
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        action := 'create';
+    ELSIF TG_OP = 'UPDATE' THEN
+        IF old_row = new_row THEN
+            RETURN NULL;
+        ELSIF old_row->>'deleted_at' IS NULL AND new_row->>'deleted_at' IS NOT NULL THEN
+            action := 'trash';
+        ELSIF old_row->>'deleted_at' IS NOT NULL AND new_row->>'deleted_at' IS NULL THEN
+            action := 'restore';
+        END IF;
+    END IF;
+
+    INSERT INTO audit_log (entity, entity_id, action, before, after, actor, request_id)
+    VALUES (TG_TABLE_NAME, (COALESCE(new_row, old_row)->>TG_ARGV[0])::UUID, action, old_row, new_row,
+        NULLIF(current_setting('vitta.actor', true), ''), NULLIF(current_setting('vitta.request_id', true), ''));
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER accounts_audit AFTER INSERT OR UPDATE OR DELETE ON accounts
+    FOR EACH ROW EXECUTE FUNCTION audit_change('id');
+CREATE TRIGGER groups_audit AFTER INSERT OR UPDATE OR DELETE ON groups
+    FOR EACH ROW EXECUTE FUNCTION audit_change('id');
+CREATE TRIGGER categories_audit AFTER INSERT OR UPDATE OR DELETE ON categories
+    FOR EACH ROW EXECUTE FUNCTION audit_change('id');
+CREATE TRIGGER payees_audit AFTER INSERT OR UPDATE OR DELETE ON payees
+    FOR EACH ROW EXECUTE FUNCTION audit_change('id');
+CREATE TRIGGER transactions_audit AFTER INSERT OR UPDATE OR DELETE ON transactions
+    FOR EACH ROW EXECUTE FUNCTION audit_change('id');
+CREATE TRIGGER splits_audit AFTER INSERT OR UPDATE OR DELETE ON splits
+    FOR EACH ROW EXECUTE FUNCTION audit_change('id');
+CREATE TRIGGER tags_audit AFTER INSERT OR UPDATE OR DELETE ON tags
+    FOR EACH ROW EXECUTE FUNCTION audit_change('id');
+CREATE TRIGGER transaction_tags_audit AFTER INSERT OR UPDATE OR DELETE ON transaction_tags
+    FOR EACH ROW EXECUTE FUNCTION audit_change('transaction_id');
+CREATE TRIGGER attachments_audit AFTER INSERT OR UPDATE OR DELETE ON attachments
+    FOR EACH ROW EXECUTE FUNCTION audit_change('id');
+CREATE TRIGGER budgets_audit AFTER INSERT OR UPDATE OR DELETE ON budgets
+    FOR EACH ROW EXECUTE FUNCTION audit_change('id');
+CREATE TRIGGER recurring_transactions_audit AFTER INSERT OR UPDATE OR DELETE ON recurring_transactions
+    FOR EACH ROW EXECUTE FUNCTION audit_change('id');
+CREATE TRIGGER reconciliations_audit AFTER INSERT OR UPDATE OR DELETE ON reconciliations
+    FOR EACH ROW EXECUTE FUNCTION audit_change('id');
@@ -0,0 +1,12 @@
+DROP TRIGGER accounts_invalidate_snapshots ON accounts;
+DROP TRIGGER categories_invalidate_snapshots ON categories;
+DROP TRIGGER splits_invalidate_snapshots ON splits;
+DROP TRIGGER transactions_invalidate_snapshots ON transactions;
+DROP TRIGGER budgets_invalidate_snapshots ON budgets;
+DROP FUNCTION invalidate_all_budget_snapshots();
+DROP FUNCTION splits_invalidate_snapshots();
+DROP FUNCTION transactions_invalidate_snapshots();
+DROP FUNCTION budgets_invalidate_snapshots();
+DROP FUNCTION invalidate_budget_snapshots(DATE);
+DROP TABLE budget_snapshots;
+ALTER TABLE categories DROP COLUMN carryover;
@@ -0,0 +1,69 @@
+-- carryover is what a category carries into the next month: all of its balance or only a positive one.
+ALTER TABLE categories ADD COLUMN carryover VARCHAR(16) NOT NULL DEFAULT 'all';
+
+-- budget_snapshots caches the available balance of each category at the end of a month, so the balance of a month
+-- is computed from the latest snapshot rather than from the whole history.
+CREATE TABLE budget_snapshots (
+    year INTEGER NOT NULL,
+    month INTEGER NOT NULL,
+    category_id UUID NOT NULL,
+    available DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (year, month, category_id),
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+-- invalidate_budget_snapshots drops the snapshots from a month on, as they carry the balances of that month.
+CREATE FUNCTION invalidate_budget_snapshots(since DATE) RETURNS VOID AS $$
+    DELETE FROM budget_snapshots WHERE (year, month) >= (EXTRACT(YEAR FROM since), EXTRACT(MONTH FROM since));
+$$ LANGUAGE sql;
+
+CREATE FUNCTION budgets_invalidate_snapshots() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM invalidate_budget_snapshots(LEAST(
+        CASE WHEN TG_OP <> 'INSERT' THEN make_date(OLD.year, OLD.month, 1) END,
+        CASE WHEN TG_OP <> 'DELETE' THEN make_date(NEW.year, NEW.month, 1) END));
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION transactions_invalidate_snapshots() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM invalidate_budget_snapshots(LEAST(
+        CASE WHEN TG_OP <> 'INSERT' THEN OLD.date END,
+        CASE WHEN TG_OP <> 'DELETE' THEN NEW.date END));
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION splits_invalidate_snapshots() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM invalidate_budget_snapshots(MIN(date)) FROM transactions WHERE id IN (
+        CASE WHEN TG_OP <> 'INSERT' THEN OLD.transaction_id END,
+        CASE WHEN TG_OP <> 'DELETE' THEN NEW.transaction_id END);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Changes to categories and accounts affect the balances of every month.
+CREATE FUNCTION invalidate_all_budget_snapshots() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM budget_snapshots;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER budgets_invalidate_snapshots AFTER INSERT OR UPDATE OR DELETE ON budgets
+    FOR EACH ROW EXECUTE FUNCTION budgets_invalidate_snapshots();
+CREATE TRIGGER transactions_invalidate_snapshots AFTER INSERT OR DELETE OR UPDATE OF date, credit, debit,
+    category_id, account_id, transfer_id, deleted_at ON transactions
+    FOR EACH ROW EXECUTE FUNCTION transactions_invalidate_snapshots();
+CREATE TRIGGER splits_invalidate_snapshots AFTER INSERT OR UPDATE OR DELETE ON splits
+    FOR EACH ROW EXECUTE FUNCTION splits_invalidate_snapshots();
+CREATE TRIGGER categories_invalidate_snapshots AFTER UPDATE OF carryover, deleted_at ON categories
+    FOR EACH STATEMENT EXECUTE FUNCTION invalidate_all_budget_snapshots();
+CREATE TRIGGER accounts_invalidate_snapshots AFTER UPDATE OF off_budget ON accounts
+    FOR EACH STATEMENT EXECUTE FUNCTION invalidate_all_budget_snapshots();
@@ -0,0 +1,6 @@
+DROP TRIGGER categories_invalidate_snapshots ON categories;
+CREATE TRIGGER categories_invalidate_snapshots AFTER UPDATE OF carryover, deleted_at ON categories
+    FOR EACH STATEMENT EXECUTE FUNCTION invalidate_all_budget_snapshots();
+
+ALTER TABLE budget_snapshots DROP COLUMN overspent;
+ALTER TABLE categories DROP COLUMN income;
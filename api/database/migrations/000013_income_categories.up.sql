@@ -0,0 +1,11 @@
+-- Transactions in income categories fund the budget rather than count as spending.
+ALTER TABLE categories ADD COLUMN income BOOLEAN NOT NULL DEFAULT false;
+
+-- overspent is the overspending of a category not carried over, up to the end of the month, which is taken from the
+-- money ready to assign instead.
+DELETE FROM budget_snapshots;
+ALTER TABLE budget_snapshots ADD COLUMN overspent DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+DROP TRIGGER categories_invalidate_snapshots ON categories;
+CREATE TRIGGER categories_invalidate_snapshots AFTER UPDATE OF carryover, income, deleted_at ON categories
+    FOR EACH STATEMENT EXECUTE FUNCTION invalidate_all_budget_snapshots();
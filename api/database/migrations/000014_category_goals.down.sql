@@ -0,0 +1,3 @@
+ALTER TABLE categories DROP COLUMN goal_date;
+ALTER TABLE categories DROP COLUMN goal_amount;
+ALTER TABLE categories DROP COLUMN goal_type;
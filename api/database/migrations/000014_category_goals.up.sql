@@ -0,0 +1,3 @@
+ALTER TABLE categories ADD COLUMN goal_type VARCHAR(16);
+ALTER TABLE categories ADD COLUMN goal_amount DOUBLE PRECISION;
+ALTER TABLE categories ADD COLUMN goal_date DATE;
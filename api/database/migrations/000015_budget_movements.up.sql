@@ -0,0 +1,17 @@
+CREATE TABLE budget_movements (
+    id UUID PRIMARY KEY,
+    year INTEGER NOT NULL,
+    month INTEGER NOT NULL,
+    from_category_id UUID NOT NULL,
+    to_category_id UUID NOT NULL,
+    amount DOUBLE PRECISION NOT NULL,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (from_category_id) REFERENCES categories(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+CREATE INDEX budget_movements_year_month_idx ON budget_movements (year, month);
+
+CREATE TRIGGER budget_movements_audit AFTER INSERT OR UPDATE OR DELETE ON budget_movements
+    FOR EACH ROW EXECUTE FUNCTION audit_change('id');
@@ -0,0 +1,5 @@
+DELETE FROM budget_snapshots;
+
+DROP TRIGGER accounts_invalidate_snapshots ON accounts;
+CREATE TRIGGER accounts_invalidate_snapshots AFTER UPDATE OF off_budget ON accounts
+    FOR EACH STATEMENT EXECUTE FUNCTION invalidate_all_budget_snapshots();
@@ -0,0 +1,8 @@
+-- Budget lines now leave out off-budget accounts, so snapshots computed with their activity are stale.
+DELETE FROM budget_snapshots;
+
+-- Snapshots are only dropped when an account actually moves on or off budget, not on every account update.
+DROP TRIGGER accounts_invalidate_snapshots ON accounts;
+CREATE TRIGGER accounts_invalidate_snapshots AFTER UPDATE OF off_budget ON accounts
+    FOR EACH ROW WHEN (OLD.off_budget IS DISTINCT FROM NEW.off_budget)
+    EXECUTE FUNCTION invalidate_all_budget_snapshots();
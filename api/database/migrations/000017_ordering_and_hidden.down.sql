@@ -0,0 +1,7 @@
+DROP TRIGGER categories_invalidate_snapshots ON categories;
+CREATE TRIGGER categories_invalidate_snapshots AFTER UPDATE OF carryover, income, deleted_at ON categories
+    FOR EACH STATEMENT EXECUTE FUNCTION invalidate_all_budget_snapshots();
+
+ALTER TABLE categories DROP COLUMN hidden;
+ALTER TABLE categories DROP COLUMN sort_index;
+ALTER TABLE groups DROP COLUMN sort_index;
@@ -0,0 +1,15 @@
+-- sort_index orders groups, and categories within their group, as arranged by the user.
+ALTER TABLE groups ADD COLUMN sort_index INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE categories ADD COLUMN sort_index INTEGER NOT NULL DEFAULT 0;
+
+UPDATE groups SET sort_index = ordered.index FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY created_at) AS index
+    FROM groups) AS ordered WHERE groups.id = ordered.id;
+UPDATE categories SET sort_index = ordered.index FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY group_id
+    ORDER BY created_at) AS index FROM categories) AS ordered WHERE categories.id = ordered.id;
+
+-- Hidden categories are left out of the budget and category lists, but keep their budgets and transactions.
+ALTER TABLE categories ADD COLUMN hidden BOOLEAN NOT NULL DEFAULT false;
+
+DROP TRIGGER categories_invalidate_snapshots ON categories;
+CREATE TRIGGER categories_invalidate_snapshots AFTER UPDATE OF carryover, income, hidden, deleted_at ON categories
+    FOR EACH STATEMENT EXECUTE FUNCTION invalidate_all_budget_snapshots();
@@ -0,0 +1,2 @@
+DROP TABLE scenario_budgets;
+DROP TABLE scenarios;
@@ -0,0 +1,29 @@
+-- Scenarios are named drafts of the budget over a range of months, edited apart from the real budgets.
+CREATE TABLE scenarios (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    notes TEXT,
+    from_month DATE NOT NULL,
+    to_month DATE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE scenario_budgets (
+    id UUID PRIMARY KEY,
+    scenario_id UUID NOT NULL,
+    category_id UUID NOT NULL,
+    year INTEGER NOT NULL,
+    month INTEGER NOT NULL,
+    budgeted DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (scenario_id) REFERENCES scenarios(id) ON DELETE CASCADE,
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE,
+    UNIQUE (scenario_id, year, month, category_id)
+);
+
+CREATE TRIGGER scenarios_audit AFTER INSERT OR UPDATE OR DELETE ON scenarios
+    FOR EACH ROW EXECUTE FUNCTION audit_change('id');
+CREATE TRIGGER scenario_budgets_audit AFTER INSERT OR UPDATE OR DELETE ON scenario_budgets
+    FOR EACH ROW EXECUTE FUNCTION audit_change('id');
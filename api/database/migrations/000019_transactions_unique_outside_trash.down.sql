@@ -0,0 +1,7 @@
+DELETE FROM transactions AS t WHERE t.deleted_at IS NOT NULL AND EXISTS (SELECT 1 FROM transactions AS o
+    WHERE o.account_id = t.account_id AND o.notes = t.notes AND o.credit = t.credit AND o.debit = t.debit
+    AND o.cleared_at = t.cleared_at AND (o.deleted_at IS NULL OR o.id < t.id));
+
+DROP INDEX transactions_account_id_notes_credit_debit_cleared_at_idx;
+ALTER TABLE transactions ADD CONSTRAINT transactions_account_id_notes_credit_debit_cleared_at_key
+    UNIQUE (account_id, notes, credit, debit, cleared_at);
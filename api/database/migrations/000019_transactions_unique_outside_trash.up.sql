@@ -0,0 +1,4 @@
+-- Imported transactions only have to be unique outside of the trash, so trashed ones can be imported again.
+ALTER TABLE transactions DROP CONSTRAINT transactions_account_id_notes_credit_debit_cleared_at_key;
+CREATE UNIQUE INDEX transactions_account_id_notes_credit_debit_cleared_at_idx
+    ON transactions (account_id, notes, credit, debit, cleared_at) WHERE deleted_at IS NULL;
@@ -0,0 +1,13 @@
+CREATE OR REPLACE FUNCTION invalidate_all_budget_snapshots() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM budget_snapshots;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION invalidate_budget_snapshots(since DATE) RETURNS VOID AS $$
+    DELETE FROM budget_snapshots WHERE (year, month) >= (EXTRACT(YEAR FROM since), EXTRACT(MONTH FROM since));
+$$ LANGUAGE sql;
+
+DROP TABLE budget_snapshot_generation;
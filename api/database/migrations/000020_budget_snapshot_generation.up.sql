@@ -0,0 +1,20 @@
+-- budget_snapshot_generation counts the invalidations of budget snapshots, so a computation of the balances that ran
+-- alongside an invalidation can tell its snapshots are stale and not save them.
+CREATE TABLE budget_snapshot_generation (
+    generation BIGINT NOT NULL
+);
+INSERT INTO budget_snapshot_generation VALUES (0);
+
+CREATE OR REPLACE FUNCTION invalidate_budget_snapshots(since DATE) RETURNS VOID AS $$
+    UPDATE budget_snapshot_generation SET generation = generation + 1;
+    DELETE FROM budget_snapshots WHERE (year, month) >= (EXTRACT(YEAR FROM since), EXTRACT(MONTH FROM since));
+$$ LANGUAGE sql;
+
+CREATE OR REPLACE FUNCTION invalidate_all_budget_snapshots() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE budget_snapshot_generation SET generation = generation + 1;
+    DELETE FROM budget_snapshots;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
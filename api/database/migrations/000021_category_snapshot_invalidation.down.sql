@@ -0,0 +1,3 @@
+DROP TRIGGER categories_invalidate_snapshots ON categories;
+CREATE TRIGGER categories_invalidate_snapshots AFTER UPDATE OF carryover, income, hidden, deleted_at ON categories
+    FOR EACH STATEMENT EXECUTE FUNCTION invalidate_all_budget_snapshots();
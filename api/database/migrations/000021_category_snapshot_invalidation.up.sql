@@ -0,0 +1,6 @@
+-- Snapshots are only dropped when a category setting the balances depend on changes, not when a category is renamed.
+DROP TRIGGER categories_invalidate_snapshots ON categories;
+CREATE TRIGGER categories_invalidate_snapshots AFTER UPDATE OF carryover, income, hidden, deleted_at ON categories
+    FOR EACH ROW WHEN (OLD.carryover IS DISTINCT FROM NEW.carryover OR OLD.income IS DISTINCT FROM NEW.income
+    OR OLD.hidden IS DISTINCT FROM NEW.hidden OR OLD.deleted_at IS DISTINCT FROM NEW.deleted_at)
+    EXECUTE FUNCTION invalidate_all_budget_snapshots();
@@ -0,0 +1 @@
+DROP TRIGGER scheduled_transactions_audit ON scheduled_transactions;
@@ -0,0 +1,2 @@
+CREATE TRIGGER scheduled_transactions_audit AFTER INSERT OR UPDATE OR DELETE ON scheduled_transactions
+    FOR EACH ROW EXECUTE FUNCTION audit_change('transaction_id');
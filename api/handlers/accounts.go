@@ -13,14 +13,16 @@ import (
 type (
 	// Account model.
 	Account struct {
-		ID        uuid.UUID `json:"id"`
-		Name      string    `json:"name"`
-		OffBudget *bool     `json:"offBudget"`
-		Category  string    `json:"category"`
-		Adapter   string    `json:"adapter"`
-		CreatedAt time.Time `json:"createdAt"`
-		UpdatedAt time.Time `json:"updatedAt"`
-		Balance   float64   `json:"balance"`
+		ID             uuid.UUID  `json:"id"`
+		Name           string     `json:"name"`
+		OffBudget      *bool      `json:"offBudget"`
+		Category       string     `json:"category"`
+		Adapter        string     `json:"adapter"`
+		CreatedAt      time.Time  `json:"createdAt"`
+		UpdatedAt      time.Time  `json:"updatedAt"`
+		DeletedAt      *time.Time `json:"deletedAt,omitempty"`
+		ClearedBalance float64    `json:"clearedBalance"`
+		WorkingBalance float64    `json:"workingBalance"`
 	}
 
 	// Adapter model.
@@ -34,14 +36,22 @@ const (
 	queryCreateAccount = `INSERT INTO accounts (id, name, off_budget, category, adapter, created_at, updated_at)` +
 		` VALUES ($1, $2, $3, $4, $5, $6, $7)`
 	queryUpdateAccount = `UPDATE accounts SET name=$1, off_budget=$2, category=$3, adapter=$4, updated_at=$5` +
-		` WHERE id=$6`
-	queryDeleteAccount       = `DELETE FROM accounts WHERE id=$1`
-	queryGetAccountForUsage  = `SELECT * FROM accounts WHERE id=$1`
-	queryGetAccountsForUsage = `SELECT * FROM accounts`
-	queryGetAccount          = `SELECT a.*, COALESCE(SUM(t.credit)-SUM(t.debit), 0) as balance FROM accounts a LEFT JOIN` +
-		` transactions t ON a.id = t.account_id AND t.cleared_at IS NOT NULL WHERE a.id=$1 GROUP BY a.id`
-	queryGetAccounts = `SELECT a.*, COALESCE(SUM(t.credit)-SUM(t.debit), 0) as balance FROM accounts a LEFT JOIN` +
-		` transactions t ON a.id = t.account_id AND t.cleared_at IS NOT NULL GROUP BY a.id ORDER BY a.created_at ASC`
+		` WHERE id=$6 AND deleted_at IS NULL AND updated_at IS NOT DISTINCT FROM $7`
+	queryGetAccountVersion = `SELECT updated_at FROM accounts WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`
+	// queryDeleteAccount moves an account and its transactions to the trash, at the same time so they are restored
+	// together.
+	queryDeleteAccount = `WITH trashed AS (UPDATE transactions SET deleted_at=$2 WHERE account_id=$1 AND` +
+		` deleted_at IS NULL) UPDATE accounts SET deleted_at=$2 WHERE id=$1 AND deleted_at IS NULL`
+	queryGetAccountForUsage  = `SELECT * FROM accounts WHERE id=$1 AND deleted_at IS NULL`
+	queryGetAccountsForUsage = `SELECT * FROM accounts WHERE deleted_at IS NULL`
+	// queryAccountsWithBalances selects accounts a along with their cleared and working balances.
+	queryAccountsWithBalances = `SELECT a.*, COALESCE(SUM(t.credit) FILTER (WHERE t.status <> 'uncleared')` +
+		` - SUM(t.debit) FILTER (WHERE t.status <> 'uncleared'), 0) as cleared_balance,` +
+		` COALESCE(SUM(t.credit)-SUM(t.debit), 0) as working_balance FROM accounts a LEFT JOIN` +
+		` transactions t ON a.id = t.account_id AND t.deleted_at IS NULL`
+	queryGetAccount  = queryAccountsWithBalances + ` WHERE a.id=$1 AND a.deleted_at IS NULL GROUP BY a.id`
+	queryGetAccounts = queryAccountsWithBalances + ` WHERE a.deleted_at IS NULL GROUP BY a.id` +
+		` ORDER BY a.created_at ASC`
 )
 
 func (h *Handler) CreateAccount(w http.ResponseWriter, r *http.Request) {
@@ -98,7 +108,26 @@ func (h *Handler) UpdateAccount(w http.ResponseWriter, r *http.Request) {
 
 	var account Account
 
-	err = json.NewDecoder(r.Body).Decode(&account)
+	err = h.db.QueryRow(r.Context(), queryGetAccountForUsage, accountID).Scan(&account.ID, &account.Name,
+		&account.OffBudget, &account.Category, &account.Adapter, &account.CreatedAt, &account.UpdatedAt,
+		&account.DeletedAt)
+	if err != nil {
+		slog.Error("error getting account from database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	if !matchesIfMatch(r, account.UpdatedAt) {
+		slog.Error("error matching account version", "error", errPreconditionFailed)
+		buildErrorResponse(w, errPreconditionFailed.Error(), http.StatusPreconditionFailed)
+
+		return
+	}
+
+	version := account.UpdatedAt
+
+	_, err = decodeMergePatch(r.Body, &account)
 	if err != nil {
 		slog.Error("error decoding update account request", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
@@ -108,8 +137,8 @@ func (h *Handler) UpdateAccount(w http.ResponseWriter, r *http.Request) {
 
 	account.UpdatedAt = time.Now()
 
-	_, err = h.db.Exec(r.Context(), queryUpdateAccount,
-		account.Name, account.OffBudget, account.Category, account.Adapter, account.UpdatedAt, accountID)
+	result, err := h.db.Exec(r.Context(), queryUpdateAccount,
+		account.Name, account.OffBudget, account.Category, account.Adapter, account.UpdatedAt, accountID, version)
 	if err != nil {
 		slog.Error("error updating account in database", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -117,6 +146,13 @@ func (h *Handler) UpdateAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if result.RowsAffected() == 0 {
+		slog.Error("error updating account in database", "error", errPreconditionFailed)
+		buildErrorResponse(w, errPreconditionFailed.Error(), http.StatusPreconditionFailed)
+
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -132,10 +168,14 @@ func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = h.db.Exec(r.Context(), queryDeleteAccount, accountID)
+	err = h.writeIfMatch(r, []versionQuery{lockVersion(queryGetAccountVersion, accountID)}, func(db dbExecutor) error {
+		_, err := db.Exec(r.Context(), queryDeleteAccount, accountID, time.Now())
+
+		return err //nolint: wrapcheck
+	})
 	if err != nil {
 		slog.Error("error deleting account in database", "error", err)
-		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
 
 		return
 	}
@@ -160,7 +200,7 @@ func (h *Handler) GetAccounts(w http.ResponseWriter, r *http.Request) {
 		var acc Account
 
 		err := rows.Scan(&acc.ID, &acc.Name, &acc.OffBudget, &acc.Category, &acc.Adapter,
-			&acc.CreatedAt, &acc.UpdatedAt, &acc.Balance)
+			&acc.CreatedAt, &acc.UpdatedAt, &acc.DeletedAt, &acc.ClearedBalance, &acc.WorkingBalance)
 		if err != nil {
 			slog.Error("error scanning accounts row from database", "error", err)
 			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -201,15 +241,17 @@ func (h *Handler) GetAccount(w http.ResponseWriter, r *http.Request) {
 	var account Account
 
 	err = h.db.QueryRow(r.Context(), queryGetAccount, accountID).Scan(&account.ID, &account.Name, &account.OffBudget,
-		&account.Category, &account.Adapter, &account.CreatedAt, &account.UpdatedAt, &account.Balance)
+		&account.Category, &account.Adapter, &account.CreatedAt, &account.UpdatedAt, &account.DeletedAt,
+		&account.ClearedBalance, &account.WorkingBalance)
 	if err != nil {
 		slog.Error("error getting account from database", "error", err)
-		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
 
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(account.UpdatedAt))
 	w.WriteHeader(http.StatusOK)
 
 	err = json.NewEncoder(w).Encode(account)
@@ -18,8 +18,10 @@ var (
 	testCategory    = "CC"
 	testAdapter     = "icici"
 	testAccountTime = time.Now()
-	accountRowCols  = []string{"id", "name", "off_budget", "category", "adapter", "created_at", "updated_at"}
-	accountsRowCols = []string{"id", "name", "off_budget", "category", "adapter", "created_at", "updated_at", "balance"}
+	accountRowCols  = []string{"id", "name", "off_budget", "category", "adapter", "created_at", "updated_at",
+		"deleted_at"}
+	accountsRowCols = []string{"id", "name", "off_budget", "category", "adapter", "created_at", "updated_at",
+		"deleted_at", "cleared_balance", "working_balance"}
 )
 
 func TestCreateAccount(t *testing.T) {
@@ -76,7 +78,11 @@ func TestUpdateAccount(t *testing.T) {
 		},
 		{
 			"error due to bad request", http.MethodPatch, "/v1/accounts/" + testAccountID.String(), true, strings.NewReader("invalid-body"),
-			nil, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM accounts").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(accountRowCols).
+					AddRow(testAccountID, "Old name", &testOffBudget, testCategory, testAdapter, testAccountTime, testAccountTime, testNullTime))
+			},
 			http.StatusBadRequest, "invalid character",
 		},
 		{
@@ -85,8 +91,10 @@ func TestUpdateAccount(t *testing.T) {
 				`,"category":"` + testCategory + `","adapter":"` + testAdapter + `"}`),
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM accounts").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(accountRowCols).
+					AddRow(testAccountID, "Old name", &testOffBudget, testCategory, testAdapter, testAccountTime, testAccountTime, testNullTime))
 				mock.ExpectExec("UPDATE accounts").WithArgs(
-					testAccountName, &testOffBudget, testCategory, testAdapter, pgxmock.AnyArg(), testAccountID,
+					testAccountName, &testOffBudget, testCategory, testAdapter, pgxmock.AnyArg(), testAccountID, testAccountTime,
 				).WillReturnError(pgx.ErrTxClosed)
 			},
 			http.StatusInternalServerError, "tx is closed",
@@ -97,12 +105,94 @@ func TestUpdateAccount(t *testing.T) {
 				`,"category":"` + testCategory + `","adapter":"` + testAdapter + `"}`),
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM accounts").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(accountRowCols).
+					AddRow(testAccountID, "Old name", &testOffBudget, testCategory, testAdapter, testAccountTime, testAccountTime, testNullTime))
+				mock.ExpectExec("UPDATE accounts").WithArgs(
+					testAccountName, &testOffBudget, testCategory, testAdapter, pgxmock.AnyArg(), testAccountID, testAccountTime,
+				).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			http.StatusNoContent, "",
+		},
+		{
+			"error due to account not found", http.MethodPatch, "/v1/accounts/" + testAccountID.String(), true, strings.NewReader(`{"name":"x"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM accounts").WithArgs(testAccountID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusNotFound, "no rows",
+		},
+		{
+			"error due to unknown field", http.MethodPatch, "/v1/accounts/" + testAccountID.String(), true, strings.NewReader(`{"nmae":"x"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM accounts").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(accountRowCols).
+					AddRow(testAccountID, "Old name", &testOffBudget, testCategory, testAdapter, testAccountTime, testAccountTime, testNullTime))
+			},
+			http.StatusBadRequest, "unknown field",
+		},
+		{
+			"success updating only supplied fields", http.MethodPatch, "/v1/accounts/" + testAccountID.String(), true,
+			strings.NewReader(`{"name":"` + testAccountName + `"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM accounts").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(accountRowCols).
+					AddRow(testAccountID, "Old name", &testOffBudget, testCategory, testAdapter, testAccountTime, testAccountTime, testNullTime))
+				mock.ExpectExec("UPDATE accounts").WithArgs(
+					testAccountName, &testOffBudget, testCategory, testAdapter, pgxmock.AnyArg(), testAccountID, testAccountTime,
+				).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			http.StatusNoContent, "",
+		},
+		{
+			"success keeping stored version when body has updatedAt", http.MethodPatch,
+			"/v1/accounts/" + testAccountID.String(), true,
+			strings.NewReader(`{"name":"` + testAccountName + `","updatedAt":"2020-01-01T00:00:00Z"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM accounts").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(accountRowCols).
+					AddRow(testAccountID, "Old name", &testOffBudget, testCategory, testAdapter, testAccountTime, testAccountTime, testNullTime))
+				mock.ExpectExec("UPDATE accounts").WithArgs(
+					testAccountName, &testOffBudget, testCategory, testAdapter, pgxmock.AnyArg(), testAccountID, testAccountTime,
+				).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			http.StatusNoContent, "",
+		},
+		{
+			"error due to stale if-match", http.MethodPatch, "/v1/accounts/" + testAccountID.String(), true,
+			strings.NewReader(`{"name":"` + testAccountName + `"}`),
+			http.Header{"If-Match": []string{`"stale"`}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM accounts").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(accountRowCols).
+					AddRow(testAccountID, "Old name", &testOffBudget, testCategory, testAdapter, testAccountTime, testAccountTime, testNullTime))
+			},
+			http.StatusPreconditionFailed, "resource has been modified",
+		},
+		{
+			"success updating account with matching if-match", http.MethodPatch, "/v1/accounts/" + testAccountID.String(), true,
+			strings.NewReader(`{"name":"` + testAccountName + `"}`),
+			http.Header{"If-Match": []string{etag(testAccountTime)}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM accounts").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(accountRowCols).
+					AddRow(testAccountID, "Old name", &testOffBudget, testCategory, testAdapter, testAccountTime, testAccountTime, testNullTime))
 				mock.ExpectExec("UPDATE accounts").WithArgs(
-					testAccountName, &testOffBudget, testCategory, testAdapter, pgxmock.AnyArg(), testAccountID,
+					testAccountName, &testOffBudget, testCategory, testAdapter, pgxmock.AnyArg(), testAccountID, testAccountTime,
 				).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 			},
 			http.StatusNoContent, "",
 		},
+		{
+			"error due to concurrent update", http.MethodPatch, "/v1/accounts/" + testAccountID.String(), true,
+			strings.NewReader(`{"name":"` + testAccountName + `"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM accounts").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(accountRowCols).
+					AddRow(testAccountID, "Old name", &testOffBudget, testCategory, testAdapter, testAccountTime, testAccountTime, testNullTime))
+				mock.ExpectExec("UPDATE accounts").WithArgs(
+					testAccountName, &testOffBudget, testCategory, testAdapter, pgxmock.AnyArg(), testAccountID, testAccountTime,
+				).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			http.StatusPreconditionFailed, "resource has been modified",
+		},
 	}
 	executeTests(t, tests)
 }
@@ -124,7 +214,9 @@ func TestDeleteAccount(t *testing.T) {
 			nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec("DELETE FROM accounts").WithArgs(testAccountID).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE accounts SET deleted_at").WithArgs(testAccountID, pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
 			},
 			http.StatusInternalServerError, "tx is closed",
 		},
@@ -133,7 +225,35 @@ func TestDeleteAccount(t *testing.T) {
 			nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec("DELETE FROM accounts").WithArgs(testAccountID).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE accounts SET deleted_at").WithArgs(testAccountID, pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusNoContent, "",
+		},
+		{
+			"error due to stale if-match", http.MethodDelete, "/v1/accounts/" + testAccountID.String(), true,
+			nil,
+			http.Header{"If-Match": []string{`"stale"`}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT updated_at FROM accounts").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows([]string{"updated_at"}).
+					AddRow(&testAccountTime))
+				mock.ExpectRollback()
+			},
+			http.StatusPreconditionFailed, "resource has been modified",
+		},
+		{
+			"success deleting account with matching if-match", http.MethodDelete, "/v1/accounts/" + testAccountID.String(),
+			true, nil,
+			http.Header{"If-Match": []string{etag(testAccountTime)}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT updated_at FROM accounts .+ FOR UPDATE").WithArgs(testAccountID).
+					WillReturnRows(pgxmock.NewRows([]string{"updated_at"}).AddRow(&testAccountTime))
+				mock.ExpectExec("UPDATE accounts SET deleted_at").WithArgs(testAccountID, pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
 			},
 			http.StatusNoContent, "",
 		},
@@ -160,7 +280,7 @@ func TestGetAccounts(t *testing.T) {
 			"error scanning accounts rows from db", http.MethodGet, "/v1/accounts", true, nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectQuery("SELECT *").WillReturnRows(pgxmock.NewRows(accountsRowCols).AddRow("invalid", "ok", "false", "category", "adapter", "bad-time", "bad-time", "bad-value"))
+				mock.ExpectQuery("SELECT *").WillReturnRows(pgxmock.NewRows(accountsRowCols).AddRow("invalid", "ok", "false", "category", "adapter", "bad-time", "bad-time", testNullTime, "bad-value", "bad-value"))
 			},
 			http.StatusInternalServerError, "Scanning value error",
 		},
@@ -177,7 +297,7 @@ func TestGetAccounts(t *testing.T) {
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WillReturnRows(pgxmock.NewRows(accountsRowCols).AddRow(testAccountID.String(), testAccountName, &testOffBudget, testCategory,
-					testAdapter, testAccountTime, testAccountTime, testAmount))
+					testAdapter, testAccountTime, testAccountTime, testNullTime, testAmount, testAmount))
 			},
 			http.StatusOK, testAccountID.String(),
 		},
@@ -212,9 +332,18 @@ func TestGetAccount(t *testing.T) {
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(accountsRowCols).AddRow(testAccountID.String(), testAccountName, &testOffBudget, testCategory,
-					testAdapter, testAccountTime, testAccountTime, testAmount))
+					testAdapter, testAccountTime, testAccountTime, testNullTime, testAmount, 50.0))
 			},
-			http.StatusOK, testAccountID.String(),
+			http.StatusOK, `"clearedBalance":42.69,"workingBalance":50`,
+		},
+		{
+			"error due to account not found", http.MethodGet, "/v1/accounts/" + testAccountID.String(), true,
+			nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT *").WithArgs(testAccountID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusNotFound, "no rows",
 		},
 	}
 	executeTests(t, tests)
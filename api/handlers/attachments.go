@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+	"vitta/storage"
+
+	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Attachment model.
+type Attachment struct {
+	ID            uuid.UUID `json:"id"`
+	TransactionID uuid.UUID `json:"transactionId"`
+	FileName      string    `json:"fileName"`
+	ContentType   string    `json:"contentType"`
+	Size          int64     `json:"size"`
+	Hash          string    `json:"hash"`
+	StorageKey    string    `json:"-"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// sniffLength is the number of bytes used for detecting the content type.
+const sniffLength = 512
+
+var errAttachmentTransactionNotFound = errors.New("transaction not found for attachment")
+
+const (
+	queryCreateAttachment = `INSERT INTO attachments (id, transaction_id, file_name, content_type, size, hash,` +
+		` storage_key, created_at, updated_at) SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9 WHERE EXISTS` +
+		` (SELECT 1 FROM transactions WHERE account_id=$10 AND id=$2 AND deleted_at IS NULL)`
+	queryDeleteAttachment = `DELETE FROM attachments AS a USING transactions AS t WHERE a.transaction_id = t.id AND` +
+		` t.account_id=$1 AND t.id=$2 AND a.id=$3 RETURNING a.storage_key`
+	queryGetAttachments = `SELECT a.* FROM attachments AS a JOIN transactions AS t ON a.transaction_id = t.id` +
+		` WHERE t.account_id=$1 AND t.id=$2 AND t.deleted_at IS NULL ORDER BY a.created_at ASC`
+	queryGetAttachment = `SELECT a.* FROM attachments AS a JOIN transactions AS t ON a.transaction_id = t.id` +
+		` WHERE t.account_id=$1 AND t.id=$2 AND t.deleted_at IS NULL AND a.id=$3`
+	// queryDeleteAttachmentsOf removes attachments of the transactions in the deleted CTE and returns their keys.
+	queryDeleteAttachmentsOf = `, files AS (DELETE FROM attachments WHERE transaction_id IN (SELECT id FROM deleted)` +
+		` RETURNING storage_key) SELECT storage_key FROM files`
+)
+
+func (h *Handler) CreateAttachment(w http.ResponseWriter, r *http.Request) { //nolint: funlen,cyclop
+	id := r.PathValue("id")
+	tID := r.PathValue("tId")
+
+	accountID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing account id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	transactionID, err := uuid.Parse(tID)
+	if err != nil {
+		slog.Error("error parsing transaction id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.cfg.UploadMemoryLimit)
+
+	err = r.ParseMultipartForm(h.cfg.UploadMemoryLimit)
+	if err != nil {
+		slog.Error("error parsing multipart form", "error", err)
+
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			buildErrorResponse(w, err.Error(), http.StatusRequestEntityTooLarge)
+
+			return
+		}
+
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		slog.Error("error getting file", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffLength)
+
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		slog.Error("error reading file", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	attachment := Attachment{
+		TransactionID: transactionID,
+		FileName:      header.Filename,
+		ContentType:   http.DetectContentType(head[:n]),
+	}
+
+	attachment.ID, err = uuid.NewV7()
+	if err != nil {
+		slog.Error("error creating attachment id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	attachment.StorageKey = attachment.ID.String()
+	hasher := sha256.New()
+
+	attachment.Size, err = h.storage.Put(r.Context(), attachment.StorageKey,
+		io.TeeReader(io.MultiReader(bytes.NewReader(head[:n]), file), hasher))
+	if err != nil {
+		slog.Error("error storing attachment", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	attachment.Hash = hex.EncodeToString(hasher.Sum(nil))
+	attachment.CreatedAt = time.Now()
+	attachment.UpdatedAt = attachment.CreatedAt
+
+	result, err := h.db.Exec(r.Context(), queryCreateAttachment, attachment.ID, attachment.TransactionID,
+		attachment.FileName, attachment.ContentType, attachment.Size, attachment.Hash, attachment.StorageKey,
+		attachment.CreatedAt, attachment.UpdatedAt, accountID)
+	if err == nil && result.RowsAffected() == 0 {
+		err = errAttachmentTransactionNotFound
+	}
+
+	if err != nil {
+		slog.Error("error creating attachment in database", "error", err)
+		deleteAttachmentFiles(r.Context(), h.storage, []string{attachment.StorageKey})
+
+		code := http.StatusInternalServerError
+		if errors.Is(err, errAttachmentTransactionNotFound) {
+			code = http.StatusNotFound
+		}
+
+		buildErrorResponse(w, err.Error(), code)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	err = json.NewEncoder(w).Encode(attachment)
+	if err != nil {
+		slog.Error("error encoding attachment response", "error", err)
+	}
+}
+
+func (h *Handler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	accountID, transactionID, attachmentID, err := parseAttachmentPath(r)
+	if err != nil {
+		slog.Error("error parsing attachment path", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	err = h.deleteReturningAttachments(r.Context(), queryDeleteAttachment, accountID, transactionID, attachmentID)
+	if err != nil {
+		slog.Error("error deleting attachment in database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) GetAttachments(w http.ResponseWriter, r *http.Request) { //nolint: funlen
+	id := r.PathValue("id")
+	tID := r.PathValue("tId")
+
+	accountID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing account id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	transactionID, err := uuid.Parse(tID)
+	if err != nil {
+		slog.Error("error parsing transaction id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	rows, err := h.db.Query(r.Context(), queryGetAttachments, accountID, transactionID)
+	if err != nil {
+		slog.Error("error getting attachments from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+	defer rows.Close()
+
+	attachments := []Attachment{}
+
+	for rows.Next() {
+		var attachment Attachment
+
+		err := rows.Scan(&attachment.ID, &attachment.TransactionID, &attachment.FileName, &attachment.ContentType,
+			&attachment.Size, &attachment.Hash, &attachment.StorageKey, &attachment.CreatedAt, &attachment.UpdatedAt)
+		if err != nil {
+			slog.Error("error scanning attachments row from database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error reading attachments rows from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(attachments)
+	if err != nil {
+		slog.Error("error encoding attachments response", "error", err)
+	}
+}
+
+func (h *Handler) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	accountID, transactionID, attachmentID, err := parseAttachmentPath(r)
+	if err != nil {
+		slog.Error("error parsing attachment path", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var attachment Attachment
+
+	err = h.db.QueryRow(r.Context(), queryGetAttachment, accountID, transactionID, attachmentID).Scan(&attachment.ID,
+		&attachment.TransactionID, &attachment.FileName, &attachment.ContentType, &attachment.Size, &attachment.Hash,
+		&attachment.StorageKey, &attachment.CreatedAt, &attachment.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		slog.Error("error finding attachment", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusNotFound)
+
+		return
+	} else if err != nil {
+		slog.Error("error getting attachment from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	content, err := h.storage.Get(r.Context(), attachment.StorageKey)
+	if err != nil {
+		slog.Error("error reading attachment from storage", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment",
+		map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", `"`+attachment.Hash+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	_, err = io.Copy(w, content)
+	if err != nil {
+		slog.Error("error writing attachment response", "error", err)
+	}
+}
+
+// deleteReturningAttachments runs a delete query returning storage keys and removes those files from storage.
+func (h *Handler) deleteReturningAttachments(ctx context.Context, query string, args ...any) error {
+	keys, err := queryStorageKeys(ctx, h.db, query, args...)
+	if err != nil {
+		return err
+	}
+
+	deleteAttachmentFiles(ctx, h.storage, keys)
+
+	return nil
+}
+
+// queryStorageKeys runs a delete query returning the storage keys of the attachments it removed.
+func queryStorageKeys(ctx context.Context, db dbExecutor, query string, args ...any) ([]string, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting rows: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []string{}
+
+	for rows.Next() {
+		var key string
+
+		err := rows.Scan(&key)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning storage key: %w", err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error deleting rows: %w", err)
+	}
+
+	return keys, nil
+}
+
+func deleteAttachmentFiles(ctx context.Context, store storage.Storage, keys []string) {
+	for _, key := range keys {
+		err := store.Delete(ctx, key)
+		if err != nil {
+			slog.Error("error deleting attachment from storage", "error", err, "key", key)
+		}
+	}
+}
+
+func parseAttachmentPath(r *http.Request) (uuid.UUID, uuid.UUID, uuid.UUID, error) {
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, uuid.Nil, fmt.Errorf("error parsing account id: %w", err)
+	}
+
+	transactionID, err := uuid.Parse(r.PathValue("tId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, uuid.Nil, fmt.Errorf("error parsing transaction id: %w", err)
+	}
+
+	attachmentID, err := uuid.Parse(r.PathValue("aId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, uuid.Nil, fmt.Errorf("error parsing attachment id: %w", err)
+	}
+
+	return accountID, transactionID, attachmentID, nil
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testAttachmentID      = uuid.MustParse("01927f3e-6ecf-7091-987f-8aa23adcda13")
+	testAttachmentContent = "%PDF-1.4 some receipt"
+	testAttachmentHash    = "3f1b4e7bbb8ab1fcf3b7a6dd36c12c48e4a1c40c2a0a2efcc5eb2c16e8f2e6f0"
+	attachmentRowCols     = []string{"id", "transaction_id", "file_name", "content_type", "size", "hash",
+		"storage_key", "created_at", "updated_at"}
+	testAttachmentsPath = "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String() + "/attachments"
+	testAttachmentPath  = testAttachmentsPath + "/" + testAttachmentID.String()
+)
+
+func TestCreateAttachment(t *testing.T) {
+	sampleBytes1, ctype1 := getMockAttachment(t, testAttachmentContent)
+	sampleBytes2, ctype2 := getMockAttachment(t, testAttachmentContent)
+	sampleBytes3, ctype3 := getMockAttachment(t, testAttachmentContent)
+	sampleBytes4, ctype4 := getMockAttachment(t, strings.Repeat("a", 1048577))
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodPost, testAttachmentsPath, false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to invalid account id", http.MethodPost, "/v1/accounts/invalid-account-id/transactions/" + testTransactionID.String() + "/attachments", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error due to bad transaction id", http.MethodPost, "/v1/accounts/" + testAccountID.String() + "/transactions/invalid-uuid/attachments", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error parsing multi part form", http.MethodPost, testAttachmentsPath, true, nil,
+			nil, nil,
+			http.StatusBadRequest, "request Content-Type isn't multipart/form-data",
+		},
+		{
+			"error due to file too large", http.MethodPost, testAttachmentsPath, true,
+			sampleBytes4, ctype4, nil,
+			http.StatusRequestEntityTooLarge, "request body too large",
+		},
+		{
+			"error inserting attachment to database", http.MethodPost, testAttachmentsPath, true,
+			sampleBytes1, ctype1,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("INSERT INTO attachments").WithArgs(pgxmock.AnyArg(), testTransactionID, "receipt.pdf",
+					"application/pdf", int64(len(testAttachmentContent)), pgxmock.AnyArg(), pgxmock.AnyArg(),
+					pgxmock.AnyArg(), pgxmock.AnyArg(), testAccountID).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error due to transaction not found", http.MethodPost, testAttachmentsPath, true,
+			sampleBytes2, ctype2,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("INSERT INTO attachments").WithArgs(pgxmock.AnyArg(), testTransactionID, "receipt.pdf",
+					"application/pdf", int64(len(testAttachmentContent)), pgxmock.AnyArg(), pgxmock.AnyArg(),
+					pgxmock.AnyArg(), pgxmock.AnyArg(), testAccountID).WillReturnResult(pgxmock.NewResult("INSERT", 0))
+			},
+			http.StatusNotFound, "transaction not found",
+		},
+		{
+			"success creating attachment", http.MethodPost, testAttachmentsPath, true,
+			sampleBytes3, ctype3,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("INSERT INTO attachments").WithArgs(pgxmock.AnyArg(), testTransactionID, "receipt.pdf",
+					"application/pdf", int64(len(testAttachmentContent)), pgxmock.AnyArg(), pgxmock.AnyArg(),
+					pgxmock.AnyArg(), pgxmock.AnyArg(), testAccountID).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			},
+			http.StatusCreated, `"contentType":"application/pdf"`,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestDeleteAttachment(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodDelete, testAttachmentPath, false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad attachment id", http.MethodDelete, testAttachmentsPath + "/invalid-uuid", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "error parsing attachment id",
+		},
+		{
+			"error deleting attachment in database", http.MethodDelete, testAttachmentPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("DELETE FROM attachments").WithArgs(testAccountID, testTransactionID, testAttachmentID).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error scanning storage key", http.MethodDelete, testAttachmentPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("DELETE FROM attachments").WithArgs(testAccountID, testTransactionID, testAttachmentID).
+					WillReturnRows(pgxmock.NewRows([]string{"storage_key"}).AddRow(42))
+			},
+			http.StatusInternalServerError, "error scanning storage key",
+		},
+		{
+			"success deleting attachment", http.MethodDelete, testAttachmentPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("DELETE FROM attachments").WithArgs(testAccountID, testTransactionID, testAttachmentID).
+					WillReturnRows(pgxmock.NewRows([]string{"storage_key"}).AddRow(testAttachmentID.String()))
+			},
+			http.StatusNoContent, "",
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestGetAttachments(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, testAttachmentsPath, false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad transaction id", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions/invalid-uuid/attachments", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error getting attachments from db", http.MethodGet, testAttachmentsPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT a.*").WithArgs(testAccountID, testTransactionID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusInternalServerError, "no rows",
+		},
+		{
+			"error scanning attachments rows from db", http.MethodGet, testAttachmentsPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT a.*").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(attachmentRowCols).
+					AddRow("invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid"))
+			},
+			http.StatusInternalServerError, "Scanning value error",
+		},
+		{
+			"error reading attachments rows from db", http.MethodGet, testAttachmentsPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT a.*").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(attachmentRowCols).
+					RowError(0, errors.New("some error in db")))
+			},
+			http.StatusInternalServerError, "some error in db",
+		},
+		{
+			"success", http.MethodGet, testAttachmentsPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT a.*").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(attachmentRowCols).
+					AddRow(testAttachmentID, testTransactionID, "receipt.pdf", "application/pdf", int64(len(testAttachmentContent)),
+						testAttachmentHash, testAttachmentID.String(), testAccountTime, testAccountTime))
+			},
+			http.StatusOK, testAttachmentHash,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestGetAttachment(t *testing.T) {
+	missingAttachmentID := uuid.MustParse("01927f3e-6ecf-7091-987f-8aa23adcda14")
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, testAttachmentPath, false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad account id", http.MethodGet, "/v1/accounts/invalid-uuid/transactions/" + testTransactionID.String() + "/attachments/" + testAttachmentID.String(), true, nil,
+			nil, nil,
+			http.StatusBadRequest, "error parsing account id",
+		},
+		{
+			"error due to attachment not found", http.MethodGet, testAttachmentPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT a.*").WithArgs(testAccountID, testTransactionID, testAttachmentID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusNotFound, "no rows",
+		},
+		{
+			"error getting attachment from db", http.MethodGet, testAttachmentPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT a.*").WithArgs(testAccountID, testTransactionID, testAttachmentID).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error reading attachment from storage", http.MethodGet, testAttachmentsPath + "/" + missingAttachmentID.String(), true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT a.*").WithArgs(testAccountID, testTransactionID, missingAttachmentID).WillReturnRows(pgxmock.NewRows(attachmentRowCols).
+					AddRow(missingAttachmentID, testTransactionID, "receipt.pdf", "application/pdf", int64(len(testAttachmentContent)),
+						testAttachmentHash, missingAttachmentID.String(), testAccountTime, testAccountTime))
+			},
+			http.StatusInternalServerError, "error opening file",
+		},
+		{
+			"success", http.MethodGet, testAttachmentPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT a.*").WithArgs(testAccountID, testTransactionID, testAttachmentID).WillReturnRows(pgxmock.NewRows(attachmentRowCols).
+					AddRow(testAttachmentID, testTransactionID, "receipt.pdf", "application/pdf", int64(len(testAttachmentContent)),
+						testAttachmentHash, testAttachmentID.String(), testAccountTime, testAccountTime))
+			},
+			http.StatusOK, testAttachmentContent,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func getMockAttachment(t *testing.T, content string) (io.Reader, http.Header) {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile("file", "receipt.pdf")
+	require.NoError(t, err)
+
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+
+	err = writer.Close()
+	require.NoError(t, err)
+
+	return body, http.Header{"Content-Type": []string{writer.FormDataContentType()}}
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// AuditEntry model. Entries are recorded by database triggers on every change.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	Entity    string          `json:"entity"`
+	EntityID  uuid.UUID       `json:"entityId"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	Actor     *string         `json:"actor"`
+	RequestID *string         `json:"requestId"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// auditFilter holds the filters of an audit log listing.
+type auditFilter struct {
+	Entity    string
+	EntityID  *uuid.UUID
+	Action    string
+	Actor     string
+	RequestID string
+	From      *time.Time
+	To        *time.Time
+	Cursor    *int64
+}
+
+const (
+	auditColumns = `id, entity, entity_id, action, before, after, actor, request_id, created_at`
+	// queryGetAudit lists the entries matching the filters in $1 to $7, newest first, before the cursor in $8.
+	queryGetAudit = `SELECT ` + auditColumns + ` FROM audit_log WHERE ($1 = '' OR entity = $1)` +
+		` AND ($2::uuid IS NULL OR entity_id = $2) AND ($3 = '' OR action = $3) AND ($4 = '' OR actor = $4)` +
+		` AND ($5 = '' OR request_id = $5) AND ($6::timestamp IS NULL OR created_at >= $6)` +
+		` AND ($7::timestamp IS NULL OR created_at < $7) AND ($8::bigint IS NULL OR id < $8)` +
+		` ORDER BY id DESC LIMIT $9`
+	// queryGetHistory lists the entries of the entity $2 recorded under any of the names in $1, oldest first.
+	queryGetHistory = `SELECT ` + auditColumns + ` FROM audit_log WHERE entity = ANY($1) AND entity_id=$2` +
+		` ORDER BY id`
+)
+
+// GetAudit lists the audit log, newest first.
+func (h *Handler) GetAudit(w http.ResponseWriter, r *http.Request) { //nolint: funlen
+	limit := listLimit(r)
+
+	filter, err := parseAuditFilter(r.URL.Query())
+	if err != nil {
+		slog.Error("error parsing audit filter", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	// One extra row is fetched to know whether a next page exists.
+	rows, err := h.db.Query(r.Context(), queryGetAudit, filter.Entity, filter.EntityID, filter.Action, filter.Actor,
+		filter.RequestID, filter.From, filter.To, filter.Cursor, limit+1)
+	if err != nil {
+		slog.Error("error getting audit log from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	entries, err := scanAuditEntries(rows)
+	if err != nil {
+		slog.Error("error reading audit log from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	result := map[string]interface{}{}
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+		result["nextCursor"] = strconv.FormatInt(entries[limit-1].ID, 10)
+	}
+
+	result["entries"] = entries
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		slog.Error("error encoding audit response", "error", err)
+	}
+}
+
+// getHistory returns a handler listing the audit entries of the entity whose id is in the idParam path value, recorded
+// under any of the given entity names.
+func (h *Handler) getHistory(idParam string, entities ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(r.PathValue(idParam))
+		if err != nil {
+			slog.Error("error parsing history id", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		rows, err := h.db.Query(r.Context(), queryGetHistory, entities, id)
+		if err != nil {
+			slog.Error("error getting history from database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		entries, err := scanAuditEntries(rows)
+		if err != nil {
+			slog.Error("error reading history from database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		err = json.NewEncoder(w).Encode(entries)
+		if err != nil {
+			slog.Error("error encoding history response", "error", err)
+		}
+	}
+}
+
+func scanAuditEntries(rows pgx.Rows) ([]AuditEntry, error) {
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+
+	for rows.Next() {
+		var entry AuditEntry
+
+		err := rows.Scan(&entry.ID, &entry.Entity, &entry.EntityID, &entry.Action, &entry.Before, &entry.After,
+			&entry.Actor, &entry.RequestID, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning audit row: %w", err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading audit rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+func parseAuditFilter(values url.Values) (auditFilter, error) {
+	filter := auditFilter{
+		Entity:    values.Get("entity"),
+		Action:    values.Get("action"),
+		Actor:     values.Get("actor"),
+		RequestID: values.Get("requestId"),
+	}
+
+	var err error
+
+	if entityID := values.Get("entityId"); entityID != "" {
+		id, err := uuid.Parse(entityID)
+		if err != nil {
+			return filter, fmt.Errorf("error parsing entity id: %w", err)
+		}
+
+		filter.EntityID = &id
+	}
+
+	filter.From, err = parseFilterDate(values, "from")
+	if err != nil {
+		return filter, err
+	}
+
+	filter.To, err = parseFilterDate(values, "to")
+	if err != nil {
+		return filter, err
+	}
+
+	if filter.To != nil {
+		to := filter.To.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		id, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("error parsing cursor: %w", err)
+		}
+
+		filter.Cursor = &id
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"vitta/config"
+	"vitta/database"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	auditRowCols = []string{"id", "entity", "entity_id", "action", "before", "after", "actor", "request_id",
+		"created_at"}
+	testAuditActor     = "vitta"
+	testAuditRequestID = "req-1"
+	testAuditTime      = time.Date(2024, 11, 5, 10, 0, 0, 0, time.UTC)
+	testAuditFrom      = time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	testAuditTo        = time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	testAuditCursor    = int64(42)
+	testNullCursor     *int64
+	testAuditArgs      = []any{"", testNullID, "", "", "", testNullTime, testNullTime, testNullCursor, 51}
+)
+
+func auditRows() *pgxmock.Rows {
+	return pgxmock.NewRows(auditRowCols).
+		AddRow(int64(43), "accounts", testAccountID, "update", []byte(`{"name":"old"}`), []byte(`{"name":"new"}`),
+			&testAuditActor, &testAuditRequestID, testAuditTime).
+		AddRow(testAuditCursor, "accounts", testAccountID, "create", []byte(nil), []byte(`{"name":"old"}`),
+			&testAuditActor, &testAuditRequestID, testAuditTime)
+}
+
+func TestGetAudit(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, "/v1/audit", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad entity id", http.MethodGet, "/v1/audit?entityId=invalid-uuid", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "error parsing entity id",
+		},
+		{
+			"error due to bad from date", http.MethodGet, "/v1/audit?from=01-11-2024", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "error parsing from date",
+		},
+		{
+			"error due to bad cursor", http.MethodGet, "/v1/audit?cursor=abc", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "error parsing cursor",
+		},
+		{
+			"error getting audit log from database", http.MethodGet, "/v1/audit", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM audit_log").WithArgs(testAuditArgs...).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error reading audit log from database", http.MethodGet, "/v1/audit", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM audit_log").WithArgs(testAuditArgs...).WillReturnRows(pgxmock.NewRows(auditRowCols).
+					RowError(0, errors.New("some error in db")))
+			},
+			http.StatusInternalServerError, "some error in db",
+		},
+		{
+			"success with filters and next cursor", http.MethodGet, "/v1/audit?entity=accounts&entityId=" +
+				testAccountID.String() + "&action=update&actor=vitta&requestId=req-1&from=2024-11-01" +
+				"&to=2024-11-30&cursor=50&limit=1", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				cursor := int64(50)
+				mock.ExpectQuery("SELECT (.+) FROM audit_log").WithArgs("accounts", &testAccountID, "update", "vitta",
+					"req-1", &testAuditFrom, &testAuditTo, &cursor, 2).WillReturnRows(auditRows())
+			},
+			http.StatusOK, `"nextCursor":"43"`,
+		},
+		{
+			"success capping limit", http.MethodGet, "/v1/audit?limit=1000", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				args := append(append([]any{}, testAuditArgs[:len(testAuditArgs)-1]...), 501)
+				mock.ExpectQuery("SELECT (.+) FROM audit_log").WithArgs(args...).WillReturnRows(auditRows())
+			},
+			http.StatusOK, `"action":"create"`,
+		},
+		{
+			"success", http.MethodGet, "/v1/audit", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM audit_log").WithArgs(testAuditArgs...).WillReturnRows(auditRows())
+			},
+			http.StatusOK, `"action":"create","before":null,"after":{"name":"old"},"actor":"vitta","requestId":"req-1"`,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestGetHistory(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/history", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad id", http.MethodGet, "/v1/payees/invalid-uuid/history", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error getting history from database", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/history",
+			true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM audit_log").WithArgs([]string{"accounts"}, testAccountID).
+					WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success getting transaction history", http.MethodGet, "/v1/accounts/" + testAccountID.String() +
+				"/transactions/" + testTransactionID.String() + "/history", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM audit_log").WithArgs([]string{"transactions", "transaction_tags"},
+					testTransactionID).WillReturnRows(pgxmock.NewRows(auditRowCols))
+			},
+			http.StatusOK, "[]",
+		},
+		{
+			"success", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/history", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT (.+) FROM audit_log").WithArgs([]string{"accounts"}, testAccountID).
+					WillReturnRows(auditRows())
+			},
+			http.StatusOK, `"entity":"accounts","entityId":"` + testAccountID.String() + `","action":"update"`,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	tests := []struct {
+		name              string
+		method            string
+		requestID         string
+		expectedActor     string
+		expectedRequestID string
+	}{
+		{"request id from the request", http.MethodPost, "req-1", "vitta", "req-1"},
+		{"generated request id", http.MethodPatch, "", "vitta", ""},
+		{"read request without audit details", http.MethodGet, "req-1", "", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var audit database.Audit
+
+			h := &Handler{cfg: &config.Config{}}
+			handler := h.auditMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				audit = database.AuditFrom(r.Context())
+			}))
+
+			req, err := http.NewRequestWithContext(context.TODO(), tc.method, "/v1/accounts", nil)
+			require.NoError(t, err)
+			req.SetBasicAuth("vitta", "vittaT3st!")
+
+			if tc.requestID != "" {
+				req.Header.Set(requestIDHeader, tc.requestID)
+			}
+
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+
+			assert.Equal(t, tc.expectedActor, audit.Actor)
+			assert.NotEmpty(t, res.Header().Get(requestIDHeader))
+
+			if tc.expectedActor != "" {
+				assert.Equal(t, audit.RequestID, res.Header().Get(requestIDHeader))
+			}
+
+			if tc.expectedRequestID != "" {
+				assert.Equal(t, tc.expectedRequestID, audit.RequestID)
+			}
+		})
+	}
+}
@@ -1,33 +1,51 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
 	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type (
 	// Group model.
 	Group struct {
-		ID        uuid.UUID `json:"id"`
-		Name      string    `json:"name"`
-		Notes     string    `json:"notes,omitempty"`
-		CreatedAt time.Time `json:"createdAt"`
-		UpdatedAt time.Time `json:"updatedAt"`
+		ID        uuid.UUID  `json:"id"`
+		Name      string     `json:"name"`
+		Notes     string     `json:"notes,omitempty"`
+		CreatedAt time.Time  `json:"createdAt"`
+		UpdatedAt time.Time  `json:"updatedAt"`
+		DeletedAt *time.Time `json:"deletedAt,omitempty"`
+		SortIndex int        `json:"sortIndex"`
 	}
 
-	// Category model.
+	// Category model. A hidden category is left out of the budget and category lists.
 	Category struct {
-		ID        uuid.UUID `json:"id"`
-		GroupID   uuid.UUID `json:"groupId"`
-		Name      string    `json:"name"`
-		Notes     string    `json:"notes,omitempty"`
-		CreatedAt time.Time `json:"createdAt"`
-		UpdatedAt time.Time `json:"updatedAt"`
+		ID        uuid.UUID  `json:"id"`
+		GroupID   uuid.UUID  `json:"groupId"`
+		Name      string     `json:"name"`
+		Notes     string     `json:"notes,omitempty"`
+		CreatedAt time.Time  `json:"createdAt"`
+		UpdatedAt time.Time  `json:"updatedAt"`
+		DeletedAt *time.Time `json:"deletedAt,omitempty"`
+		Carryover string     `json:"carryover"`
+		Income    bool       `json:"income"`
+		Goal      *Goal      `json:"goal,omitempty"`
+		SortIndex int        `json:"sortIndex"`
+		Hidden    bool       `json:"hidden"`
+	}
+
+	// Reorder model. The ids in the order to sort them in.
+	Reorder struct {
+		IDs []uuid.UUID `json:"ids"`
 	}
 
 	// Budget model.
@@ -43,47 +61,149 @@ type (
 
 	// BudgetResult model.
 	BudgetResult struct {
-		Budgeted      float64    `json:"budgeted"`
-		Spent         float64    `json:"spent"`
-		Year          uint16     `json:"year"`
-		Month         uint8      `json:"month"`
-		CategoryID    *uuid.UUID `json:"categoryId"`
-		CategoryName  *string    `json:"categoryName"`
-		CategoryNotes *string    `json:"categoryNotes"`
-		GroupID       uuid.UUID  `json:"groupId"`
-		GroupName     string     `json:"groupName"`
-		GroupNotes    string     `json:"groupNotes"`
+		Budgeted      float64     `json:"budgeted"`
+		Spent         float64     `json:"spent"`
+		Available     float64     `json:"available"`
+		Year          uint16      `json:"year"`
+		Month         uint8       `json:"month"`
+		CategoryID    *uuid.UUID  `json:"categoryId"`
+		CategoryName  *string     `json:"categoryName"`
+		CategoryNotes *string     `json:"categoryNotes"`
+		GroupID       uuid.UUID   `json:"groupId"`
+		GroupName     string      `json:"groupName"`
+		GroupNotes    string      `json:"groupNotes"`
+		Carryover     *string     `json:"carryover"`
+		Goal          *GoalStatus `json:"goal,omitempty"`
+		UpdatedAt     *time.Time  `json:"updatedAt,omitempty"`
+	}
+
+	// BudgetSummary model. ReadyToAssign is the income received to date less what was assigned and the overspending
+	// not carried over by categories.
+	BudgetSummary struct {
+		Income        float64 `json:"income"`
+		Assigned      float64 `json:"assigned"`
+		Overspent     float64 `json:"overspent"`
+		ReadyToAssign float64 `json:"readyToAssign"`
+	}
+
+	// BudgetTotals model. The budgeted, spent and available amounts added up over categories.
+	BudgetTotals struct {
+		Budgeted  float64 `json:"budgeted"`
+		Spent     float64 `json:"spent"`
+		Available float64 `json:"available"`
+	}
+
+	// BudgetGroup model. A group of the monthly budget with its categories and their subtotals.
+	BudgetGroup struct {
+		GroupID    uuid.UUID `json:"groupId"`
+		GroupName  string    `json:"groupName"`
+		GroupNotes string    `json:"groupNotes"`
+		BudgetTotals
+		Categories []BudgetResult `json:"categories"`
 	}
 )
 
+var (
+	errReorderIDs       = errors.New("ids are required")
+	errReorderDuplicate = errors.New("ids must not repeat")
+	errReorderNotFound  = errors.New("some ids were not found")
+	errSortIndexPatch   = errors.New("sortIndex can only be changed through the order endpoints")
+)
+
 const (
-	queryCreateGroup = `INSERT INTO groups (id, name, notes, created_at, updated_at)` +
-		` VALUES ($1, $2, $3, $4, $5)`
-	queryUpdateGroup    = `UPDATE groups SET name=$1, notes=$2, updated_at=$3 WHERE id=$4`
-	queryDeleteGroup    = `DELETE FROM groups WHERE id=$1`
-	queryGetTotalGroups = `SELECT COUNT(*) as total FROM groups WHERE (name ILIKE '%' ||` +
+	// queryOnBudgetTransfer matches transactions of t whose transfer comes from or goes to an on-budget account. As
+	// budget lines only come from on-budget accounts, such transfers move money within the budget.
+	queryOnBudgetTransfer = `EXISTS (SELECT 1 FROM transactions AS o JOIN accounts AS oa ON o.account_id = oa.id` +
+		` WHERE o.transfer_id = t.transfer_id AND o.id <> t.id AND NOT oa.off_budget)`
+	// queryBudgetLines yields one row per categorised amount of the on-budget accounts, using split lines in place of
+	// split transactions. Transfers with off-budget accounts are kept as money leaving or entering the budget.
+	queryBudgetLines = `(SELECT t.category_id, t.credit, t.debit, t.date, t.account_id, t.transfer_id` +
+		` FROM transactions AS t JOIN accounts AS a ON a.id = t.account_id WHERE t.deleted_at IS NULL` +
+		` AND NOT a.off_budget AND NOT EXISTS (SELECT 1 FROM splits AS s WHERE s.transaction_id = t.id) AND NOT ` +
+		queryOnBudgetTransfer + ` UNION ALL SELECT s.category_id, s.credit, s.debit, t.date, t.account_id,` +
+		` t.transfer_id FROM splits AS s JOIN transactions AS t ON s.transaction_id = t.id JOIN accounts AS a` +
+		` ON a.id = t.account_id WHERE t.deleted_at IS NULL AND NOT a.off_budget AND NOT ` +
+		queryOnBudgetTransfer + `)`
+	// queryCreateGroup adds the group after the others.
+	queryCreateGroup = `INSERT INTO groups (id, name, notes, sort_index, created_at, updated_at)` +
+		` VALUES ($1, $2, $3, (SELECT COALESCE(MAX(sort_index), 0) + 1 FROM groups), $4, $5) RETURNING sort_index`
+	queryUpdateGroup = `UPDATE groups SET name=$1, notes=$2, updated_at=$3 WHERE id=$4` +
+		` AND deleted_at IS NULL AND updated_at IS NOT DISTINCT FROM $5`
+	queryGetGroupVersion = `SELECT updated_at FROM groups WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`
+	// queryDeleteGroup moves a group and its categories to the trash, at the same time so they are restored together.
+	queryDeleteGroup = `WITH trashed AS (UPDATE categories SET deleted_at=$2 WHERE group_id=$1 AND` +
+		` deleted_at IS NULL) UPDATE groups SET deleted_at=$2 WHERE id=$1 AND deleted_at IS NULL`
+	queryGetGroup       = `SELECT * FROM groups WHERE id=$1 AND deleted_at IS NULL`
+	queryGetTotalGroups = `SELECT COUNT(*) as total FROM groups WHERE deleted_at IS NULL AND (name ILIKE '%' ||` +
 		` COALESCE(NULLIF($1, ''), '') || '%')`
-	queryGetGroups = `SELECT * FROM groups WHERE (name ILIKE '%' || COALESCE(NULLIF($1, ''), '')` +
-		` || '%') ORDER BY created_at ASC`
-	queryCreateCategory = `INSERT INTO categories (id, group_id, name, notes, created_at, updated_at)` +
-		` VALUES ($1, $2, $3, $4, $5, $6)`
-	queryUpdateCategory     = `UPDATE categories SET name=$1, notes=$2, group_id=$3, updated_at=$4 WHERE id=$5`
-	queryDeleteCategory     = `DELETE FROM categories WHERE id=$1`
-	queryGetTotalCategories = `SELECT COUNT(*) as total FROM categories WHERE (name ILIKE '%' ||` +
-		` COALESCE(NULLIF($1, ''), '') || '%')`
-	queryGetCategories = `SELECT * FROM categories WHERE (name ILIKE '%' || COALESCE(NULLIF($1, ''), '')` +
-		` || '%') ORDER BY created_at ASC`
+	queryGetGroups = `SELECT * FROM groups WHERE deleted_at IS NULL AND (name ILIKE '%' ||` +
+		` COALESCE(NULLIF($1, ''), '') || '%') ORDER BY sort_index ASC, created_at ASC`
+	// queryReorderGroups sorts the groups in $1 in the order of the array, followed by the others in their current
+	// order, and returns the number of groups of $1 it sorted.
+	queryReorderGroups = `WITH ordered AS (SELECT g.id, o.id IS NOT NULL AS listed, row_number() OVER` +
+		` (ORDER BY o.index NULLS LAST, g.sort_index, g.created_at) AS index FROM groups AS g` +
+		` LEFT JOIN unnest($1::uuid[]) WITH ORDINALITY AS o(id, index) ON o.id = g.id WHERE g.deleted_at IS NULL),` +
+		` reordered AS (UPDATE groups SET sort_index=ordered.index, updated_at=$2 FROM ordered` +
+		` WHERE groups.id = ordered.id AND (ordered.listed OR groups.sort_index <> ordered.index)` +
+		` RETURNING ordered.listed) SELECT COUNT(*) FILTER (WHERE listed) FROM reordered`
+	// queryCreateCategory adds the category after the others of its group.
+	queryCreateCategory = `INSERT INTO categories (id, group_id, name, notes, carryover, income, goal_type,` +
+		` goal_amount, goal_date, hidden, sort_index, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7,` +
+		` $8, $9, $10, (SELECT COALESCE(MAX(sort_index), 0) + 1 FROM categories WHERE group_id=$2), $11, $12)` +
+		` RETURNING sort_index`
+	queryUpdateCategory = `UPDATE categories SET name=$1, notes=$2, group_id=$3, carryover=$4, income=$5,` +
+		` goal_type=$6, goal_amount=$7, goal_date=$8, hidden=$9, updated_at=$10 WHERE id=$11 AND deleted_at IS NULL` +
+		` AND updated_at IS NOT DISTINCT FROM $12`
+	// queryReorderCategories sorts the categories in $1 in the order of the array within their groups, followed by
+	// the others of those groups in their current order, and returns the number of categories of $1 it sorted.
+	queryReorderCategories = `WITH ordered AS (SELECT c.id, o.id IS NOT NULL AS listed, row_number() OVER` +
+		` (PARTITION BY c.group_id ORDER BY o.index NULLS LAST, c.sort_index, c.created_at) AS index` +
+		` FROM categories AS c LEFT JOIN unnest($1::uuid[]) WITH ORDINALITY AS o(id, index) ON o.id = c.id` +
+		` WHERE c.deleted_at IS NULL AND c.group_id IN (SELECT group_id FROM categories WHERE id = ANY($1))),` +
+		` reordered AS (UPDATE categories SET sort_index=ordered.index, updated_at=$2 FROM ordered` +
+		` WHERE categories.id = ordered.id AND (ordered.listed OR categories.sort_index <> ordered.index)` +
+		` RETURNING ordered.listed) SELECT COUNT(*) FILTER (WHERE listed) FROM reordered`
+	queryGetCategoryVersion = `SELECT updated_at FROM categories WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`
+	queryDeleteCategory     = `UPDATE categories SET deleted_at=$2 WHERE id=$1 AND deleted_at IS NULL`
+	queryGetCategory        = `SELECT * FROM categories WHERE id=$1 AND deleted_at IS NULL`
+	queryGetTotalCategories = `SELECT COUNT(*) as total FROM categories WHERE deleted_at IS NULL AND` +
+		` (name ILIKE '%' || COALESCE(NULLIF($1, ''), '') || '%') AND ($2 OR NOT hidden)`
+	// queryGetCategories lists the categories matching $1, with the hidden ones only when $2 is true.
+	queryGetCategories = `SELECT * FROM categories WHERE deleted_at IS NULL AND (name ILIKE '%' ||` +
+		` COALESCE(NULLIF($1, ''), '') || '%') AND ($2 OR NOT hidden) ORDER BY sort_index ASC, created_at ASC`
 	querySetBudget = `INSERT INTO budgets (id, category_id, year, month, budgeted, created_at,` +
-		` updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (year, month, category_id) DO UPDATE SET budgeted=$5`
+		` updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (year, month, category_id) DO UPDATE SET budgeted=$5,` +
+		` updated_at=$7`
+	// queryGetBudgetVersion is the version of a monthly budget, the last update of any of the categories the budget
+	// shows, so it matches the ETag of the budget. It locks the budgets of the month.
+	queryGetBudgetVersion = `SELECT MAX(updated_at) FROM (SELECT b.updated_at FROM budgets AS b JOIN categories AS cg` +
+		` ON cg.id = b.category_id AND cg.deleted_at IS NULL AND NOT cg.income AND NOT cg.hidden JOIN groups AS g` +
+		` ON g.id = cg.group_id AND g.deleted_at IS NULL WHERE b.year=$1 AND b.month=$2 FOR UPDATE OF b) AS versions`
 	queryGetBudget = `SELECT COALESCE(budgets.budgeted, 0) AS budgeted, COALESCE(t.spent, 0) AS spent,` +
 		` COALESCE(budgets.year, $1) AS year, COALESCE(budgets.month, $2) AS month, cg.id AS category_id,` +
 		` cg.name AS category_name, cg.notes as category_notes, g.id AS group_id, g.name AS group_name,` +
-		` g.notes as group_notes FROM groups AS g LEFT JOIN categories AS cg` +
-		` ON cg.group_id = g.id LEFT JOIN budgets ON budgets.category_id = cg.id AND budgets.year = $1 AND` +
-		` budgets.month = $2 LEFT JOIN(SELECT category_id, SUM(credit) AS total_credit, SUM(debit) AS total_debit,` +
-		` SUM(credit - debit) AS spent FROM transactions WHERE EXTRACT(YEAR FROM cleared_at) = $1 AND` +
-		` EXTRACT(MONTH FROM cleared_at) = $2 GROUP BY category_id) AS t ON cg.id = t.category_id` +
-		` ORDER BY g.created_at ASC, cg.created_at ASC`
+		` g.notes as group_notes, cg.carryover, cg.goal_type, cg.goal_amount, cg.goal_date, budgets.updated_at` +
+		` FROM groups AS g LEFT JOIN categories AS cg` +
+		` ON cg.group_id = g.id AND cg.deleted_at IS NULL AND NOT cg.income AND NOT cg.hidden LEFT JOIN budgets` +
+		` ON budgets.category_id = cg.id AND budgets.year = $1 AND budgets.month = $2 LEFT JOIN(SELECT category_id,` +
+		` SUM(credit) AS total_credit, SUM(debit) AS total_debit, SUM(credit - debit) AS spent FROM ` +
+		queryBudgetLines + ` AS lines WHERE EXTRACT(YEAR FROM date) = $1 AND EXTRACT(MONTH FROM date) = $2` +
+		` GROUP BY category_id) AS t` +
+		` ON cg.id = t.category_id WHERE g.deleted_at IS NULL` +
+		` ORDER BY g.sort_index ASC, g.created_at ASC, g.id, cg.sort_index ASC, cg.created_at ASC`
+	// queryGetBudgetSummary returns the income received on budget and the amount assigned, in the month $1-$2 and up
+	// to its end. Uncategorised transfers with off-budget accounts fund the budget, or take from it. Income stays
+	// received and money stays assigned once its category is trashed.
+	queryGetBudgetSummary = `SELECT COALESCE(SUM(amount) FILTER (WHERE kind = 'income' AND year = $1 AND month = $2),` +
+		` 0), COALESCE(SUM(amount) FILTER (WHERE kind = 'assigned' AND year = $1 AND month = $2), 0),` +
+		` COALESCE(SUM(amount) FILTER (WHERE kind = 'income'), 0), COALESCE(SUM(amount) FILTER` +
+		` (WHERE kind = 'assigned'), 0) FROM (SELECT 'income' AS kind, EXTRACT(YEAR FROM lines.date)::int AS year,` +
+		` EXTRACT(MONTH FROM lines.date)::int AS month, lines.credit - lines.debit AS amount FROM ` +
+		queryBudgetLines + ` AS lines LEFT JOIN categories AS c ON c.id = lines.category_id WHERE (c.income` +
+		` OR (lines.category_id IS NULL AND lines.transfer_id IS NOT NULL))` +
+		` AND lines.date < make_date($1, $2, 1) + INTERVAL '1 month' UNION ALL SELECT 'assigned', b.year, b.month,` +
+		` b.budgeted FROM budgets AS b JOIN categories AS c ON c.id = b.category_id WHERE NOT c.income` +
+		` AND (b.year, b.month) <= ($1, $2)) AS summary`
 )
 
 func (h *Handler) CreateGroup(w http.ResponseWriter, r *http.Request) {
@@ -108,8 +228,8 @@ func (h *Handler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 	group.CreatedAt = time.Now()
 	group.UpdatedAt = group.CreatedAt
 
-	_, err = h.db.Exec(r.Context(), queryCreateGroup,
-		group.ID, group.Name, group.Notes, group.CreatedAt, group.UpdatedAt)
+	err = h.db.QueryRow(r.Context(), queryCreateGroup,
+		group.ID, group.Name, group.Notes, group.CreatedAt, group.UpdatedAt).Scan(&group.SortIndex)
 	if err != nil {
 		slog.Error("error creating group in database", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -139,7 +259,25 @@ func (h *Handler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
 
 	var group Group
 
-	err = json.NewDecoder(r.Body).Decode(&group)
+	err = scanGroup(h.db.QueryRow(r.Context(), queryGetGroup, groupID), &group)
+	if err != nil {
+		slog.Error("error getting group from database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	if !matchesIfMatch(r, group.UpdatedAt) {
+		slog.Error("error matching group version", "error", errPreconditionFailed)
+		buildErrorResponse(w, errPreconditionFailed.Error(), http.StatusPreconditionFailed)
+
+		return
+	}
+
+	version := group.UpdatedAt
+	sortIndex := group.SortIndex
+
+	_, err = decodeMergePatch(r.Body, &group)
 	if err != nil {
 		slog.Error("error decoding update group request", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
@@ -147,10 +285,17 @@ func (h *Handler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if group.SortIndex != sortIndex {
+		slog.Error("error validating update group request", "error", errSortIndexPatch)
+		buildErrorResponse(w, errSortIndexPatch.Error(), http.StatusBadRequest)
+
+		return
+	}
+
 	group.UpdatedAt = time.Now()
 
-	_, err = h.db.Exec(r.Context(), queryUpdateGroup,
-		group.Name, group.Notes, group.UpdatedAt, groupID)
+	result, err := h.db.Exec(r.Context(), queryUpdateGroup,
+		group.Name, group.Notes, group.UpdatedAt, groupID, version)
 	if err != nil {
 		slog.Error("error updating group in database", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -158,6 +303,13 @@ func (h *Handler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if result.RowsAffected() == 0 {
+		slog.Error("error updating group in database", "error", errPreconditionFailed)
+		buildErrorResponse(w, errPreconditionFailed.Error(), http.StatusPreconditionFailed)
+
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -173,10 +325,14 @@ func (h *Handler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = h.db.Exec(r.Context(), queryDeleteGroup, groupID)
+	err = h.writeIfMatch(r, []versionQuery{lockVersion(queryGetGroupVersion, groupID)}, func(db dbExecutor) error {
+		_, err := db.Exec(r.Context(), queryDeleteGroup, groupID, time.Now())
+
+		return err //nolint: wrapcheck
+	})
 	if err != nil {
 		slog.Error("error deleting group in database", "error", err)
-		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
 
 		return
 	}
@@ -185,6 +341,37 @@ func (h *Handler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (h *Handler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	groupID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing group id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var group Group
+
+	err = scanGroup(h.db.QueryRow(r.Context(), queryGetGroup, groupID), &group)
+	if err != nil {
+		slog.Error("error getting group from database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(group.UpdatedAt))
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(group)
+	if err != nil {
+		slog.Error("error encoding group response", "error", err)
+	}
+}
+
 func (h *Handler) GetGroups(w http.ResponseWriter, r *http.Request) { //nolint: funlen
 	searchQuery := r.URL.Query().Get("q")
 
@@ -223,7 +410,7 @@ func (h *Handler) GetGroups(w http.ResponseWriter, r *http.Request) { //nolint:
 	for rows.Next() {
 		var group Group
 
-		err := rows.Scan(&group.ID, &group.Name, &group.Notes, &group.CreatedAt, &group.UpdatedAt)
+		err := scanGroup(rows, &group)
 		if err != nil {
 			slog.Error("error scanning groups row from database", "error", err)
 			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -261,6 +448,18 @@ func (h *Handler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if category.Carryover == "" {
+		category.Carryover = carryoverAll
+	}
+
+	err = validateCategory(&category)
+	if err != nil {
+		slog.Error("error validating create category request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
 	category.ID, err = uuid.NewV7()
 	if err != nil {
 		slog.Error("error creating category id", "error", err)
@@ -271,9 +470,11 @@ func (h *Handler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 
 	category.CreatedAt = time.Now()
 	category.UpdatedAt = category.CreatedAt
+	goalType, goalAmount, goalDate := category.Goal.columns()
 
-	_, err = h.db.Exec(r.Context(), queryCreateCategory,
-		category.ID, category.GroupID, category.Name, category.Notes, category.CreatedAt, category.UpdatedAt)
+	err = h.db.QueryRow(r.Context(), queryCreateCategory,
+		category.ID, category.GroupID, category.Name, category.Notes, category.Carryover, category.Income,
+		goalType, goalAmount, goalDate, category.Hidden, category.CreatedAt, category.UpdatedAt).Scan(&category.SortIndex)
 	if err != nil {
 		slog.Error("error creating category in database", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -303,7 +504,25 @@ func (h *Handler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
 
 	var category Category
 
-	err = json.NewDecoder(r.Body).Decode(&category)
+	err = scanCategory(h.db.QueryRow(r.Context(), queryGetCategory, categoryID), &category)
+	if err != nil {
+		slog.Error("error getting category from database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	if !matchesIfMatch(r, category.UpdatedAt) {
+		slog.Error("error matching category version", "error", errPreconditionFailed)
+		buildErrorResponse(w, errPreconditionFailed.Error(), http.StatusPreconditionFailed)
+
+		return
+	}
+
+	version := category.UpdatedAt
+	sortIndex := category.SortIndex
+
+	_, err = decodeMergePatch(r.Body, &category)
 	if err != nil {
 		slog.Error("error decoding update category request", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
@@ -311,10 +530,27 @@ func (h *Handler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if category.SortIndex != sortIndex {
+		slog.Error("error validating update category request", "error", errSortIndexPatch)
+		buildErrorResponse(w, errSortIndexPatch.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	err = validateCategory(&category)
+	if err != nil {
+		slog.Error("error validating update category request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
 	category.UpdatedAt = time.Now()
+	goalType, goalAmount, goalDate := category.Goal.columns()
 
-	_, err = h.db.Exec(r.Context(), queryUpdateCategory,
-		category.Name, category.Notes, category.GroupID, category.UpdatedAt, categoryID)
+	result, err := h.db.Exec(r.Context(), queryUpdateCategory,
+		category.Name, category.Notes, category.GroupID, category.Carryover, category.Income, goalType, goalAmount,
+		goalDate, category.Hidden, category.UpdatedAt, categoryID, version)
 	if err != nil {
 		slog.Error("error udpating category in database", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -322,6 +558,13 @@ func (h *Handler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if result.RowsAffected() == 0 {
+		slog.Error("error udpating category in database", "error", errPreconditionFailed)
+		buildErrorResponse(w, errPreconditionFailed.Error(), http.StatusPreconditionFailed)
+
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -337,10 +580,14 @@ func (h *Handler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = h.db.Exec(r.Context(), queryDeleteCategory, categoryID)
+	err = h.writeIfMatch(r, []versionQuery{lockVersion(queryGetCategoryVersion, categoryID)}, func(db dbExecutor) error {
+		_, err := db.Exec(r.Context(), queryDeleteCategory, categoryID, time.Now())
+
+		return err //nolint: wrapcheck
+	})
 	if err != nil {
 		slog.Error("error deleting category in database", "error", err)
-		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
 
 		return
 	}
@@ -349,10 +596,42 @@ func (h *Handler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (h *Handler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	categoryID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing category id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var category Category
+
+	err = scanCategory(h.db.QueryRow(r.Context(), queryGetCategory, categoryID), &category)
+	if err != nil {
+		slog.Error("error getting category from database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(category.UpdatedAt))
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(category)
+	if err != nil {
+		slog.Error("error encoding category response", "error", err)
+	}
+}
+
 func (h *Handler) GetCategories(w http.ResponseWriter, r *http.Request) { //nolint: funlen
 	searchQuery := r.URL.Query().Get("q")
+	hidden, _ := strconv.ParseBool(r.URL.Query().Get("hidden"))
 
-	rows, err := h.db.Query(r.Context(), queryGetTotalCategories, searchQuery)
+	rows, err := h.db.Query(r.Context(), queryGetTotalCategories, searchQuery, hidden)
 	if err != nil {
 		slog.Error("error getting total categories from database", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -373,7 +652,7 @@ func (h *Handler) GetCategories(w http.ResponseWriter, r *http.Request) { //noli
 		}
 	}
 
-	rows, err = h.db.Query(r.Context(), queryGetCategories, searchQuery)
+	rows, err = h.db.Query(r.Context(), queryGetCategories, searchQuery, hidden)
 	if err != nil {
 		slog.Error("error getting categories from database", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -387,8 +666,7 @@ func (h *Handler) GetCategories(w http.ResponseWriter, r *http.Request) { //noli
 	for rows.Next() {
 		var category Category
 
-		err := rows.Scan(&category.ID, &category.GroupID, &category.Name, &category.Notes,
-			&category.CreatedAt, &category.UpdatedAt)
+		err := scanCategory(rows, &category)
 		if err != nil {
 			slog.Error("error scanning categories row from database", "error", err)
 			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -415,6 +693,103 @@ func (h *Handler) GetCategories(w http.ResponseWriter, r *http.Request) { //noli
 	}
 }
 
+// reorder returns a handler sorting the groups or categories in the order of the given ids, using the query.
+func (h *Handler) reorder(query string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var reorder Reorder
+
+		err := json.NewDecoder(r.Body).Decode(&reorder)
+		if err != nil {
+			slog.Error("error decoding reorder request", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		err = validateReorder(reorder)
+		if err != nil {
+			slog.Error("error validating reorder request", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		err = h.applyReorder(r.Context(), query, reorder)
+		if errors.Is(err, errReorderNotFound) {
+			slog.Error("error reordering in database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusNotFound)
+
+			return
+		}
+
+		if err != nil {
+			slog.Error("error reordering in database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func validateReorder(reorder Reorder) error {
+	if len(reorder.IDs) == 0 {
+		return errReorderIDs
+	}
+
+	seen := make(map[uuid.UUID]bool, len(reorder.IDs))
+
+	for _, id := range reorder.IDs {
+		if seen[id] {
+			return errReorderDuplicate
+		}
+
+		seen[id] = true
+	}
+
+	return nil
+}
+
+// applyReorder updates the sort indexes in a transaction, so nothing is reordered when an id is not found. Rows left
+// out of the ids keep their order after the given ones, so no two rows share a sort index.
+func (h *Handler) applyReorder(ctx context.Context, query string, reorder Reorder) error {
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error creating database txn: %w", err)
+	}
+
+	defer func(ctx context.Context) {
+		if err != nil {
+			rollBackErr := tx.Rollback(ctx)
+			if rollBackErr != nil {
+				slog.Error("error rolling back database txn", "error", rollBackErr)
+			}
+		}
+	}(ctx)
+
+	var sorted int
+
+	err = tx.QueryRow(ctx, query, reorder.IDs, time.Now()).Scan(&sorted)
+	if err != nil {
+		return fmt.Errorf("error updating sort indexes: %w", err)
+	}
+
+	if sorted != len(reorder.IDs) {
+		err = errReorderNotFound
+
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("error committing database txn: %w", err)
+	}
+
+	return nil
+}
+
 func (h *Handler) SetBudget(w http.ResponseWriter, r *http.Request) {
 	var budget Budget
 
@@ -437,11 +812,16 @@ func (h *Handler) SetBudget(w http.ResponseWriter, r *http.Request) {
 	budget.CreatedAt = time.Now()
 	budget.UpdatedAt = budget.CreatedAt
 
-	_, err = h.db.Exec(r.Context(), querySetBudget,
-		budget.ID, budget.CategoryID, budget.Year, budget.Month, budget.Budgeted, budget.CreatedAt, budget.UpdatedAt)
+	err = h.writeIfMatch(r, []versionQuery{lockVersion(queryGetBudgetVersion, budget.Year, budget.Month)},
+		func(db dbExecutor) error {
+			_, err := db.Exec(r.Context(), querySetBudget, budget.ID, budget.CategoryID, budget.Year, budget.Month,
+				budget.Budgeted, budget.CreatedAt, budget.UpdatedAt)
+
+			return err //nolint: wrapcheck
+		})
 	if err != nil {
 		slog.Error("error setting budget in database", "error", err)
-		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
 
 		return
 	}
@@ -455,7 +835,27 @@ func (h *Handler) SetBudget(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *Handler) GetBudget(w http.ResponseWriter, r *http.Request) {
+// setBudgets sets the budgeted amounts of categories and months, within the transaction of db.
+func setBudgets(ctx context.Context, db dbExecutor, budgets []Budget) error {
+	now := time.Now()
+
+	for _, budget := range budgets {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return fmt.Errorf("error creating budget id: %w", err)
+		}
+
+		_, err = db.Exec(ctx, querySetBudget, id, budget.CategoryID, budget.Year, budget.Month, budget.Budgeted, now,
+			now)
+		if err != nil {
+			return fmt.Errorf("error setting budget: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (h *Handler) GetBudget(w http.ResponseWriter, r *http.Request) { //nolint: funlen
 	yearQ := r.URL.Query().Get("year")
 	monthQ := r.URL.Query().Get("month")
 
@@ -475,44 +875,186 @@ func (h *Handler) GetBudget(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rows, err := h.db.Query(r.Context(), queryGetBudget, year, month)
+	budgets, in, err := h.monthBudget(r.Context(), year, month)
 	if err != nil {
 		slog.Error("error getting budgets from database", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
 
 		return
 	}
+
+	version := time.Time{}
+
+	for _, budget := range budgets {
+		if budget.UpdatedAt != nil && budget.UpdatedAt.After(version) {
+			version = *budget.UpdatedAt
+		}
+	}
+
+	var (
+		summary                      BudgetSummary
+		incomeToDate, assignedToDate float64
+	)
+
+	err = h.db.QueryRow(r.Context(), queryGetBudgetSummary, year, month).Scan(&summary.Income, &summary.Assigned,
+		&incomeToDate, &assignedToDate)
+	if err != nil {
+		slog.Error("error getting budget summary from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	summary.Overspent = in.overspent
+	summary.ReadyToAssign = incomeToDate - assignedToDate - in.overspentToDate
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(version))
+	w.WriteHeader(http.StatusOK)
+
+	groups, totals := groupBudgets(budgets)
+
+	err = json.NewEncoder(w).Encode(map[string]interface{}{"summary": summary, "totals": totals, "groups": groups})
+	if err != nil {
+		slog.Error("error encoding budgets response", "error", err)
+	}
+}
+
+// monthBudget returns the budget of every category for the month, with their available balance and goal status, and
+// what the categories carried into the month.
+func (h *Handler) monthBudget(ctx context.Context, year, month int) ([]BudgetResult, carryIn, error) { //nolint: funlen
+	rows, err := h.db.Query(ctx, queryGetBudget, year, month)
+	if err != nil {
+		return nil, carryIn{}, fmt.Errorf("error getting budgets: %w", err)
+	}
 	defer rows.Close()
 
 	budgets := []BudgetResult{}
+	carryovers := map[uuid.UUID]string{}
 
 	for rows.Next() {
-		var budget BudgetResult
+		var (
+			budget     BudgetResult
+			goalType   *string
+			goalAmount *float64
+			goalDate   *time.Time
+		)
 
 		err := rows.Scan(&budget.Budgeted, &budget.Spent, &budget.Year, &budget.Month, &budget.CategoryID,
-			&budget.CategoryName, &budget.CategoryNotes, &budget.GroupID, &budget.GroupName, &budget.GroupNotes)
+			&budget.CategoryName, &budget.CategoryNotes, &budget.GroupID, &budget.GroupName, &budget.GroupNotes,
+			&budget.Carryover, &goalType, &goalAmount, &goalDate, &budget.UpdatedAt)
 		if err != nil {
-			slog.Error("error scanning budgets row from database", "error", err)
-			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+			return nil, carryIn{}, fmt.Errorf("error scanning budgets row: %w", err)
+		}
 
-			return
+		if goal := newGoal(goalType, goalAmount, goalDate); goal != nil {
+			budget.Goal = &GoalStatus{Goal: *goal}
+		}
+
+		if budget.CategoryID != nil && budget.Carryover != nil {
+			carryovers[*budget.CategoryID] = *budget.Carryover
 		}
 
 		budgets = append(budgets, budget)
 	}
 
 	if err := rows.Err(); err != nil {
-		slog.Error("error reading budgets rows from database", "error", err)
-		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return nil, carryIn{}, fmt.Errorf("error reading budgets rows: %w", err)
+	}
 
-		return
+	in, err := h.carriedOver(ctx, year, month, carryovers)
+	if err != nil {
+		return nil, in, err
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	for i, budget := range budgets {
+		if budget.CategoryID != nil {
+			budgets[i].Available = in.available[*budget.CategoryID] + budget.Budgeted + budget.Spent
+		}
 
-	err = json.NewEncoder(w).Encode(budgets)
+		if budget.Goal != nil {
+			budgets[i].Goal = budget.Goal.status(year, month, budgets[i])
+		}
+	}
+
+	return budgets, in, nil
+}
+
+func validateCategory(category *Category) error {
+	if !validCarryover(category.Carryover) {
+		return errInvalidCarryover
+	}
+
+	return validateGoal(category.Goal)
+}
+
+func scanGroup(row pgx.Row, group *Group) error {
+	return row.Scan(&group.ID, &group.Name, &group.Notes, &group.CreatedAt, &group.UpdatedAt, //nolint: wrapcheck
+		&group.DeletedAt, &group.SortIndex)
+}
+
+func scanCategory(row pgx.Row, category *Category) error {
+	var (
+		goalType   *string
+		goalAmount *float64
+		goalDate   *time.Time
+	)
+
+	err := row.Scan(&category.ID, &category.GroupID, &category.Name, &category.Notes, &category.CreatedAt,
+		&category.UpdatedAt, &category.DeletedAt, &category.Carryover, &category.Income, &goalType, &goalAmount,
+		&goalDate, &category.SortIndex, &category.Hidden)
 	if err != nil {
-		slog.Error("error encoding budgets response", "error", err)
+		return err //nolint: wrapcheck
+	}
+
+	category.Goal = newGoal(goalType, goalAmount, goalDate)
+
+	return nil
+}
+
+// groupBudgets nests the budgets, ordered by group, under their groups, and adds them up per group and overall.
+func groupBudgets(budgets []BudgetResult) ([]BudgetGroup, BudgetTotals) {
+	groups := []BudgetGroup{}
+	totals := BudgetTotals{}
+
+	for _, budget := range budgets {
+		if len(groups) == 0 || groups[len(groups)-1].GroupID != budget.GroupID {
+			groups = append(groups, BudgetGroup{
+				GroupID: budget.GroupID, GroupName: budget.GroupName, GroupNotes: budget.GroupNotes,
+				Categories: []BudgetResult{},
+			})
+		}
+
+		// A group without categories comes as a single row without a category.
+		if budget.CategoryID == nil {
+			continue
+		}
+
+		group := &groups[len(groups)-1]
+		group.Categories = append(group.Categories, budget)
+		group.BudgetTotals = group.add(budget)
+		totals = totals.add(budget)
+	}
+
+	for i := range groups {
+		groups[i].BudgetTotals = groups[i].rounded()
+	}
+
+	return groups, totals.rounded()
+}
+
+func (t BudgetTotals) add(budget BudgetResult) BudgetTotals {
+	return BudgetTotals{
+		Budgeted:  t.Budgeted + budget.Budgeted,
+		Spent:     t.Spent + budget.Spent,
+		Available: t.Available + budget.Available,
+	}
+}
+
+func (t BudgetTotals) rounded() BudgetTotals {
+	return BudgetTotals{
+		Budgeted:  math.Round(t.Budgeted*100) / 100,  //nolint: mnd
+		Spent:     math.Round(t.Spent*100) / 100,     //nolint: mnd
+		Available: math.Round(t.Available*100) / 100, //nolint: mnd
 	}
 }
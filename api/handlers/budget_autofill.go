@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+
+	uuid "github.com/google/uuid"
+)
+
+type (
+	// AutoFill model. Months is the number of months averaged by the averageSpent strategy.
+	AutoFill struct {
+		Year     uint16 `json:"year"`
+		Month    uint8  `json:"month"`
+		Strategy string `json:"strategy"`
+		Months   int    `json:"months"`
+	}
+
+	// AutoFillResult model. Current is what the category has budgeted in the month, and budgeted what the strategy
+	// budgets for it.
+	AutoFillResult struct {
+		CategoryID uuid.UUID `json:"categoryId"`
+		Current    float64   `json:"current"`
+		Budgeted   float64   `json:"budgeted"`
+	}
+)
+
+// Auto-fill strategies.
+const (
+	autoFillCopyBudgeted = "copyBudgeted"
+	autoFillAverageSpent = "averageSpent"
+	autoFillLastSpent    = "lastSpent"
+	autoFillGoals        = "goals"
+
+	autoFillDefaultMonths = 3
+)
+
+var (
+	errAutoFillStrategy = errors.New("strategy must be copyBudgeted, averageSpent, lastSpent or goals")
+	errAutoFillMonth    = errors.New("month must be between 1 and 12")
+	errAutoFillMonths   = errors.New("months must be positive")
+)
+
+const (
+	// queryAutoFillBudgeted returns the budgeted amount of each category in the month $1-$2 and in the month $3-$4.
+	queryAutoFillBudgeted = `SELECT cg.id, COALESCE(b.budgeted, 0), COALESCE(prev.budgeted, 0) FROM categories AS cg` +
+		` LEFT JOIN budgets AS b ON b.category_id = cg.id AND b.year = $1 AND b.month = $2` +
+		` LEFT JOIN budgets AS prev ON prev.category_id = cg.id AND prev.year = $3 AND prev.month = $4` +
+		` WHERE cg.deleted_at IS NULL AND NOT cg.income AND NOT cg.hidden ORDER BY cg.sort_index ASC, cg.created_at ASC`
+	// queryAutoFillSpent returns the budgeted amount of each category in the month $1-$2 and what it spent from the
+	// month $3-$4 up to the month $1-$2 excluded.
+	queryAutoFillSpent = `SELECT cg.id, COALESCE(b.budgeted, 0), COALESCE(SUM(lines.debit - lines.credit), 0)` +
+		` FROM categories AS cg LEFT JOIN budgets AS b ON b.category_id = cg.id AND b.year = $1 AND b.month = $2` +
+		` LEFT JOIN ` + queryBudgetLines + ` AS lines ON lines.category_id = cg.id` +
+		` AND lines.date >= make_date($3, $4, 1) AND lines.date < make_date($1, $2, 1)` +
+		` WHERE cg.deleted_at IS NULL AND NOT cg.income AND NOT cg.hidden GROUP BY cg.id, b.budgeted` +
+		` ORDER BY cg.sort_index ASC, cg.created_at ASC`
+)
+
+// AutoFillBudget budgets every category of a month with a strategy, in one transaction. With preview, it only
+// returns what the strategy would budget.
+func (h *Handler) AutoFillBudget(w http.ResponseWriter, r *http.Request) { //nolint: funlen
+	var autoFill AutoFill
+
+	err := json.NewDecoder(r.Body).Decode(&autoFill)
+	if err != nil {
+		slog.Error("error decoding auto-fill budget request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	err = validateAutoFill(&autoFill)
+	if err != nil {
+		slog.Error("error validating auto-fill budget request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	preview, _ := strconv.ParseBool(r.URL.Query().Get("preview"))
+
+	results, err := h.autoFill(r.Context(), autoFill)
+	if err != nil {
+		slog.Error("error auto-filling budget", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	if !preview {
+		err = h.writeIfMatch(r, []versionQuery{lockVersion(queryGetBudgetVersion, autoFill.Year, autoFill.Month)},
+			func(db dbExecutor) error {
+				return saveAutoFill(r.Context(), db, autoFill, results)
+			})
+		if err != nil {
+			slog.Error("error saving auto-filled budget in database", "error", err)
+			buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(map[string]interface{}{
+		"year": autoFill.Year, "month": autoFill.Month, "strategy": autoFill.Strategy, "preview": preview,
+		"budgets": results,
+	})
+	if err != nil {
+		slog.Error("error encoding auto-fill budget response", "error", err)
+	}
+}
+
+func validateAutoFill(autoFill *AutoFill) error {
+	switch autoFill.Strategy {
+	case autoFillCopyBudgeted, autoFillAverageSpent, autoFillLastSpent, autoFillGoals:
+	default:
+		return errAutoFillStrategy
+	}
+
+	if autoFill.Month < 1 || autoFill.Month > 12 {
+		return errAutoFillMonth
+	}
+
+	if autoFill.Months == 0 {
+		autoFill.Months = autoFillDefaultMonths
+	}
+
+	if autoFill.Months < 0 {
+		return errAutoFillMonths
+	}
+
+	return nil
+}
+
+// autoFill returns what the strategy budgets for every category in the month.
+func (h *Handler) autoFill(ctx context.Context, autoFill AutoFill) ([]AutoFillResult, error) { //nolint: funlen
+	year, month := int(autoFill.Year), int(autoFill.Month)
+	results := []AutoFillResult{}
+
+	if autoFill.Strategy == autoFillGoals {
+		budgets, _, err := h.monthBudget(ctx, year, month)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, budget := range budgets {
+			if budget.CategoryID == nil {
+				continue
+			}
+
+			result := AutoFillResult{CategoryID: *budget.CategoryID, Current: budget.Budgeted, Budgeted: budget.Budgeted}
+			if budget.Goal != nil {
+				result.Budgeted = math.Round((budget.Budgeted+budget.Goal.Needed)*100) / 100 //nolint: mnd
+			}
+
+			results = append(results, result)
+		}
+
+		return results, nil
+	}
+
+	query, months := queryAutoFillSpent, autoFill.Months
+
+	switch autoFill.Strategy {
+	case autoFillCopyBudgeted:
+		query, months = queryAutoFillBudgeted, 1
+	case autoFillLastSpent:
+		months = 1
+	}
+
+	from := budgetMonth(year, month) - months
+
+	rows, err := h.db.Query(ctx, query, year, month, from/12, from%12+1) //nolint: mnd
+	if err != nil {
+		return nil, fmt.Errorf("error getting auto-fill amounts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			result AutoFillResult
+			amount float64
+		)
+
+		err := rows.Scan(&result.CategoryID, &result.Current, &amount)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning auto-fill amounts: %w", err)
+		}
+
+		result.Budgeted = amount
+		if query == queryAutoFillSpent {
+			result.Budgeted = math.Round(max(amount, 0)/float64(months)*100) / 100 //nolint: mnd
+		}
+
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading auto-fill amounts: %w", err)
+	}
+
+	return results, nil
+}
+
+// saveAutoFill budgets the categories whose amount the strategy changes.
+func saveAutoFill(ctx context.Context, db dbExecutor, autoFill AutoFill, results []AutoFillResult) error {
+	budgets := []Budget{}
+
+	for _, result := range results {
+		if result.Budgeted != result.Current {
+			budgets = append(budgets, Budget{
+				CategoryID: result.CategoryID, Year: autoFill.Year, Month: autoFill.Month, Budgeted: result.Budgeted,
+			})
+		}
+	}
+
+	return setBudgets(ctx, db, budgets)
+}
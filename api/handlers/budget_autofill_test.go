@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+var autoFillRowCols = []string{"category_id", "current", "amount"}
+
+func TestAutoFillBudget(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodPost, "/v1/budgets/autofill", false, strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad request", http.MethodPost, "/v1/budgets/autofill", true, strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusBadRequest, "invalid character",
+		},
+		{
+			"error due to unknown strategy", http.MethodPost, "/v1/budgets/autofill", true,
+			strings.NewReader(`{"year":2024,"month":10,"strategy":"guess"}`),
+			nil, nil,
+			http.StatusBadRequest, "strategy must be",
+		},
+		{
+			"error due to bad month", http.MethodPost, "/v1/budgets/autofill", true,
+			strings.NewReader(`{"year":2024,"month":13,"strategy":"copyBudgeted"}`),
+			nil, nil,
+			http.StatusBadRequest, "month must be between 1 and 12",
+		},
+		{
+			"error due to negative months", http.MethodPost, "/v1/budgets/autofill", true,
+			strings.NewReader(`{"year":2024,"month":10,"strategy":"averageSpent","months":-1}`),
+			nil, nil,
+			http.StatusBadRequest, "months must be positive",
+		},
+		{
+			"error getting auto-fill amounts from db", http.MethodPost, "/v1/budgets/autofill?preview=true", true,
+			strings.NewReader(`{"year":2024,"month":10,"strategy":"copyBudgeted"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM categories AS cg").WithArgs(2024, 10, 2024, 9).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success previewing last month's budgeted", http.MethodPost, "/v1/budgets/autofill?preview=true", true,
+			strings.NewReader(`{"year":2024,"month":10,"strategy":"copyBudgeted"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM categories AS cg").WithArgs(2024, 10, 2024, 9).WillReturnRows(
+					pgxmock.NewRows(autoFillRowCols).AddRow(testCategoryID, 0.0, testAmount))
+			},
+			http.StatusOK, `"preview":true,"strategy":"copyBudgeted","year":2024`,
+		},
+		{
+			"success previewing average spent", http.MethodPost, "/v1/budgets/autofill?preview=true", true,
+			strings.NewReader(`{"year":2024,"month":10,"strategy":"averageSpent"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SUM\\(lines.debit - lines.credit\\)").WithArgs(2024, 10, 2024, 7).WillReturnRows(
+					pgxmock.NewRows(autoFillRowCols).AddRow(testCategoryID, 10.0, 100.0))
+			},
+			http.StatusOK, `"current":10,"budgeted":33.33`,
+		},
+		{
+			"success previewing last month's spent across years", http.MethodPost, "/v1/budgets/autofill?preview=true",
+			true,
+			strings.NewReader(`{"year":2024,"month":1,"strategy":"lastSpent","months":6}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SUM\\(lines.debit - lines.credit\\)").WithArgs(2024, 1, 2023, 12).WillReturnRows(
+					pgxmock.NewRows(autoFillRowCols).AddRow(testCategoryID, 10.0, -25.0))
+			},
+			http.StatusOK, `"current":10,"budgeted":0`,
+		},
+		{
+			"success previewing goals", http.MethodPost, "/v1/budgets/autofill?preview=true", true,
+			strings.NewReader(`{"year":2024,"month":10,"strategy":"goals"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				goalType, goalAmount := goalMonthly, 100.0
+				mock.ExpectQuery("FROM groups AS g").WithArgs(2024, 10).WillReturnRows(pgxmock.NewRows(budgetRowCols).
+					AddRow(testAmount, 4.20, uint16(2024), uint8(10), &testCategoryID, &testCategoryName, nil,
+						testGroupID, testGroupName, "notes", &testCarryoverAll, &goalType, &goalAmount, nil,
+						&testAccountTime))
+				mock.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+				mock.ExpectQuery("SELECT generation FROM budget_snapshot_generation").WillReturnRows(
+					pgxmock.NewRows(budgetGenerationCols).AddRow(int64(1)))
+				mock.ExpectQuery("FROM budget_snapshots").WithArgs(2024, 10).WillReturnRows(
+					pgxmock.NewRows(budgetSnapshotRowCols))
+				mock.ExpectQuery("FROM \\(SELECT category_id, year, month, budgeted").WithArgs(1, 1, 2024, 10).
+					WillReturnRows(pgxmock.NewRows(budgetActivityRowCols))
+				mock.ExpectCommit()
+			},
+			http.StatusOK, `"current":42.69,"budgeted":100`,
+		},
+		{
+			"error due to stale if-match", http.MethodPost, "/v1/budgets/autofill", true,
+			strings.NewReader(`{"year":2024,"month":10,"strategy":"copyBudgeted"}`),
+			http.Header{"If-Match": []string{`"stale"`}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM categories AS cg").WithArgs(2024, 10, 2024, 9).WillReturnRows(
+					pgxmock.NewRows(autoFillRowCols).AddRow(testCategoryID, 0.0, testAmount))
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT MAX").WithArgs(uint16(2024), uint8(10)).WillReturnRows(pgxmock.NewRows([]string{"max"}).
+					AddRow(&testAccountTime))
+				mock.ExpectRollback()
+			},
+			http.StatusPreconditionFailed, "resource has been modified",
+		},
+		{
+			"error setting auto-filled budget in db", http.MethodPost, "/v1/budgets/autofill", true,
+			strings.NewReader(`{"year":2024,"month":10,"strategy":"copyBudgeted"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM categories AS cg").WithArgs(2024, 10, 2024, 9).WillReturnRows(
+					pgxmock.NewRows(autoFillRowCols).AddRow(testCategoryID, 0.0, testAmount))
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO budgets").WithArgs(pgxmock.AnyArg(), testCategoryID, uint16(2024), uint8(10),
+					testAmount, pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success auto-filling changed categories", http.MethodPost, "/v1/budgets/autofill", true,
+			strings.NewReader(`{"year":2024,"month":10,"strategy":"copyBudgeted"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM categories AS cg").WithArgs(2024, 10, 2024, 9).WillReturnRows(
+					pgxmock.NewRows(autoFillRowCols).AddRow(testCategoryID, 0.0, testAmount).
+						AddRow(testGroupID, 10.0, 10.0))
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO budgets").WithArgs(pgxmock.AnyArg(), testCategoryID, uint16(2024), uint8(10),
+					testAmount, pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusOK, `"preview":false`,
+		},
+	}
+	executeTests(t, tests)
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	uuid "github.com/google/uuid"
+)
+
+// Movement model. A movement moves an amount budgeted in a month from one category to another.
+type Movement struct {
+	ID             *uuid.UUID `json:"id,omitempty"`
+	Year           uint16     `json:"year"`
+	Month          uint8      `json:"month"`
+	FromCategoryID uuid.UUID  `json:"fromCategoryId"`
+	ToCategoryID   uuid.UUID  `json:"toCategoryId"`
+	Amount         float64    `json:"amount"`
+	Notes          *string    `json:"notes"`
+	CreatedAt      *time.Time `json:"createdAt,omitempty"`
+}
+
+var (
+	errMovementAmount     = errors.New("amount must be positive")
+	errMovementMonth      = errors.New("month must be between 1 and 12")
+	errMovementCategories = errors.New("fromCategoryId and toCategoryId must be different")
+	errMovementCategory   = errors.New("categories must exist and not be income categories")
+)
+
+const (
+	// queryCountBudgetCategories counts the categories in $1 that can be budgeted.
+	queryCountBudgetCategories = `SELECT COUNT(*) FROM categories WHERE id = ANY($1) AND deleted_at IS NULL` +
+		` AND NOT income`
+	// queryAddBudget adds $5 to the amount budgeted for a category in a month.
+	queryAddBudget = `INSERT INTO budgets (id, category_id, year, month, budgeted, created_at, updated_at)` +
+		` VALUES ($1, $2, $3, $4, $5, $6, $6) ON CONFLICT (year, month, category_id)` +
+		` DO UPDATE SET budgeted=COALESCE(budgets.budgeted, 0) + $5, updated_at=$6`
+	queryCreateMovement = `INSERT INTO budget_movements (id, year, month, from_category_id, to_category_id, amount,` +
+		` notes, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	queryGetMovements = `SELECT id, year, month, from_category_id, to_category_id, amount, notes, created_at` +
+		` FROM budget_movements WHERE year=$1 AND month=$2 ORDER BY created_at ASC`
+)
+
+// MoveBudget moves money budgeted in a month from one category to another, adjusting both in one transaction.
+func (h *Handler) MoveBudget(w http.ResponseWriter, r *http.Request) { //nolint: funlen
+	var movement Movement
+
+	err := json.NewDecoder(r.Body).Decode(&movement)
+	if err != nil {
+		slog.Error("error decoding move budget request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	err = validateMovement(movement)
+	if err != nil {
+		slog.Error("error validating move budget request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var count int
+
+	err = h.db.QueryRow(r.Context(), queryCountBudgetCategories,
+		[]uuid.UUID{movement.FromCategoryID, movement.ToCategoryID}).Scan(&count)
+	if err != nil {
+		slog.Error("error getting movement categories from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	if count != 2 { //nolint: mnd
+		slog.Error("error validating movement categories", "error", errMovementCategory)
+		buildErrorResponse(w, errMovementCategory.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		slog.Error("error creating movement id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	createdAt := time.Now()
+	movement.ID = &id
+	movement.CreatedAt = &createdAt
+
+	err = h.writeIfMatch(r, []versionQuery{lockVersion(queryGetBudgetVersion, movement.Year, movement.Month)},
+		func(db dbExecutor) error {
+			return createMovement(r.Context(), db, movement)
+		})
+	if err != nil {
+		slog.Error("error moving budget in database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	err = json.NewEncoder(w).Encode(movement)
+	if err != nil {
+		slog.Error("error encoding movement response", "error", err)
+	}
+}
+
+// GetMovements lists the movements of a month, oldest first.
+func (h *Handler) GetMovements(w http.ResponseWriter, r *http.Request) { //nolint: funlen
+	year, err := strconv.Atoi(r.URL.Query().Get("year"))
+	if err != nil {
+		slog.Error("error converting year to int", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	month, err := strconv.Atoi(r.URL.Query().Get("month"))
+	if err != nil {
+		slog.Error("error converting month to int", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	rows, err := h.db.Query(r.Context(), queryGetMovements, year, month)
+	if err != nil {
+		slog.Error("error getting movements from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+	defer rows.Close()
+
+	movements := []Movement{}
+
+	for rows.Next() {
+		var movement Movement
+
+		err := rows.Scan(&movement.ID, &movement.Year, &movement.Month, &movement.FromCategoryID,
+			&movement.ToCategoryID, &movement.Amount, &movement.Notes, &movement.CreatedAt)
+		if err != nil {
+			slog.Error("error scanning movements row from database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		movements = append(movements, movement)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error reading movements rows from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(movements)
+	if err != nil {
+		slog.Error("error encoding movements response", "error", err)
+	}
+}
+
+func validateMovement(movement Movement) error {
+	if movement.Amount <= 0 {
+		return errMovementAmount
+	}
+
+	if movement.Month < 1 || movement.Month > 12 {
+		return errMovementMonth
+	}
+
+	if movement.FromCategoryID == movement.ToCategoryID {
+		return errMovementCategories
+	}
+
+	return nil
+}
+
+// createMovement takes the amount from one category, gives it to the other and records the movement, within the
+// transaction of db.
+func createMovement(ctx context.Context, db dbExecutor, movement Movement) error {
+	adjustments := []struct {
+		categoryID uuid.UUID
+		amount     float64
+	}{{movement.FromCategoryID, -movement.Amount}, {movement.ToCategoryID, movement.Amount}}
+
+	for _, adjustment := range adjustments {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return fmt.Errorf("error creating budget id: %w", err)
+		}
+
+		_, err = db.Exec(ctx, queryAddBudget, id, adjustment.categoryID, movement.Year, movement.Month,
+			adjustment.amount, *movement.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("error adjusting budget: %w", err)
+		}
+	}
+
+	_, err := db.Exec(ctx, queryCreateMovement, movement.ID, movement.Year, movement.Month, movement.FromCategoryID,
+		movement.ToCategoryID, movement.Amount, movement.Notes, movement.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting movement: %w", err)
+	}
+
+	return nil
+}
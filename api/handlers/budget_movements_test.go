@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+var (
+	testToCategoryID = uuid.MustParse("01927f36-44b8-7e62-a7a9-395eacab5630")
+	testMovementBody = `{"year":2024,"month":10,"fromCategoryId":"` + testCategoryID.String() +
+		`","toCategoryId":"` + testToCategoryID.String() + `","amount":25.5,"notes":"groceries ran over"}`
+	movementRowCols = []string{"id", "year", "month", "from_category_id", "to_category_id", "amount", "notes",
+		"created_at"}
+)
+
+func TestMoveBudget(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodPost, "/v1/budgets/movements", false, strings.NewReader(testMovementBody),
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad request", http.MethodPost, "/v1/budgets/movements", true, strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusBadRequest, "invalid character",
+		},
+		{
+			"error due to non positive amount", http.MethodPost, "/v1/budgets/movements", true,
+			strings.NewReader(strings.Replace(testMovementBody, "25.5", "-1", 1)),
+			nil, nil,
+			http.StatusBadRequest, "amount must be positive",
+		},
+		{
+			"error due to same categories", http.MethodPost, "/v1/budgets/movements", true,
+			strings.NewReader(strings.Replace(testMovementBody, testToCategoryID.String(), testCategoryID.String(), 1)),
+			nil, nil,
+			http.StatusBadRequest, "must be different",
+		},
+		{
+			"error getting categories from db", http.MethodPost, "/v1/budgets/movements", true,
+			strings.NewReader(testMovementBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT COUNT").WithArgs([]uuid.UUID{testCategoryID, testToCategoryID}).
+					WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error due to unknown category", http.MethodPost, "/v1/budgets/movements", true,
+			strings.NewReader(testMovementBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT COUNT").WithArgs([]uuid.UUID{testCategoryID, testToCategoryID}).
+					WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+			},
+			http.StatusBadRequest, "categories must exist",
+		},
+		{
+			"error adjusting budget in db", http.MethodPost, "/v1/budgets/movements", true,
+			strings.NewReader(testMovementBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT COUNT").WithArgs([]uuid.UUID{testCategoryID, testToCategoryID}).
+					WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO budgets").WithArgs(pgxmock.AnyArg(), testCategoryID, uint16(2024), uint8(10),
+					-25.5, pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectExec("INSERT INTO budgets").WithArgs(pgxmock.AnyArg(), testToCategoryID, uint16(2024),
+					uint8(10), 25.5, pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error due to stale if-match", http.MethodPost, "/v1/budgets/movements", true,
+			strings.NewReader(testMovementBody),
+			http.Header{"If-Match": []string{`"stale"`}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT COUNT").WithArgs([]uuid.UUID{testCategoryID, testToCategoryID}).
+					WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT MAX").WithArgs(uint16(2024), uint8(10)).WillReturnRows(pgxmock.NewRows([]string{"max"}).
+					AddRow(&testAccountTime))
+				mock.ExpectRollback()
+			},
+			http.StatusPreconditionFailed, "resource has been modified",
+		},
+		{
+			"success moving budget", http.MethodPost, "/v1/budgets/movements", true,
+			strings.NewReader(testMovementBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT COUNT").WithArgs([]uuid.UUID{testCategoryID, testToCategoryID}).
+					WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO budgets").WithArgs(pgxmock.AnyArg(), testCategoryID, uint16(2024), uint8(10),
+					-25.5, pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectExec("INSERT INTO budgets").WithArgs(pgxmock.AnyArg(), testToCategoryID, uint16(2024),
+					uint8(10), 25.5, pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectExec("INSERT INTO budget_movements").WithArgs(pgxmock.AnyArg(), uint16(2024), uint8(10),
+					testCategoryID, testToCategoryID, 25.5, pgxmock.AnyArg(), pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusCreated, `"amount":25.5,"notes":"groceries ran over"`,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestGetMovements(t *testing.T) {
+	notes := "groceries ran over"
+
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, "/v1/budgets/movements?year=2024&month=10", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad month", http.MethodGet, "/v1/budgets/movements?year=2024&month=NA", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "parsing",
+		},
+		{
+			"error getting movements from db", http.MethodGet, "/v1/budgets/movements?year=2024&month=10", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM budget_movements").WithArgs(2024, 10).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error reading movements from db", http.MethodGet, "/v1/budgets/movements?year=2024&month=10", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM budget_movements").WithArgs(2024, 10).WillReturnRows(
+					pgxmock.NewRows(movementRowCols).RowError(0, errors.New("some error in db")))
+			},
+			http.StatusInternalServerError, "some error in db",
+		},
+		{
+			"success getting movements", http.MethodGet, "/v1/budgets/movements?year=2024&month=10", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM budget_movements").WithArgs(2024, 10).WillReturnRows(
+					pgxmock.NewRows(movementRowCols).AddRow(&testGroupID, uint16(2024), uint8(10), testCategoryID,
+						testToCategoryID, 25.5, &notes, &testAccountTime))
+			},
+			http.StatusOK, `"fromCategoryId":"` + testCategoryID.String() + `"`,
+		},
+	}
+	executeTests(t, tests)
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	uuid "github.com/google/uuid"
+)
+
+type (
+	// RangeMonth model. The budgeted and spent amounts of a month.
+	RangeMonth struct {
+		Year     int     `json:"year"`
+		Month    int     `json:"month"`
+		Budgeted float64 `json:"budgeted"`
+		Spent    float64 `json:"spent"`
+	}
+
+	// RangeAmounts model. The total, average, minimum and maximum of an amount over the months of a range.
+	RangeAmounts struct {
+		Total   float64 `json:"total"`
+		Average float64 `json:"average"`
+		Min     float64 `json:"min"`
+		Max     float64 `json:"max"`
+	}
+
+	// RangeStats model.
+	RangeStats struct {
+		Budgeted RangeAmounts `json:"budgeted"`
+		Spent    RangeAmounts `json:"spent"`
+	}
+
+	// RangeCategory model.
+	RangeCategory struct {
+		CategoryID   uuid.UUID    `json:"categoryId"`
+		CategoryName string       `json:"categoryName"`
+		Months       []RangeMonth `json:"months"`
+		Stats        RangeStats   `json:"stats"`
+	}
+
+	// RangeGroup model. Its months add up the months of its categories.
+	RangeGroup struct {
+		GroupID    uuid.UUID       `json:"groupId"`
+		GroupName  string          `json:"groupName"`
+		Months     []RangeMonth    `json:"months"`
+		Stats      RangeStats      `json:"stats"`
+		Categories []RangeCategory `json:"categories"`
+	}
+
+	// BudgetRange model. Its months add up the months of all groups.
+	BudgetRange struct {
+		From   string       `json:"from"`
+		To     string       `json:"to"`
+		Months []RangeMonth `json:"months"`
+		Stats  RangeStats   `json:"stats"`
+		Groups []RangeGroup `json:"groups"`
+	}
+)
+
+const (
+	// rangeMonthLayout is the format of the months bounding a range.
+	rangeMonthLayout = "2006-01"
+	maxRangeMonths   = 120
+)
+
+var errBudgetRange = errors.New("range must go from an earlier month to a later one, over at most 120 months")
+
+// queryGetBudgetRange returns the budgeted and spent amounts of every category for each month from $1-$2 to $3-$4,
+// ordered by group, category and month. Months without activity are included with zero amounts, and hidden categories
+// too as the range reports past activity.
+const queryGetBudgetRange = `WITH months AS (SELECT EXTRACT(YEAR FROM m)::int AS year,` +
+	` EXTRACT(MONTH FROM m)::int AS month FROM generate_series(make_date($1, $2, 1), make_date($3, $4, 1),` +
+	` INTERVAL '1 month') AS m), spent AS (SELECT category_id, EXTRACT(YEAR FROM date)::int AS year,` +
+	` EXTRACT(MONTH FROM date)::int AS month, SUM(credit - debit) AS spent FROM ` + queryBudgetLines +
+	` AS lines WHERE date >= make_date($1, $2, 1) AND date < make_date($3, $4, 1) + INTERVAL '1 month'` +
+	` GROUP BY 1, 2, 3) SELECT g.id, g.name, cg.id, cg.name, m.year, m.month, COALESCE(b.budgeted, 0),` +
+	` COALESCE(s.spent, 0) FROM groups AS g JOIN categories AS cg ON cg.group_id = g.id` +
+	` AND cg.deleted_at IS NULL AND NOT cg.income CROSS JOIN months AS m LEFT JOIN budgets AS b` +
+	` ON b.category_id = cg.id AND b.year = m.year AND b.month = m.month LEFT JOIN spent AS s` +
+	` ON s.category_id = cg.id AND s.year = m.year AND s.month = m.month WHERE g.deleted_at IS NULL` +
+	` ORDER BY g.sort_index ASC, g.created_at ASC, g.id, cg.sort_index ASC, cg.created_at ASC, cg.id, m.year,` +
+	` m.month`
+
+// GetBudgetRange reports the budget of every category and group over a range of months, given by from and to, or
+// over a whole year.
+func (h *Handler) GetBudgetRange(w http.ResponseWriter, r *http.Request) { //nolint: funlen
+	from, to, err := parseBudgetRange(r.URL.Query())
+	if err != nil {
+		slog.Error("error parsing budget range", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	rows, err := h.db.Query(r.Context(), queryGetBudgetRange, from.Year(), int(from.Month()), to.Year(),
+		int(to.Month()))
+	if err != nil {
+		slog.Error("error getting budget range from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+	defer rows.Close()
+
+	months := budgetMonth(to.Year(), int(to.Month())) - budgetMonth(from.Year(), int(from.Month())) + 1
+	result := BudgetRange{
+		From:   from.Format(rangeMonthLayout),
+		To:     to.Format(rangeMonthLayout),
+		Months: newRangeMonths(from, months),
+		Groups: []RangeGroup{},
+	}
+
+	for rows.Next() {
+		var (
+			groupID, categoryID     uuid.UUID
+			groupName, categoryName string
+			month                   RangeMonth
+		)
+
+		err := rows.Scan(&groupID, &groupName, &categoryID, &categoryName, &month.Year, &month.Month,
+			&month.Budgeted, &month.Spent)
+		if err != nil {
+			slog.Error("error scanning budget range row from database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		if len(result.Groups) == 0 || result.Groups[len(result.Groups)-1].GroupID != groupID {
+			result.Groups = append(result.Groups, RangeGroup{
+				GroupID: groupID, GroupName: groupName, Months: newRangeMonths(from, months),
+				Categories: []RangeCategory{},
+			})
+		}
+
+		group := &result.Groups[len(result.Groups)-1]
+
+		if len(group.Categories) == 0 || group.Categories[len(group.Categories)-1].CategoryID != categoryID {
+			group.Categories = append(group.Categories, RangeCategory{CategoryID: categoryID, CategoryName: categoryName})
+		}
+
+		category := &group.Categories[len(group.Categories)-1]
+		category.Months = append(category.Months, month)
+
+		index := budgetMonth(month.Year, month.Month) - budgetMonth(from.Year(), int(from.Month()))
+		group.Months[index].Budgeted += month.Budgeted
+		group.Months[index].Spent += month.Spent
+		result.Months[index].Budgeted += month.Budgeted
+		result.Months[index].Spent += month.Spent
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error reading budget range rows from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	for i := range result.Groups {
+		for j := range result.Groups[i].Categories {
+			result.Groups[i].Categories[j].Stats = newRangeStats(result.Groups[i].Categories[j].Months)
+		}
+
+		result.Groups[i].Stats = newRangeStats(result.Groups[i].Months)
+	}
+
+	result.Stats = newRangeStats(result.Months)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		slog.Error("error encoding budget range response", "error", err)
+	}
+}
+
+// parseBudgetRange returns the first and last months of the range, from the from and to months or from the year.
+func parseBudgetRange(values url.Values) (time.Time, time.Time, error) {
+	if values.Get("from") == "" && values.Get("to") == "" && values.Get("year") != "" {
+		year, err := strconv.Atoi(values.Get("year"))
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("error parsing year: %w", err)
+		}
+
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(year, time.December, 1, 0, 0, 0, 0, time.UTC), nil
+	}
+
+	return parseMonthRange(values.Get("from"), values.Get("to"))
+}
+
+// parseMonthRange returns the first and last months of a range given in the month layout.
+func parseMonthRange(fromMonth, toMonth string) (time.Time, time.Time, error) {
+	from, err := time.Parse(rangeMonthLayout, fromMonth)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("error parsing from month: %w", err)
+	}
+
+	to, err := time.Parse(rangeMonthLayout, toMonth)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("error parsing to month: %w", err)
+	}
+
+	months := budgetMonth(to.Year(), int(to.Month())) - budgetMonth(from.Year(), int(from.Month())) + 1
+	if months < 1 || months > maxRangeMonths {
+		return time.Time{}, time.Time{}, errBudgetRange
+	}
+
+	return from, to, nil
+}
+
+// newRangeMonths returns the given number of months starting from a month, with zero amounts.
+func newRangeMonths(from time.Time, months int) []RangeMonth {
+	result := make([]RangeMonth, months)
+
+	for i := range result {
+		month := from.AddDate(0, i, 0)
+		result[i] = RangeMonth{Year: month.Year(), Month: int(month.Month())}
+	}
+
+	return result
+}
+
+func newRangeStats(months []RangeMonth) RangeStats {
+	var budgeted, spent []float64
+
+	for _, month := range months {
+		budgeted = append(budgeted, month.Budgeted)
+		spent = append(spent, month.Spent)
+	}
+
+	return RangeStats{Budgeted: newRangeAmounts(budgeted), Spent: newRangeAmounts(spent)}
+}
+
+func newRangeAmounts(amounts []float64) RangeAmounts {
+	if len(amounts) == 0 {
+		return RangeAmounts{}
+	}
+
+	result := RangeAmounts{Min: amounts[0], Max: amounts[0]}
+
+	for _, amount := range amounts {
+		result.Total += amount
+		result.Min = min(result.Min, amount)
+		result.Max = max(result.Max, amount)
+	}
+
+	result.Average = math.Round(result.Total/float64(len(amounts))*100) / 100 //nolint: mnd
+
+	return result
+}
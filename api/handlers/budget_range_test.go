@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+var budgetRangeRowCols = []string{"group_id", "group_name", "category_id", "category_name", "year", "month",
+	"budgeted", "spent"}
+
+func TestGetBudgetRange(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, "/v1/budgets/range?from=2024-11&to=2025-01", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad from month", http.MethodGet, "/v1/budgets/range?from=2024&to=2025-01", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "error parsing from month",
+		},
+		{
+			"error due to bad year", http.MethodGet, "/v1/budgets/range?year=NA", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "error parsing year",
+		},
+		{
+			"error due to reversed range", http.MethodGet, "/v1/budgets/range?from=2025-01&to=2024-11", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "range must go from an earlier month",
+		},
+		{
+			"error getting budget range from db", http.MethodGet, "/v1/budgets/range?from=2024-11&to=2025-01", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("generate_series").WithArgs(2024, 11, 2025, 1).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error reading budget range from db", http.MethodGet, "/v1/budgets/range?year=2024", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("generate_series").WithArgs(2024, 1, 2024, 12).WillReturnRows(
+					pgxmock.NewRows(budgetRangeRowCols).RowError(0, errors.New("some error in db")))
+			},
+			http.StatusInternalServerError, "some error in db",
+		},
+		{
+			"success getting budget range", http.MethodGet, "/v1/budgets/range?from=2024-12&to=2025-01", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("generate_series").WithArgs(2024, 12, 2025, 1).WillReturnRows(
+					pgxmock.NewRows(budgetRangeRowCols).
+						AddRow(testGroupID, testGroupName, testCategoryID, testCategoryName, 2024, 12, 100.0, -80.0).
+						AddRow(testGroupID, testGroupName, testCategoryID, testCategoryName, 2025, 1, 50.0, -20.0).
+						AddRow(testGroupID, testGroupName, testToCategoryID, "Dining", 2024, 12, 10.0, 0.0).
+						AddRow(testGroupID, testGroupName, testToCategoryID, "Dining", 2025, 1, 30.0, -5.0))
+			},
+			http.StatusOK, `"from":"2024-12","to":"2025-01","months":[{"year":2024,"month":12,"budgeted":110,` +
+				`"spent":-80},{"year":2025,"month":1,"budgeted":80,"spent":-25}],"stats":{"budgeted":{"total":190,` +
+				`"average":95,"min":80,"max":110},"spent":{"total":-105,"average":-52.5,"min":-80,"max":-25}}`,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestNewRangeAmounts(t *testing.T) {
+	tests := []struct {
+		name     string
+		amounts  []float64
+		expected RangeAmounts
+	}{
+		{"no months", nil, RangeAmounts{}},
+		{"rounds the average", []float64{10, 0, 0}, RangeAmounts{Total: 10, Average: 3.33, Min: 0, Max: 10}},
+		{"negative amounts", []float64{-5, -15}, RangeAmounts{Total: -20, Average: -10, Min: -15, Max: -5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, newRangeAmounts(tt.amounts))
+		})
+	}
+}
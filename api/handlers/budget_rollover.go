@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Carry-over modes of a category, deciding what its balance at the end of a month carries into the next one.
+const (
+	carryoverAll      = "all"
+	carryoverPositive = "positive"
+)
+
+var (
+	errInvalidCarryover     = errors.New("carryover must be all or positive")
+	errStaleBudgetSnapshots = errors.New("budget snapshots were invalidated while computing them")
+)
+
+const (
+	// queryGetBudgetSnapshot returns the balances of the latest snapshot taken before the month $1-$2.
+	queryGetBudgetSnapshot = `SELECT s.category_id, s.available, s.overspent, s.year, s.month` +
+		` FROM budget_snapshots AS s` +
+		` JOIN (SELECT year, month FROM budget_snapshots WHERE (year, month) < ($1, $2)` +
+		` ORDER BY year DESC, month DESC LIMIT 1) AS latest USING (year, month)`
+	// queryGetBudgetActivity returns the budgeted and spent amounts of each category and month, from the month $1-$2
+	// up to the month $3-$4 excluded.
+	queryGetBudgetActivity = `SELECT category_id, year, month, COALESCE(SUM(budgeted), 0),` +
+		` COALESCE(SUM(spent), 0) FROM (SELECT category_id, year, month, budgeted, 0 AS spent FROM budgets` +
+		` WHERE (year, month) >= ($1, $2) AND (year, month) < ($3, $4) UNION ALL SELECT category_id,` +
+		` EXTRACT(YEAR FROM date)::int, EXTRACT(MONTH FROM date)::int, 0, credit - debit FROM ` + queryBudgetLines +
+		` AS lines WHERE category_id IS NOT NULL AND date >= make_date($1, $2, 1) AND date < make_date($3, $4, 1))` +
+		` AS activity GROUP BY category_id, year, month`
+	// queryGetBudgetSnapshotGeneration returns the count of snapshot invalidations, bumped by every invalidation.
+	queryGetBudgetSnapshotGeneration  = `SELECT generation FROM budget_snapshot_generation`
+	queryLockBudgetSnapshotGeneration = queryGetBudgetSnapshotGeneration + ` FOR UPDATE`
+	querySaveBudgetSnapshots          = `INSERT INTO budget_snapshots (year, month, category_id, available, overspent)` +
+		` SELECT * FROM unnest($1::int[], $2::int[], $3::uuid[], $4::float8[], $5::float8[])` +
+		` ON CONFLICT (year, month, category_id) DO UPDATE SET available = EXCLUDED.available,` +
+		` overspent = EXCLUDED.overspent`
+)
+
+// budgetSnapshots holds the columns of the snapshots to save.
+type budgetSnapshots struct {
+	years      []int
+	months     []int
+	categories []uuid.UUID
+	available  []float64
+	overspent  []float64
+}
+
+// carryIn is what the categories carry into a month.
+type carryIn struct {
+	// available is what each category carries into the month.
+	available map[uuid.UUID]float64
+	// overspent is the overspending not carried into the month, and overspentToDate that of all months up to it.
+	overspent       float64
+	overspentToDate float64
+}
+
+// budgetMonth numbers a month by the months elapsed since year zero, so months can be iterated over.
+func budgetMonth(year, month int) int {
+	return year*12 + month - 1 //nolint: mnd
+}
+
+// carry returns what a balance carries into the next month under a carry-over mode.
+func carry(mode string, available float64) float64 {
+	if mode == carryoverPositive {
+		return max(available, 0)
+	}
+
+	return available
+}
+
+func validCarryover(mode string) bool {
+	return mode == carryoverAll || mode == carryoverPositive
+}
+
+// carriedOver returns what the categories, given with their carry-over mode, carry into the month. It starts from the
+// latest snapshot before the month and snapshots every month it computes on the way. It reads a single snapshot of the
+// database, so the snapshots it saves match the data they were computed from.
+func (h *Handler) carriedOver(ctx context.Context, year, month int, //nolint: funlen,cyclop
+	carryovers map[uuid.UUID]string,
+) (carryIn, error) {
+	target := budgetMonth(year, month)
+	from := budgetMonth(1, 1)
+	available := map[uuid.UUID]float64{}
+	overspent := map[uuid.UUID]float64{}
+	in := carryIn{available: make(map[uuid.UUID]float64, len(carryovers))}
+
+	tx, err := h.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+	if err != nil {
+		return in, fmt.Errorf("error creating database txn: %w", err)
+	}
+
+	defer func(ctx context.Context) {
+		if err != nil {
+			rollBackErr := tx.Rollback(ctx)
+			if rollBackErr != nil {
+				slog.Error("error rolling back database txn", "error", rollBackErr)
+			}
+		}
+	}(ctx)
+
+	// The first query of the transaction takes its snapshot, so the generation is the one the balances are read at.
+	var generation int64
+
+	err = tx.QueryRow(ctx, queryGetBudgetSnapshotGeneration).Scan(&generation)
+	if err != nil {
+		return in, fmt.Errorf("error getting budget snapshot generation: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, queryGetBudgetSnapshot, year, month)
+	if err != nil {
+		return in, fmt.Errorf("error getting budget snapshot: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			categoryID                  uuid.UUID
+			amount, overspentAmount     float64
+			snapshotYear, snapshotMonth int
+		)
+
+		err = rows.Scan(&categoryID, &amount, &overspentAmount, &snapshotYear, &snapshotMonth)
+		if err != nil {
+			return in, fmt.Errorf("error scanning budget snapshot: %w", err)
+		}
+
+		available[categoryID] = amount
+		overspent[categoryID] = overspentAmount
+		from = budgetMonth(snapshotYear, snapshotMonth) + 1
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return in, fmt.Errorf("error reading budget snapshot: %w", err)
+	}
+
+	rows, err = tx.Query(ctx, queryGetBudgetActivity, from/12, from%12+1, year, month) //nolint: mnd
+	if err != nil {
+		return in, fmt.Errorf("error getting budget activity: %w", err)
+	}
+	defer rows.Close()
+
+	activity := map[int]map[uuid.UUID]float64{}
+	start := target
+
+	for rows.Next() {
+		var (
+			categoryID                  uuid.UUID
+			activityYear, activityMonth int
+			budgeted, spent             float64
+		)
+
+		err = rows.Scan(&categoryID, &activityYear, &activityMonth, &budgeted, &spent)
+		if err != nil {
+			return in, fmt.Errorf("error scanning budget activity: %w", err)
+		}
+
+		index := budgetMonth(activityYear, activityMonth)
+		if activity[index] == nil {
+			activity[index] = map[uuid.UUID]float64{}
+		}
+
+		activity[index][categoryID] += budgeted + spent
+		start = min(start, index)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return in, fmt.Errorf("error reading budget activity: %w", err)
+	}
+
+	// Without a snapshot, the computation starts at the first month with activity, as earlier months carry nothing.
+	if len(available) > 0 {
+		start = min(start, from)
+	}
+
+	var snapshots budgetSnapshots
+
+	for index := start; index < target; index++ {
+		for categoryID, mode := range carryovers {
+			carried := carry(mode, available[categoryID])
+			overspent[categoryID] += carried - available[categoryID]
+			available[categoryID] = carried + activity[index][categoryID]
+
+			snapshots.years = append(snapshots.years, index/12)     //nolint: mnd
+			snapshots.months = append(snapshots.months, index%12+1) //nolint: mnd
+			snapshots.categories = append(snapshots.categories, categoryID)
+			snapshots.available = append(snapshots.available, available[categoryID])
+			snapshots.overspent = append(snapshots.overspent, overspent[categoryID])
+		}
+	}
+
+	for categoryID, mode := range carryovers {
+		in.available[categoryID] = carry(mode, available[categoryID])
+		in.overspent += in.available[categoryID] - available[categoryID]
+		in.overspentToDate += overspent[categoryID]
+	}
+
+	in.overspentToDate += in.overspent
+
+	if len(snapshots.categories) > 0 {
+		err = saveBudgetSnapshots(ctx, tx, generation, snapshots)
+		if err != nil {
+			// Snapshots are a cache, failing to save them only slows down the next computation. The transaction is
+			// rolled back as err is set.
+			slog.Error("error saving budget snapshots", "error", err)
+
+			return in, nil
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return in, fmt.Errorf("error committing database txn: %w", err)
+	}
+
+	return in, nil
+}
+
+// saveBudgetSnapshots saves the snapshots unless they were invalidated since the generation they were computed at.
+// Locking the generation makes invalidations wait for the snapshots to be saved before dropping them.
+func saveBudgetSnapshots(ctx context.Context, db dbExecutor, generation int64, snapshots budgetSnapshots) error {
+	var current int64
+
+	err := db.QueryRow(ctx, queryLockBudgetSnapshotGeneration).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("error locking budget snapshot generation: %w", err)
+	}
+
+	if current != generation {
+		return errStaleBudgetSnapshots
+	}
+
+	_, err = db.Exec(ctx, querySaveBudgetSnapshots, snapshots.years, snapshots.months, snapshots.categories,
+		snapshots.available, snapshots.overspent)
+	if err != nil {
+		return fmt.Errorf("error inserting budget snapshots: %w", err)
+	}
+
+	return nil
+}
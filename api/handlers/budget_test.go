@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
 	"strings"
 	"testing"
@@ -10,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
 )
 
 var (
@@ -17,10 +17,19 @@ var (
 	testCategoryName = "Swiggy Online"
 	testGroupID      = uuid.MustParse("01927f36-44b8-7e62-a7a9-395eacab562b")
 	testAmount       = 42.69
-	groupRowCols     = []string{"id", "name", "notes", "created_at", "updated_at"}
-	categoryRowCols  = []string{"id", "groupId", "name", "notes", "created_at", "updated_at"}
-	budgetRowCols    = []string{"budgeted", "spent", "year", "month", "category_id", "category_name", "category_notes",
-		"group_id", "group_name", "group_notes"}
+	groupRowCols     = []string{"id", "name", "notes", "created_at", "updated_at", "deleted_at", "sort_index"}
+	categoryRowCols  = []string{"id", "groupId", "name", "notes", "created_at", "updated_at", "deleted_at",
+		"carryover", "income", "goal_type", "goal_amount", "goal_date", "sort_index", "hidden"}
+	budgetRowCols = []string{"budgeted", "spent", "year", "month", "category_id", "category_name", "category_notes",
+		"group_id", "group_name", "group_notes", "carryover", "goal_type", "goal_amount", "goal_date", "updated_at"}
+	budgetSnapshotRowCols = []string{"category_id", "available", "overspent", "year", "month"}
+	budgetGenerationCols  = []string{"generation"}
+	budgetSummaryRowCols  = []string{"income", "assigned", "income_to_date", "assigned_to_date"}
+	budgetActivityRowCols = []string{"category_id", "year", "month", "budgeted", "spent"}
+	testCarryoverAll      = carryoverAll
+	testCarryoverPositive = carryoverPositive
+	testNullGoalType      *string
+	testNullAmount        *float64
 )
 
 func TestCreateGroup(t *testing.T) {
@@ -40,7 +49,7 @@ func TestCreateGroup(t *testing.T) {
 			strings.NewReader(`{"name":"` + testGroupName + `"}`),
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec("INSERT INTO groups").WithArgs(pgxmock.AnyArg(), testGroupName,
+				mock.ExpectQuery("INSERT INTO groups").WithArgs(pgxmock.AnyArg(), testGroupName,
 					"", pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
 			},
 			http.StatusInternalServerError, "tx is closed",
@@ -50,10 +59,10 @@ func TestCreateGroup(t *testing.T) {
 			strings.NewReader(`{"name":"` + testGroupName + `"}`),
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec("INSERT INTO groups").WithArgs(pgxmock.AnyArg(), testGroupName,
-					"", pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectQuery("INSERT INTO groups").WithArgs(pgxmock.AnyArg(), testGroupName,
+					"", pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(pgxmock.NewRows([]string{"sort_index"}).AddRow(3))
 			},
-			http.StatusCreated, testGroupName,
+			http.StatusCreated, `"sortIndex":3`,
 		},
 	}
 	executeTests(t, tests)
@@ -73,7 +82,11 @@ func TestUpdateGroup(t *testing.T) {
 		},
 		{
 			"error due to bad request", http.MethodPatch, "/v1/groups/" + testGroupID.String(), true, strings.NewReader("invalid-body"),
-			nil, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM groups").WithArgs(testGroupID).WillReturnRows(pgxmock.NewRows(groupRowCols).
+					AddRow(testGroupID, "Old name", "Some notes", testAccountTime, testAccountTime, testNullTime, 1))
+			},
 			http.StatusBadRequest, "invalid character",
 		},
 		{
@@ -81,8 +94,10 @@ func TestUpdateGroup(t *testing.T) {
 			strings.NewReader(`{"name":"` + testGroupName + `","notes":"Some notes"}`),
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM groups").WithArgs(testGroupID).WillReturnRows(pgxmock.NewRows(groupRowCols).
+					AddRow(testGroupID, "Old name", "Some notes", testAccountTime, testAccountTime, testNullTime, 1))
 				mock.ExpectExec("UPDATE groups").WithArgs(
-					testGroupName, "Some notes", pgxmock.AnyArg(), testGroupID,
+					testGroupName, "Some notes", pgxmock.AnyArg(), testGroupID, testAccountTime,
 				).WillReturnError(pgx.ErrTxClosed)
 			},
 			http.StatusInternalServerError, "tx is closed",
@@ -92,8 +107,63 @@ func TestUpdateGroup(t *testing.T) {
 			strings.NewReader(`{"name":"` + testGroupName + `","notes":"Some notes"}`),
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM groups").WithArgs(testGroupID).WillReturnRows(pgxmock.NewRows(groupRowCols).
+					AddRow(testGroupID, "Old name", "Some notes", testAccountTime, testAccountTime, testNullTime, 1))
+				mock.ExpectExec("UPDATE groups").WithArgs(
+					testGroupName, "Some notes", pgxmock.AnyArg(), testGroupID, testAccountTime,
+				).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			http.StatusNoContent, "",
+		},
+		{
+			"error due to sort index in patch", http.MethodPatch, "/v1/groups/" + testGroupID.String(), true,
+			strings.NewReader(`{"name":"` + testGroupName + `","sortIndex":3}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM groups").WithArgs(testGroupID).WillReturnRows(pgxmock.NewRows(groupRowCols).
+					AddRow(testGroupID, "Old name", "Some notes", testAccountTime, testAccountTime, testNullTime, 1))
+			},
+			http.StatusBadRequest, "sortIndex can only be changed through the order endpoints",
+		},
+		{
+			"success updating group with its current sort index", http.MethodPatch, "/v1/groups/" + testGroupID.String(), true,
+			strings.NewReader(`{"name":"` + testGroupName + `","notes":"Some notes","sortIndex":1}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM groups").WithArgs(testGroupID).WillReturnRows(pgxmock.NewRows(groupRowCols).
+					AddRow(testGroupID, "Old name", "Some notes", testAccountTime, testAccountTime, testNullTime, 1))
 				mock.ExpectExec("UPDATE groups").WithArgs(
-					testGroupName, "Some notes", pgxmock.AnyArg(), testGroupID,
+					testGroupName, "Some notes", pgxmock.AnyArg(), testGroupID, testAccountTime,
+				).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			http.StatusNoContent, "",
+		},
+		{
+			"error due to group not found", http.MethodPatch, "/v1/groups/" + testGroupID.String() + "", true, strings.NewReader(`{"name":"x"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM groups").WithArgs(testGroupID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusNotFound, "no rows",
+		},
+		{
+			"error due to unknown field", http.MethodPatch, "/v1/groups/" + testGroupID.String() + "", true, strings.NewReader(`{"nmae":"x"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM groups").WithArgs(testGroupID).WillReturnRows(pgxmock.NewRows(groupRowCols).
+					AddRow(testGroupID, "Old name", "Some notes", testAccountTime, testAccountTime, testNullTime, 1))
+			},
+			http.StatusBadRequest, "unknown field",
+		},
+		{
+			"success updating only supplied fields", http.MethodPatch, "/v1/groups/" + testGroupID.String() + "", true,
+			strings.NewReader(`{"name":"` + testGroupName + `"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM groups").WithArgs(testGroupID).WillReturnRows(pgxmock.NewRows(groupRowCols).
+					AddRow(testGroupID, "Old name", "Some notes", testAccountTime, testAccountTime, testNullTime, 1))
+				mock.ExpectExec("UPDATE groups").WithArgs(
+					testGroupName, "Some notes", pgxmock.AnyArg(), testGroupID, testAccountTime,
 				).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 			},
 			http.StatusNoContent, "",
@@ -119,7 +189,9 @@ func TestDeleteGroup(t *testing.T) {
 			nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec("DELETE FROM groups").WithArgs(testGroupID).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE groups SET deleted_at").WithArgs(testGroupID, pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
 			},
 			http.StatusInternalServerError, "tx is closed",
 		},
@@ -128,10 +200,57 @@ func TestDeleteGroup(t *testing.T) {
 			nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec("DELETE FROM groups").WithArgs(testGroupID).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE groups SET deleted_at").WithArgs(testGroupID, pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
 			},
 			http.StatusNoContent, "",
 		},
+		{
+			"error due to stale if-match", http.MethodDelete, "/v1/groups/" + testGroupID.String(), true,
+			nil,
+			http.Header{"If-Match": []string{`"stale"`}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT updated_at FROM groups").WithArgs(testGroupID).WillReturnRows(pgxmock.NewRows([]string{"updated_at"}).
+					AddRow(&testAccountTime))
+				mock.ExpectRollback()
+			},
+			http.StatusPreconditionFailed, "resource has been modified",
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestGetGroup(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, "/v1/groups/invalid-uuid", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad group id", http.MethodGet, "/v1/groups/invalid-uuid", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error due to group not found", http.MethodGet, "/v1/groups/" + testGroupID.String(), true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM groups").WithArgs(testGroupID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusNotFound, "no rows",
+		},
+		{
+			"success getting group", http.MethodGet, "/v1/groups/" + testGroupID.String(), true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM groups").WithArgs(testGroupID).WillReturnRows(pgxmock.NewRows(groupRowCols).
+					AddRow(testGroupID, testGroupName, "notes", testAccountTime, testAccountTime, testNullTime, 1))
+			},
+			http.StatusOK, testGroupID.String(),
+		},
 	}
 	executeTests(t, tests)
 }
@@ -173,7 +292,7 @@ func TestGetGroups(t *testing.T) {
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
-				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnRows(pgxmock.NewRows(groupRowCols).AddRow("invalid", "ok", "no", "bad-time", "bad-time"))
+				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnRows(pgxmock.NewRows(groupRowCols).AddRow("invalid", "ok", "no", "bad-time", "bad-time", testNullTime, 1))
 			},
 			http.StatusInternalServerError, "Scanning value error",
 		},
@@ -192,7 +311,7 @@ func TestGetGroups(t *testing.T) {
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
 				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnRows(pgxmock.NewRows(groupRowCols).AddRow(testGroupID.String(), testGroupName,
-					"notes", testAccountTime, testAccountTime))
+					"notes", testAccountTime, testAccountTime, testNullTime, 1))
 			},
 			http.StatusOK, testGroupID.String(),
 		},
@@ -212,13 +331,26 @@ func TestCreateCategory(t *testing.T) {
 			nil, nil,
 			http.StatusBadRequest, "invalid character",
 		},
+		{
+			"error due to invalid carryover", http.MethodPost, "/v1/categories", true,
+			strings.NewReader(`{"name":"` + testCategoryName + `","carryover":"negative"}`),
+			nil, nil,
+			http.StatusBadRequest, "carryover must be all or positive",
+		},
+		{
+			"error due to invalid goal", http.MethodPost, "/v1/categories", true,
+			strings.NewReader(`{"name":"` + testCategoryName + `","goal":{"type":"cap","amount":0}}`),
+			nil, nil,
+			http.StatusBadRequest, "goal amount must be positive",
+		},
 		{
 			"error inserting category to database", http.MethodPost, "/v1/categories", true,
 			strings.NewReader(`{"name":"` + testCategoryName + `","groupId":"` + testGroupID.String() + `"}`),
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec("INSERT INTO categories").WithArgs(pgxmock.AnyArg(),
-					testGroupID, testCategoryName, "", pgxmock.AnyArg(),
+				mock.ExpectQuery("INSERT INTO categories").WithArgs(pgxmock.AnyArg(),
+					testGroupID, testCategoryName, "", carryoverAll, false, testNullGoalType, testNullAmount, testNullTime,
+					false, pgxmock.AnyArg(),
 					pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
 			},
 			http.StatusInternalServerError, "tx is closed",
@@ -228,11 +360,12 @@ func TestCreateCategory(t *testing.T) {
 			strings.NewReader(`{"name":"` + testCategoryName + `","groupId":"` + testGroupID.String() + `"}`),
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec("INSERT INTO categories").WithArgs(pgxmock.AnyArg(),
-					testGroupID, testCategoryName, "", pgxmock.AnyArg(),
-					pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectQuery("INSERT INTO categories").WithArgs(pgxmock.AnyArg(),
+					testGroupID, testCategoryName, "", carryoverAll, false, testNullGoalType, testNullAmount, testNullTime,
+					false, pgxmock.AnyArg(),
+					pgxmock.AnyArg()).WillReturnRows(pgxmock.NewRows([]string{"sort_index"}).AddRow(2))
 			},
-			http.StatusCreated, testCategoryName,
+			http.StatusCreated, `"sortIndex":2,"hidden":false`,
 		},
 	}
 	executeTests(t, tests)
@@ -252,7 +385,11 @@ func TestUpdateCategory(t *testing.T) {
 		},
 		{
 			"error due to bad request", http.MethodPatch, "/v1/categories/" + testCategoryID.String(), true, strings.NewReader("invalid-body"),
-			nil, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM categories").WithArgs(testCategoryID).WillReturnRows(pgxmock.NewRows(categoryRowCols).
+					AddRow(testCategoryID, testGroupID, "Old name", "Some notes", testAccountTime, testAccountTime, testNullTime, carryoverAll, false, nil, nil, nil, 1, false))
+			},
 			http.StatusBadRequest, "invalid character",
 		},
 		{
@@ -260,8 +397,12 @@ func TestUpdateCategory(t *testing.T) {
 			strings.NewReader(`{"name":"` + testCategoryName + `","notes":"Some notes","groupId":"` + testGroupID.String() + `"}`),
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM categories").WithArgs(testCategoryID).WillReturnRows(pgxmock.NewRows(categoryRowCols).
+					AddRow(testCategoryID, testGroupID, "Old name", "Some notes", testAccountTime, testAccountTime, testNullTime, carryoverAll, false, nil, nil, nil, 1, false))
 				mock.ExpectExec("UPDATE categories").WithArgs(
-					testCategoryName, "Some notes", testGroupID, pgxmock.AnyArg(), testCategoryID,
+					testCategoryName, "Some notes", testGroupID, carryoverAll, false, testNullGoalType, testNullAmount,
+					testNullTime, false, pgxmock.AnyArg(), testCategoryID,
+					testAccountTime,
 				).WillReturnError(pgx.ErrTxClosed)
 			},
 			http.StatusInternalServerError, "tx is closed",
@@ -271,8 +412,54 @@ func TestUpdateCategory(t *testing.T) {
 			strings.NewReader(`{"name":"` + testCategoryName + `","notes":"Some notes","groupId":"` + testGroupID.String() + `"}`),
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM categories").WithArgs(testCategoryID).WillReturnRows(pgxmock.NewRows(categoryRowCols).
+					AddRow(testCategoryID, testGroupID, "Old name", "Some notes", testAccountTime, testAccountTime, testNullTime, carryoverAll, false, nil, nil, nil, 1, false))
 				mock.ExpectExec("UPDATE categories").WithArgs(
-					testCategoryName, "Some notes", testGroupID, pgxmock.AnyArg(), testCategoryID,
+					testCategoryName, "Some notes", testGroupID, carryoverAll, false, testNullGoalType, testNullAmount,
+					testNullTime, false, pgxmock.AnyArg(), testCategoryID,
+					testAccountTime,
+				).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			http.StatusNoContent, "",
+		},
+		{
+			"error due to category not found", http.MethodPatch, "/v1/categories/" + testCategoryID.String() + "", true, strings.NewReader(`{"name":"x"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM categories").WithArgs(testCategoryID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusNotFound, "no rows",
+		},
+		{
+			"error due to unknown field", http.MethodPatch, "/v1/categories/" + testCategoryID.String() + "", true, strings.NewReader(`{"nmae":"x"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM categories").WithArgs(testCategoryID).WillReturnRows(pgxmock.NewRows(categoryRowCols).
+					AddRow(testCategoryID, testGroupID, "Old name", "Some notes", testAccountTime, testAccountTime, testNullTime, carryoverAll, false, nil, nil, nil, 1, false))
+			},
+			http.StatusBadRequest, "unknown field",
+		},
+		{
+			"error due to sort index in patch", http.MethodPatch, "/v1/categories/" + testCategoryID.String(), true,
+			strings.NewReader(`{"sortIndex":2}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM categories").WithArgs(testCategoryID).WillReturnRows(pgxmock.NewRows(categoryRowCols).
+					AddRow(testCategoryID, testGroupID, "Old name", "Some notes", testAccountTime, testAccountTime, testNullTime, carryoverAll, false, nil, nil, nil, 1, false))
+			},
+			http.StatusBadRequest, "sortIndex can only be changed through the order endpoints",
+		},
+		{
+			"success updating only supplied fields", http.MethodPatch, "/v1/categories/" + testCategoryID.String() + "", true,
+			strings.NewReader(`{"name":"` + testCategoryName + `"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM categories").WithArgs(testCategoryID).WillReturnRows(pgxmock.NewRows(categoryRowCols).
+					AddRow(testCategoryID, testGroupID, "Old name", "Some notes", testAccountTime, testAccountTime, testNullTime, carryoverAll, false, nil, nil, nil, 1, false))
+				mock.ExpectExec("UPDATE categories").WithArgs(
+					testCategoryName, "Some notes", testGroupID, carryoverAll, false, testNullGoalType, testNullAmount,
+					testNullTime, false, pgxmock.AnyArg(), testCategoryID,
+					testAccountTime,
 				).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 			},
 			http.StatusNoContent, "",
@@ -298,7 +485,9 @@ func TestDeleteCategory(t *testing.T) {
 			nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec("DELETE FROM categories").WithArgs(testCategoryID).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE categories SET deleted_at").WithArgs(testCategoryID, pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
 			},
 			http.StatusInternalServerError, "tx is closed",
 		},
@@ -307,10 +496,57 @@ func TestDeleteCategory(t *testing.T) {
 			nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec("DELETE FROM categories").WithArgs(testCategoryID).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE categories SET deleted_at").WithArgs(testCategoryID, pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
 			},
 			http.StatusNoContent, "",
 		},
+		{
+			"error due to stale if-match", http.MethodDelete, "/v1/categories/" + testCategoryID.String(), true,
+			nil,
+			http.Header{"If-Match": []string{`"stale"`}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT updated_at FROM categories").WithArgs(testCategoryID).WillReturnRows(pgxmock.NewRows([]string{"updated_at"}).
+					AddRow(&testAccountTime))
+				mock.ExpectRollback()
+			},
+			http.StatusPreconditionFailed, "resource has been modified",
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestGetCategory(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, "/v1/categories/invalid-uuid", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad category id", http.MethodGet, "/v1/categories/invalid-uuid", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error due to category not found", http.MethodGet, "/v1/categories/" + testCategoryID.String(), true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM categories").WithArgs(testCategoryID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusNotFound, "no rows",
+		},
+		{
+			"success getting category", http.MethodGet, "/v1/categories/" + testCategoryID.String(), true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM categories").WithArgs(testCategoryID).WillReturnRows(pgxmock.NewRows(categoryRowCols).
+					AddRow(testCategoryID, testGroupID, testCategoryName, "notes", testAccountTime, testAccountTime, testNullTime, carryoverAll, false, nil, nil, nil, 1, false))
+			},
+			http.StatusOK, testCategoryID.String(),
+		},
 	}
 	executeTests(t, tests)
 }
@@ -326,7 +562,7 @@ func TestGetCategories(t *testing.T) {
 			"error getting total categories from db", http.MethodGet, "/v1/categories?q=some", true, nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnError(pgx.ErrNoRows)
+				mock.ExpectQuery("SELECT *").WithArgs("some", false).WillReturnError(pgx.ErrNoRows)
 			},
 			http.StatusInternalServerError, "no rows",
 		},
@@ -334,7 +570,7 @@ func TestGetCategories(t *testing.T) {
 			"error scanning total categories rows from db", http.MethodGet, "/v1/categories?q=some", true, nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(""))
+				mock.ExpectQuery("SELECT *").WithArgs("some", false).WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(""))
 			},
 			http.StatusInternalServerError, "not supported",
 		},
@@ -342,8 +578,8 @@ func TestGetCategories(t *testing.T) {
 			"error getting categories from db", http.MethodGet, "/v1/categories?q=some", true, nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
-				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnError(pgx.ErrNoRows)
+				mock.ExpectQuery("SELECT *").WithArgs("some", false).WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
+				mock.ExpectQuery("SELECT *").WithArgs("some", false).WillReturnError(pgx.ErrNoRows)
 			},
 			http.StatusInternalServerError, "no rows",
 		},
@@ -351,9 +587,9 @@ func TestGetCategories(t *testing.T) {
 			"error scanning categories rows from db", http.MethodGet, "/v1/categories?q=some", true, nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
-				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnRows(pgxmock.NewRows(categoryRowCols).
-					AddRow("invalid", "invalid", "ok", "ok", "bad-time", "bad-time"))
+				mock.ExpectQuery("SELECT *").WithArgs("some", false).WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
+				mock.ExpectQuery("SELECT *").WithArgs("some", false).WillReturnRows(pgxmock.NewRows(categoryRowCols).
+					AddRow("invalid", "invalid", "ok", "ok", "bad-time", "bad-time", testNullTime, carryoverAll, false, nil, nil, nil, 1, false))
 			},
 			http.StatusInternalServerError, "Scanning value error",
 		},
@@ -361,8 +597,8 @@ func TestGetCategories(t *testing.T) {
 			"error reading categories rows from db", http.MethodGet, "/v1/categories?q=some", true, nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
-				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnRows(pgxmock.NewRows(categoryRowCols).
+				mock.ExpectQuery("SELECT *").WithArgs("some", false).WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
+				mock.ExpectQuery("SELECT *").WithArgs("some", false).WillReturnRows(pgxmock.NewRows(categoryRowCols).
 					RowError(0, errors.New("some error in db")))
 			},
 			http.StatusInternalServerError, "some error in db",
@@ -371,13 +607,88 @@ func TestGetCategories(t *testing.T) {
 			"success", http.MethodGet, "/v1/categories?q=some", true, nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
-				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnRows(
+				mock.ExpectQuery("SELECT *").WithArgs("some", false).WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
+				mock.ExpectQuery("SELECT *").WithArgs("some", false).WillReturnRows(
 					pgxmock.NewRows(categoryRowCols).AddRow(testCategoryID.String(), testGroupID.String(),
-						testCategoryName, "notes", testAccountTime, testAccountTime))
+						testCategoryName, "notes", testAccountTime, testAccountTime, testNullTime, carryoverAll, false, nil, nil, nil, 1, false))
 			},
 			http.StatusOK, testCategoryID.String(),
 		},
+		{
+			"success getting categories with hidden ones", http.MethodGet, "/v1/categories?q=some&hidden=true", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT *").WithArgs("some", true).WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
+				mock.ExpectQuery("SELECT *").WithArgs("some", true).WillReturnRows(
+					pgxmock.NewRows(categoryRowCols).AddRow(testCategoryID.String(), testGroupID.String(),
+						testCategoryName, "notes", testAccountTime, testAccountTime, testNullTime, carryoverAll, false, nil, nil, nil, 1, true))
+			},
+			http.StatusOK, `"hidden":true`,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestReorder(t *testing.T) {
+	testReorderBody := `{"ids":["` + testGroupID.String() + `","` + testCategoryID.String() + `"]}`
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodPut, "/v1/groups/order", false, strings.NewReader(testReorderBody),
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad request", http.MethodPut, "/v1/groups/order", true, strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusBadRequest, "invalid character",
+		},
+		{
+			"error due to missing ids", http.MethodPut, "/v1/categories/order", true, strings.NewReader(`{"ids":[]}`),
+			nil, nil,
+			http.StatusBadRequest, "ids are required",
+		},
+		{
+			"error due to repeated ids", http.MethodPut, "/v1/categories/order", true,
+			strings.NewReader(`{"ids":["` + testGroupID.String() + `","` + testGroupID.String() + `"]}`),
+			nil, nil,
+			http.StatusBadRequest, "ids must not repeat",
+		},
+		{
+			"error reordering groups in database", http.MethodPut, "/v1/groups/order", true,
+			strings.NewReader(testReorderBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE groups SET sort_index").WithArgs([]uuid.UUID{testGroupID, testCategoryID},
+					pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error due to unknown category", http.MethodPut, "/v1/categories/order", true,
+			strings.NewReader(testReorderBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE categories SET sort_index").WithArgs([]uuid.UUID{testGroupID, testCategoryID},
+					pgxmock.AnyArg()).WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectRollback()
+			},
+			http.StatusNotFound, "some ids were not found",
+		},
+		{
+			"success reordering categories", http.MethodPut, "/v1/categories/order", true,
+			strings.NewReader(testReorderBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE categories SET sort_index").WithArgs([]uuid.UUID{testGroupID, testCategoryID},
+					pgxmock.AnyArg()).WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectCommit()
+			},
+			http.StatusNoContent, "",
+		},
 	}
 	executeTests(t, tests)
 }
@@ -399,8 +710,10 @@ func TestSetBudget(t *testing.T) {
 			strings.NewReader(`{"categoryId":"` + testCategoryID.String() + `","year":2024,"month":10,"budgeted":500.69}`),
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
 				mock.ExpectExec("INSERT INTO budgets").WithArgs(pgxmock.AnyArg(), testCategoryID,
 					uint16(2024), uint8(10), 500.69, pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
 			},
 			http.StatusInternalServerError, "tx is closed",
 		},
@@ -409,8 +722,52 @@ func TestSetBudget(t *testing.T) {
 			strings.NewReader(`{"categoryId":"` + testCategoryID.String() + `","year":2024,"month":10,"budgeted":500.69}`),
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
 				mock.ExpectExec("INSERT INTO budgets").WithArgs(pgxmock.AnyArg(), testCategoryID,
 					uint16(2024), uint8(10), 500.69, pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusOK, "500.69",
+		},
+		{
+			"error due to stale if-match", http.MethodPut, "/v1/budgets", true,
+			strings.NewReader(`{"categoryId":"` + testCategoryID.String() + `","year":2024,"month":10,"budgeted":500.69}`),
+			http.Header{"If-Match": []string{`"stale"`}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT MAX").WithArgs(uint16(2024), uint8(10)).WillReturnRows(pgxmock.NewRows([]string{"max"}).
+					AddRow(&testAccountTime))
+				mock.ExpectRollback()
+			},
+			http.StatusPreconditionFailed, "resource has been modified",
+		},
+		{
+			"success setting budget with matching if-match", http.MethodPut, "/v1/budgets", true,
+			strings.NewReader(`{"categoryId":"` + testCategoryID.String() + `","year":2024,"month":10,"budgeted":500.69}`),
+			http.Header{"If-Match": []string{etag(testAccountTime)}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT MAX").WithArgs(uint16(2024), uint8(10)).WillReturnRows(pgxmock.NewRows([]string{"max"}).
+					AddRow(&testAccountTime))
+				mock.ExpectExec("INSERT INTO budgets").WithArgs(pgxmock.AnyArg(), testCategoryID,
+					uint16(2024), uint8(10), 500.69, pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusOK, "500.69",
+		},
+		{
+			"success setting budget with a newer budget on an income category", http.MethodPut, "/v1/budgets", true,
+			strings.NewReader(`{"categoryId":"` + testCategoryID.String() + `","year":2024,"month":10,"budgeted":500.69}`),
+			http.Header{"If-Match": []string{etag(testAccountTime)}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT MAX.+NOT cg\.income AND NOT cg\.hidden.+g\.deleted_at IS NULL`).
+					WithArgs(uint16(2024), uint8(10)).WillReturnRows(pgxmock.NewRows([]string{"max"}).
+					AddRow(&testAccountTime))
+				mock.ExpectExec("INSERT INTO budgets").WithArgs(pgxmock.AnyArg(), testCategoryID,
+					uint16(2024), uint8(10), 500.69, pgxmock.AnyArg(), pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
 			},
 			http.StatusOK, "500.69",
 		},
@@ -448,7 +805,7 @@ func TestGetBudget(t *testing.T) {
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WithArgs(2024, 10).WillReturnRows(pgxmock.NewRows(budgetRowCols).
-					AddRow(500.69, 4.20, uint16(2024), uint8(10), nil, nil, nil, "invalid", "invalid", "invalid"))
+					AddRow(500.69, 4.20, uint16(2024), uint8(10), nil, nil, nil, "invalid", "invalid", "invalid", nil, nil, nil, nil, nil))
 			},
 			http.StatusInternalServerError, "Scanning value error",
 		},
@@ -462,15 +819,209 @@ func TestGetBudget(t *testing.T) {
 			http.StatusInternalServerError, "some error in db",
 		},
 		{
-			"success", http.MethodGet, "/v1/budgets?year=2024&month=10", true, nil,
+			"error getting budget snapshot from db", http.MethodGet, "/v1/budgets?year=2024&month=10", true, nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectQuery("SELECT *").WithArgs(2024, 10).WillReturnRows(pgxmock.NewRows(budgetRowCols).
+				mock.ExpectQuery("FROM groups AS g").WithArgs(2024, 10).WillReturnRows(pgxmock.NewRows(budgetRowCols).
+					AddRow(testAmount, 4.20, uint16(2024), uint8(10), &testCategoryID, &testCategoryName, nil,
+						testGroupID, testGroupName, "notes", &testCarryoverAll, nil, nil, nil, &testAccountTime))
+				mock.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+				mock.ExpectQuery("SELECT generation FROM budget_snapshot_generation").WillReturnRows(
+					pgxmock.NewRows(budgetGenerationCols).AddRow(int64(1)))
+				mock.ExpectQuery("FROM budget_snapshots").WithArgs(2024, 10).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error getting budget activity from db", http.MethodGet, "/v1/budgets?year=2024&month=10", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM groups AS g").WithArgs(2024, 10).WillReturnRows(pgxmock.NewRows(budgetRowCols).
+					AddRow(testAmount, 4.20, uint16(2024), uint8(10), &testCategoryID, &testCategoryName, nil,
+						testGroupID, testGroupName, "notes", &testCarryoverAll, nil, nil, nil, &testAccountTime))
+				mock.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+				mock.ExpectQuery("SELECT generation FROM budget_snapshot_generation").WillReturnRows(
+					pgxmock.NewRows(budgetGenerationCols).AddRow(int64(1)))
+				mock.ExpectQuery("FROM budget_snapshots").WithArgs(2024, 10).WillReturnRows(
+					pgxmock.NewRows(budgetSnapshotRowCols))
+				mock.ExpectQuery("FROM \\(SELECT category_id, year, month, budgeted").WithArgs(1, 1, 2024, 10).
+					WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success without history", http.MethodGet, "/v1/budgets?year=2024&month=10", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM groups AS g").WithArgs(2024, 10).WillReturnRows(pgxmock.NewRows(budgetRowCols).
+					AddRow(testAmount, 4.20, uint16(2024), uint8(10), &testCategoryID, &testCategoryName, nil,
+						testGroupID, testGroupName, "notes", &testCarryoverAll, nil, nil, nil, &testAccountTime))
+				mock.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+				mock.ExpectQuery("SELECT generation FROM budget_snapshot_generation").WillReturnRows(
+					pgxmock.NewRows(budgetGenerationCols).AddRow(int64(1)))
+				mock.ExpectQuery("FROM budget_snapshots").WithArgs(2024, 10).WillReturnRows(
+					pgxmock.NewRows(budgetSnapshotRowCols))
+				mock.ExpectQuery("FROM \\(SELECT category_id, year, month, budgeted").WithArgs(1, 1, 2024, 10).
+					WillReturnRows(pgxmock.NewRows(budgetActivityRowCols))
+				mock.ExpectCommit()
+				mock.ExpectQuery("SELECT COALESCE\\(SUM\\(amount\\)").WithArgs(2024, 10).WillReturnRows(
+					pgxmock.NewRows(budgetSummaryRowCols).AddRow(0.0, testAmount, 0.0, testAmount))
+			},
+			http.StatusOK, `"budgeted":42.69,"spent":4.2,"available":46.89`,
+		},
+		{
+			"success grouping budgets with totals", http.MethodGet, "/v1/budgets?year=2024&month=10", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM groups AS g").WithArgs(2024, 10).WillReturnRows(pgxmock.NewRows(budgetRowCols).
+					AddRow(testAmount, 4.20, uint16(2024), uint8(10), &testCategoryID, &testCategoryName, nil,
+						testGroupID, testGroupName, "notes", &testCarryoverAll, nil, nil, nil, &testAccountTime))
+				mock.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+				mock.ExpectQuery("SELECT generation FROM budget_snapshot_generation").WillReturnRows(
+					pgxmock.NewRows(budgetGenerationCols).AddRow(int64(1)))
+				mock.ExpectQuery("FROM budget_snapshots").WithArgs(2024, 10).WillReturnRows(
+					pgxmock.NewRows(budgetSnapshotRowCols))
+				mock.ExpectQuery("FROM \\(SELECT category_id, year, month, budgeted").WithArgs(1, 1, 2024, 10).
+					WillReturnRows(pgxmock.NewRows(budgetActivityRowCols))
+				mock.ExpectCommit()
+				mock.ExpectQuery("SELECT COALESCE\\(SUM\\(amount\\)").WithArgs(2024, 10).WillReturnRows(
+					pgxmock.NewRows(budgetSummaryRowCols).AddRow(0.0, testAmount, 0.0, testAmount))
+			},
+			http.StatusOK, `"groups":[{"groupId":"` + testGroupID.String() + `","groupName":"Recurring Expenses",` +
+				`"groupNotes":"notes","budgeted":42.69,"spent":4.2,"available":46.89,"categories":[{`,
+		},
+		{
+			"success reporting goals", http.MethodGet, "/v1/budgets?year=2024&month=10", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				goalType, goalAmount := goalMonthly, 100.0
+				mock.ExpectQuery("FROM groups AS g").WithArgs(2024, 10).WillReturnRows(pgxmock.NewRows(budgetRowCols).
+					AddRow(testAmount, 4.20, uint16(2024), uint8(10), &testCategoryID, &testCategoryName, nil,
+						testGroupID, testGroupName, "notes", &testCarryoverAll, &goalType, &goalAmount, nil,
+						&testAccountTime))
+				mock.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+				mock.ExpectQuery("SELECT generation FROM budget_snapshot_generation").WillReturnRows(
+					pgxmock.NewRows(budgetGenerationCols).AddRow(int64(1)))
+				mock.ExpectQuery("FROM budget_snapshots").WithArgs(2024, 10).WillReturnRows(
+					pgxmock.NewRows(budgetSnapshotRowCols))
+				mock.ExpectQuery("FROM \\(SELECT category_id, year, month, budgeted").WithArgs(1, 1, 2024, 10).
+					WillReturnRows(pgxmock.NewRows(budgetActivityRowCols))
+				mock.ExpectCommit()
+				mock.ExpectQuery("SELECT COALESCE\\(SUM\\(amount\\)").WithArgs(2024, 10).WillReturnRows(
+					pgxmock.NewRows(budgetSummaryRowCols).AddRow(0.0, testAmount, 0.0, testAmount))
+			},
+			http.StatusOK, `"goal":{"type":"monthly","amount":100,"needed":57.31,"progress":42.69,"underfunded":true}`,
+		},
+		{
+			"error getting budget summary from db", http.MethodGet, "/v1/budgets?year=2024&month=10", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM groups AS g").WithArgs(2024, 10).WillReturnRows(pgxmock.NewRows(budgetRowCols).
 					AddRow(testAmount, 4.20, uint16(2024), uint8(10), &testCategoryID, &testCategoryName, nil,
-						testGroupID, testGroupName, "notes"))
+						testGroupID, testGroupName, "notes", &testCarryoverAll, nil, nil, nil, &testAccountTime))
+				mock.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+				mock.ExpectQuery("SELECT generation FROM budget_snapshot_generation").WillReturnRows(
+					pgxmock.NewRows(budgetGenerationCols).AddRow(int64(1)))
+				mock.ExpectQuery("FROM budget_snapshots").WithArgs(2024, 10).WillReturnRows(
+					pgxmock.NewRows(budgetSnapshotRowCols))
+				mock.ExpectQuery("FROM \\(SELECT category_id, year, month, budgeted").WithArgs(1, 1, 2024, 10).
+					WillReturnRows(pgxmock.NewRows(budgetActivityRowCols))
+				mock.ExpectCommit()
+				mock.ExpectQuery("SELECT COALESCE\\(SUM\\(amount\\)").WithArgs(2024, 10).
+					WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success carrying over from a snapshot", http.MethodGet, "/v1/budgets?year=2024&month=10", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM groups AS g").WithArgs(2024, 10).WillReturnRows(pgxmock.NewRows(budgetRowCols).
+					AddRow(100.0, -20.0, uint16(2024), uint8(10), &testCategoryID, &testCategoryName, nil,
+						testGroupID, testGroupName, "notes", &testCarryoverPositive, nil, nil, nil, &testAccountTime))
+				mock.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+				mock.ExpectQuery("SELECT generation FROM budget_snapshot_generation").WillReturnRows(
+					pgxmock.NewRows(budgetGenerationCols).AddRow(int64(1)))
+				mock.ExpectQuery("FROM budget_snapshots").WithArgs(2024, 10).WillReturnRows(
+					pgxmock.NewRows(budgetSnapshotRowCols).AddRow(testCategoryID, -50.0, 10.0, 2024, 8))
+				mock.ExpectQuery("FROM \\(SELECT category_id, year, month, budgeted").WithArgs(2024, 9, 2024, 10).
+					WillReturnRows(pgxmock.NewRows(budgetActivityRowCols).AddRow(testCategoryID, 2024, 9, 100.0, -30.0))
+				mock.ExpectQuery("SELECT generation .+ FOR UPDATE").WillReturnRows(
+					pgxmock.NewRows(budgetGenerationCols).AddRow(int64(1)))
+				mock.ExpectExec("INSERT INTO budget_snapshots").WithArgs([]int{2024}, []int{9},
+					[]uuid.UUID{testCategoryID}, []float64{70.0}, []float64{60.0}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
+				mock.ExpectQuery("SELECT COALESCE\\(SUM\\(amount\\)").WithArgs(2024, 10).WillReturnRows(
+					pgxmock.NewRows(budgetSummaryRowCols).AddRow(500.0, 100.0, 1000.0, 300.0))
 			},
-			http.StatusOK, fmt.Sprintf("%.2f", testAmount),
+			http.StatusOK, `"summary":{"income":500,"assigned":100,"overspent":0,"readyToAssign":640}`,
+		},
+		{
+			"success skipping snapshots invalidated meanwhile", http.MethodGet, "/v1/budgets?year=2024&month=10", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM groups AS g").WithArgs(2024, 10).WillReturnRows(pgxmock.NewRows(budgetRowCols).
+					AddRow(100.0, -20.0, uint16(2024), uint8(10), &testCategoryID, &testCategoryName, nil,
+						testGroupID, testGroupName, "notes", &testCarryoverPositive, nil, nil, nil, &testAccountTime))
+				mock.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+				mock.ExpectQuery("SELECT generation FROM budget_snapshot_generation").WillReturnRows(
+					pgxmock.NewRows(budgetGenerationCols).AddRow(int64(1)))
+				mock.ExpectQuery("FROM budget_snapshots").WithArgs(2024, 10).WillReturnRows(
+					pgxmock.NewRows(budgetSnapshotRowCols).AddRow(testCategoryID, -50.0, 10.0, 2024, 8))
+				mock.ExpectQuery("FROM \\(SELECT category_id, year, month, budgeted").WithArgs(2024, 9, 2024, 10).
+					WillReturnRows(pgxmock.NewRows(budgetActivityRowCols).AddRow(testCategoryID, 2024, 9, 100.0, -30.0))
+				mock.ExpectQuery("SELECT generation .+ FOR UPDATE").WillReturnRows(
+					pgxmock.NewRows(budgetGenerationCols).AddRow(int64(2)))
+				mock.ExpectRollback()
+				mock.ExpectQuery("SELECT COALESCE\\(SUM\\(amount\\)").WithArgs(2024, 10).WillReturnRows(
+					pgxmock.NewRows(budgetSummaryRowCols).AddRow(500.0, 100.0, 1000.0, 300.0))
+			},
+			http.StatusOK, `"summary":{"income":500,"assigned":100,"overspent":0,"readyToAssign":640}`,
+		},
+		{
+			"success carrying over overspending from the previous month", http.MethodGet, "/v1/budgets?year=2024&month=10",
+			true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM groups AS g").WithArgs(2024, 10).WillReturnRows(pgxmock.NewRows(budgetRowCols).
+					AddRow(100.0, -20.0, uint16(2024), uint8(10), &testCategoryID, &testCategoryName, nil,
+						testGroupID, testGroupName, "notes", &testCarryoverPositive, nil, nil, nil, &testAccountTime))
+				mock.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+				mock.ExpectQuery("SELECT generation FROM budget_snapshot_generation").WillReturnRows(
+					pgxmock.NewRows(budgetGenerationCols).AddRow(int64(1)))
+				mock.ExpectQuery("FROM budget_snapshots").WithArgs(2024, 10).WillReturnRows(
+					pgxmock.NewRows(budgetSnapshotRowCols).AddRow(testCategoryID, -50.0, 10.0, 2024, 9))
+				mock.ExpectQuery("FROM \\(SELECT category_id, year, month, budgeted").WithArgs(2024, 10, 2024, 10).
+					WillReturnRows(pgxmock.NewRows(budgetActivityRowCols))
+				mock.ExpectCommit()
+				mock.ExpectQuery("SELECT COALESCE\\(SUM\\(amount\\)").WithArgs(2024, 10).WillReturnRows(
+					pgxmock.NewRows(budgetSummaryRowCols).AddRow(500.0, 100.0, 1000.0, 300.0))
+			},
+			http.StatusOK, `"summary":{"income":500,"assigned":100,"overspent":50,"readyToAssign":640}`,
 		},
 	}
 	executeTests(t, tests)
 }
+
+func TestGroupBudgets(t *testing.T) {
+	emptyGroupID := uuid.MustParse("01927f36-44b8-7e62-a7a9-395eacab5631")
+	budgets := []BudgetResult{
+		{CategoryID: &testCategoryID, GroupID: testGroupID, GroupName: testGroupName, Budgeted: 0.1, Spent: -0.3,
+			Available: 0.2},
+		{CategoryID: &testToCategoryID, GroupID: testGroupID, GroupName: testGroupName, Budgeted: 0.2, Spent: 0,
+			Available: 0.2},
+		{GroupID: emptyGroupID, GroupName: "Empty"},
+	}
+
+	groups, totals := groupBudgets(budgets)
+
+	assert.Len(t, groups, 2)
+	assert.Len(t, groups[0].Categories, 2)
+	assert.Equal(t, BudgetTotals{Budgeted: 0.3, Spent: -0.3, Available: 0.4}, groups[0].BudgetTotals)
+	assert.Equal(t, emptyGroupID, groups[1].GroupID)
+	assert.Empty(t, groups[1].Categories)
+	assert.Equal(t, BudgetTotals{Budgeted: 0.3, Spent: -0.3, Available: 0.4}, totals)
+}
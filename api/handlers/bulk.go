@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type (
+	// BulkTransactions model.
+	BulkTransactions struct {
+		IDs        []uuid.UUID       `json:"ids,omitempty"`
+		Filter     map[string]string `json:"filter,omitempty"`
+		Operation  string            `json:"operation"`
+		CategoryID *uuid.UUID        `json:"categoryId,omitempty"`
+		PayeeID    *uuid.UUID        `json:"payeeId,omitempty"`
+		AccountID  *uuid.UUID        `json:"accountId,omitempty"`
+		ClearedAt  *time.Time        `json:"clearedAt,omitempty"`
+		Tags       []string          `json:"tags,omitempty"`
+	}
+
+	// BulkResult model.
+	BulkResult struct {
+		ID     uuid.UUID `json:"id"`
+		Status string    `json:"status"`
+		Error  string    `json:"error,omitempty"`
+	}
+)
+
+const (
+	bulkSetCategory  = "setCategory"
+	bulkSetPayee     = "setPayee"
+	bulkSetCleared   = "setCleared"
+	bulkClearCleared = "clearCleared"
+	bulkAddTags      = "addTags"
+	bulkRemoveTags   = "removeTags"
+	bulkMove         = "move"
+	bulkDelete       = "delete"
+
+	bulkStatusOK    = "ok"
+	bulkStatusError = "error"
+)
+
+var (
+	errBulkTarget          = errors.New("either ids or filter is required")
+	errBulkEmptyFilter     = errors.New("filter must have at least one criterion")
+	errBulkOperation       = errors.New("unknown bulk operation")
+	errBulkClearedAt       = errors.New("clearedAt is required to set the cleared date")
+	errBulkTags            = errors.New("tags are required to add or remove tags")
+	errBulkAccount         = errors.New("accountId is required to move transactions")
+	errBulkMoveTransfer    = errors.New("transfer transactions cannot be moved")
+	errBulkMoveAccount     = errors.New("accountId must be an account that exists and is not trashed")
+	errTransactionNotFound = errors.New("transaction not found")
+)
+
+const (
+	queryGetBulkTransactionIDs = `SELECT t.id FROM transactions AS t` + queryTransactionsFilter + ` ORDER BY t.id`
+	// queryGetBulkTransaction locks a transaction and reports whether it or the other side of its transfer is
+	// reconciled.
+	queryGetBulkTransaction = `SELECT t.transfer_id, EXISTS (SELECT 1 FROM transactions AS o WHERE (o.id=t.id OR` +
+		` o.transfer_id=t.transfer_id) AND o.status = 'reconciled') FROM transactions AS t WHERE t.id=$1` +
+		` AND t.deleted_at IS NULL FOR UPDATE OF t`
+	queryBulkSetCategory = `UPDATE transactions SET category_id=$2, updated_at=$3 WHERE id=$1`
+	queryBulkSetPayee    = `UPDATE transactions SET payee_id=$2, updated_at=$3 WHERE id=$1`
+	// queryBulkSetCleared also mirrors the cleared date onto the other side of a transfer. Each reconciled side stays
+	// reconciled, the others become cleared.
+	queryBulkSetCleared = `UPDATE transactions SET cleared_at=$2, status = CASE WHEN status = 'reconciled'` +
+		` THEN status ELSE 'cleared' END, updated_at=$3 WHERE (id=$1 OR` +
+		` transfer_id = (SELECT transfer_id FROM transactions WHERE id=$1)) AND deleted_at IS NULL`
+	// queryBulkClearCleared also unclears the other side of a transfer, taking both out of their reconciliations.
+	queryBulkClearCleared = `UPDATE transactions SET cleared_at=NULL, status='uncleared', reconciliation_id=NULL,` +
+		` updated_at=$2 WHERE (id=$1 OR transfer_id = (SELECT transfer_id FROM transactions WHERE id=$1))` +
+		` AND deleted_at IS NULL`
+	// queryLockBulkAccount keeps the account transactions are moved to from being trashed until they are moved.
+	queryLockBulkAccount       = `SELECT id FROM accounts WHERE id=$1 AND deleted_at IS NULL FOR SHARE`
+	queryBulkMoveTransaction   = `UPDATE transactions SET account_id=$2, updated_at=$3 WHERE id=$1`
+	queryBulkDeleteTransaction = `UPDATE transactions SET deleted_at=$2 WHERE (id=$1 OR` +
+		` transfer_id = (SELECT transfer_id FROM transactions WHERE id=$1)) AND deleted_at IS NULL`
+)
+
+func (h *Handler) BulkTransactions(w http.ResponseWriter, r *http.Request) { //nolint: funlen,cyclop
+	var bulk BulkTransactions
+
+	err := json.NewDecoder(r.Body).Decode(&bulk)
+	if err != nil {
+		slog.Error("error decoding bulk transactions request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	err = validateBulkTransactions(&bulk)
+	if err != nil {
+		slog.Error("error validating bulk transactions request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var filterArgs []any
+
+	if bulk.IDs == nil {
+		filterArgs, err = parseBulkFilter(bulk.Filter)
+		if err != nil {
+			slog.Error("error parsing bulk transactions filter", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	tx, err := h.db.Begin(r.Context())
+	if err != nil {
+		slog.Error("error creating database txn", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	defer func(ctx context.Context) {
+		if err != nil {
+			rollBackErr := tx.Rollback(ctx)
+			if rollBackErr != nil {
+				slog.Error("error rolling back database txn", "error", rollBackErr)
+			}
+		}
+	}(r.Context())
+
+	if bulk.Operation == bulkMove {
+		err = tx.QueryRow(r.Context(), queryLockBulkAccount, bulk.AccountID).Scan(&bulk.AccountID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = errBulkMoveAccount
+		}
+
+		if err != nil {
+			slog.Error("error getting bulk transactions account", "error", err)
+			buildErrorResponse(w, err.Error(), bulkAccountErrorStatus(err))
+
+			return
+		}
+	}
+
+	if bulk.IDs == nil {
+		bulk.IDs, err = getBulkTransactionIDs(r.Context(), tx, filterArgs)
+		if err != nil {
+			slog.Error("error getting bulk transactions from database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+	}
+
+	results := make([]BulkResult, len(bulk.IDs))
+	failed := 0
+
+	for idx, id := range bulk.IDs {
+		results[idx] = BulkResult{ID: id, Status: bulkStatusOK}
+
+		_, err = tx.Exec(r.Context(), "SAVEPOINT bulk_item")
+		if err != nil {
+			slog.Error("error storing savepoint", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		opErr := applyBulkOperation(r.Context(), tx, bulk, id, forced(r))
+		if opErr != nil {
+			slog.Error("error applying bulk operation", "error", opErr, "transaction", id)
+
+			results[idx] = BulkResult{ID: id, Status: bulkStatusError, Error: opErr.Error()}
+			failed++
+
+			_, err = tx.Exec(r.Context(), "ROLLBACK TO SAVEPOINT bulk_item")
+			if err != nil {
+				slog.Error("error rolling back savepoint", "error", err)
+				buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+				return
+			}
+
+			continue
+		}
+	}
+
+	err = tx.Commit(r.Context())
+	if err != nil {
+		slog.Error("error committing database txn", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	encodeErr := json.NewEncoder(w).Encode(map[string]interface{}{"total": len(results),
+		"succeeded": len(results) - failed, "failed": failed, "results": results})
+	if encodeErr != nil {
+		slog.Error("error encoding bulk transactions response", "error", encodeErr)
+	}
+}
+
+// applyBulkOperation applies the bulk operation to a single transaction. Reconciled transactions only change their
+// amounts, clearing or account when forced.
+func applyBulkOperation(ctx context.Context, tx pgx.Tx, bulk BulkTransactions, id uuid.UUID, force bool) error {
+	var (
+		transferID *uuid.UUID
+		reconciled bool
+	)
+
+	err := tx.QueryRow(ctx, queryGetBulkTransaction, id).Scan(&transferID, &reconciled)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errTransactionNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("error getting transaction: %w", err)
+	}
+
+	if reconciled && !force && bulkChangesReconciled(bulk.Operation) {
+		return errTransactionReconciled
+	}
+
+	now := time.Now()
+
+	switch bulk.Operation {
+	case bulkSetCategory:
+		_, err = tx.Exec(ctx, queryBulkSetCategory, id, bulk.CategoryID, now)
+	case bulkSetPayee:
+		_, err = tx.Exec(ctx, queryBulkSetPayee, id, bulk.PayeeID, now)
+	case bulkSetCleared:
+		_, err = tx.Exec(ctx, queryBulkSetCleared, id, bulk.ClearedAt, now)
+	case bulkClearCleared:
+		_, err = tx.Exec(ctx, queryBulkClearCleared, id, now)
+	case bulkAddTags:
+		err = addTransactionTags(ctx, tx, id, bulk.Tags)
+	case bulkRemoveTags:
+		err = removeTransactionTags(ctx, tx, id, bulk.Tags)
+	case bulkMove:
+		if transferID != nil {
+			return errBulkMoveTransfer
+		}
+
+		_, err = tx.Exec(ctx, queryBulkMoveTransaction, id, bulk.AccountID, now)
+	case bulkDelete:
+		_, err = tx.Exec(ctx, queryBulkDeleteTransaction, id, now)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error updating transaction: %w", err)
+	}
+
+	return nil
+}
+
+func bulkAccountErrorStatus(err error) int {
+	if errors.Is(err, errBulkMoveAccount) {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
+
+// bulkChangesReconciled reports whether an operation changes what a reconciliation relies on.
+func bulkChangesReconciled(operation string) bool {
+	switch operation {
+	case bulkSetCleared, bulkClearCleared, bulkMove, bulkDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func getBulkTransactionIDs(ctx context.Context, tx pgx.Tx, filterArgs []any) ([]uuid.UUID, error) {
+	rows, err := tx.Query(ctx, queryGetBulkTransactionIDs, filterArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting transactions: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+
+	for rows.Next() {
+		var id uuid.UUID
+
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning transactions row: %w", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading transactions rows: %w", err)
+	}
+
+	return ids, nil
+}
+
+// parseBulkFilter returns the arguments of queryTransactionsFilter for a filter given as query parameters, refusing
+// a filter that would match every transaction.
+func parseBulkFilter(filter map[string]string) ([]any, error) {
+	values := url.Values{}
+	for key, value := range filter {
+		values.Set(key, value)
+	}
+
+	accountIDs, err := parseAccountIDs(values.Get("accountIds"))
+	if err != nil {
+		return nil, err
+	}
+
+	transactionFilter, err := parseTransactionFilter(values)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(accountIDs) == 0 && !transactionFilter.narrows() {
+		return nil, errBulkEmptyFilter
+	}
+
+	return append([]any{accountIDs}, transactionFilter.args()...), nil
+}
+
+func validateBulkTransactions(bulk *BulkTransactions) error {
+	if bulk.IDs == nil && bulk.Filter == nil {
+		return errBulkTarget
+	}
+
+	switch bulk.Operation {
+	case bulkSetCategory, bulkSetPayee, bulkDelete:
+	case bulkSetCleared:
+		if bulk.ClearedAt == nil {
+			return errBulkClearedAt
+		}
+	case bulkClearCleared:
+		bulk.ClearedAt = nil
+	case bulkAddTags, bulkRemoveTags:
+		bulk.Tags = parseTags(bulk.Tags)
+		if len(bulk.Tags) == 0 {
+			return errBulkTags
+		}
+	case bulkMove:
+		if bulk.AccountID == nil {
+			return errBulkAccount
+		}
+	default:
+		return fmt.Errorf("%w: %q", errBulkOperation, bulk.Operation)
+	}
+
+	return nil
+}
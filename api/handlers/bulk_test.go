@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+func TestBulkTransactions(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodPost, "/v1/transactions/bulk", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error decoding bulk request", http.MethodPost, "/v1/transactions/bulk", true, strings.NewReader(`invalid`),
+			nil, nil,
+			http.StatusBadRequest, "invalid character",
+		},
+		{
+			"error due to missing ids and filter", http.MethodPost, "/v1/transactions/bulk", true, strings.NewReader(`{"operation":"delete"}`),
+			nil, nil,
+			http.StatusBadRequest, "either ids or filter is required",
+		},
+		{
+			"error due to unknown operation", http.MethodPost, "/v1/transactions/bulk", true,
+			strings.NewReader(`{"ids":["` + testTransactionID.String() + `"],"operation":"explode"}`),
+			nil, nil,
+			http.StatusBadRequest, "unknown bulk operation",
+		},
+		{
+			"error due to missing cleared date", http.MethodPost, "/v1/transactions/bulk", true,
+			strings.NewReader(`{"ids":["` + testTransactionID.String() + `"],"operation":"setCleared"}`),
+			nil, nil,
+			http.StatusBadRequest, "clearedAt is required",
+		},
+		{
+			"error due to missing tags", http.MethodPost, "/v1/transactions/bulk", true,
+			strings.NewReader(`{"ids":["` + testTransactionID.String() + `"],"operation":"addTags","tags":[" "]}`),
+			nil, nil,
+			http.StatusBadRequest, "tags are required",
+		},
+		{
+			"error due to missing account", http.MethodPost, "/v1/transactions/bulk", true,
+			strings.NewReader(`{"ids":["` + testTransactionID.String() + `"],"operation":"move"}`),
+			nil, nil,
+			http.StatusBadRequest, "accountId is required",
+		},
+		{
+			"error due to invalid filter", http.MethodPost, "/v1/transactions/bulk", true,
+			strings.NewReader(`{"filter":{"accountIds":"invalid-uuid"},"operation":"delete"}`),
+			nil, nil,
+			http.StatusBadRequest, "error parsing account id",
+		},
+		{
+			"error due to empty filter", http.MethodPost, "/v1/transactions/bulk", true,
+			strings.NewReader(`{"filter":{"sort":"date","tagMode":"all"},"operation":"delete"}`),
+			nil, nil,
+			http.StatusBadRequest, "filter must have at least one criterion",
+		},
+		{
+			"error creating database txn", http.MethodPost, "/v1/transactions/bulk", true,
+			strings.NewReader(`{"ids":["` + testTransactionID.String() + `"],"operation":"delete"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin().WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error getting filtered transactions", http.MethodPost, "/v1/transactions/bulk", true,
+			strings.NewReader(`{"filter":{"q":"uber"},"operation":"delete"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT t.id").WithArgs(allTransactionsFilterArgs([]uuid.UUID{}, "uber")...).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error storing savepoint", http.MethodPost, "/v1/transactions/bulk", true,
+			strings.NewReader(`{"ids":["` + testTransactionID.String() + `"],"operation":"delete"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SAVEPOINT bulk_item").WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error committing database txn", http.MethodPost, "/v1/transactions/bulk", true,
+			strings.NewReader(`{"ids":["` + testTransactionID.String() + `"],"operation":"setPayee","payeeId":"` + testPayeeID.String() + `"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+				mock.ExpectQuery("SELECT t.transfer_id").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows([]string{"transfer_id", "reconciled"}).AddRow(testNullID, false))
+				mock.ExpectExec("UPDATE transactions SET payee_id").WithArgs(testTransactionID, &testPayeeID, pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit().WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success with per item results", http.MethodPost, "/v1/transactions/bulk", true,
+			strings.NewReader(`{"ids":["` + testTransactionID.String() + `","` + testNextTransactionID.String() + `"],"operation":"setCategory","categoryId":"` +
+				testCategoryID.String() + `"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+				mock.ExpectQuery("SELECT t.transfer_id").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows([]string{"transfer_id", "reconciled"}).AddRow(testNullID, false))
+				mock.ExpectExec("UPDATE transactions SET category_id").WithArgs(testTransactionID, &testCategoryID, pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+				mock.ExpectQuery("SELECT t.transfer_id").WithArgs(testNextTransactionID).WillReturnError(pgx.ErrNoRows)
+				mock.ExpectExec("ROLLBACK TO SAVEPOINT bulk_item").WillReturnResult(pgxmock.NewResult("ROLLBACK", 0))
+				mock.ExpectCommit()
+			},
+			http.StatusOK, `"failed":1,"results":[{"id":"` + testTransactionID.String() + `","status":"ok"},{"id":"` +
+				testNextTransactionID.String() + `","status":"error","error":"transaction not found"}]`,
+		},
+		{
+			"error moving to a trashed account", http.MethodPost, "/v1/transactions/bulk", true,
+			strings.NewReader(`{"ids":["` + testTransactionID.String() + `"],"operation":"move","accountId":"` + testToAccountID.String() + `"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM accounts").WithArgs(&testToAccountID).WillReturnError(pgx.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			http.StatusBadRequest, "accountId must be an account that exists and is not trashed",
+		},
+		{
+			"success refusing to move transfers", http.MethodPost, "/v1/transactions/bulk", true,
+			strings.NewReader(`{"ids":["` + testTransactionID.String() + `"],"operation":"move","accountId":"` + testToAccountID.String() + `"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM accounts").WithArgs(&testToAccountID).WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(&testToAccountID))
+				mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+				mock.ExpectQuery("SELECT t.transfer_id").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows([]string{"transfer_id", "reconciled"}).AddRow(&testTransferID, false))
+				mock.ExpectExec("ROLLBACK TO SAVEPOINT bulk_item").WillReturnResult(pgxmock.NewResult("ROLLBACK", 0))
+				mock.ExpectCommit()
+			},
+			http.StatusOK, "transfer transactions cannot be moved",
+		},
+		{
+			"success refusing to delete reconciled transactions", http.MethodPost, "/v1/transactions/bulk", true,
+			strings.NewReader(`{"ids":["` + testTransactionID.String() + `"],"operation":"delete"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+				mock.ExpectQuery("SELECT t.transfer_id").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows([]string{"transfer_id", "reconciled"}).AddRow(testNullID, true))
+				mock.ExpectExec("ROLLBACK TO SAVEPOINT bulk_item").WillReturnResult(pgxmock.NewResult("ROLLBACK", 0))
+				mock.ExpectCommit()
+			},
+			http.StatusOK, "transaction is reconciled",
+		},
+		{
+			"success deleting reconciled transactions when forced", http.MethodPost, "/v1/transactions/bulk?force=true", true,
+			strings.NewReader(`{"ids":["` + testTransactionID.String() + `"],"operation":"delete"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+				mock.ExpectQuery("SELECT t.transfer_id").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows([]string{"transfer_id", "reconciled"}).AddRow(testNullID, true))
+				mock.ExpectExec("UPDATE transactions SET deleted_at").WithArgs(testTransactionID, pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusOK, `"succeeded":1`,
+		},
+		{
+			"success keeping reconciled transactions reconciled when setting their cleared date", http.MethodPost,
+			"/v1/transactions/bulk?force=true", true,
+			strings.NewReader(`{"ids":["` + testTransactionID.String() + `"],"operation":"setCleared","clearedAt":"2024-10-01T00:00:00Z"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+				mock.ExpectQuery("SELECT t.transfer_id").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows([]string{"transfer_id", "reconciled"}).AddRow(&testTransferID, true))
+				mock.ExpectExec("SET cleared_at=\\$2, status = CASE WHEN status = 'reconciled' THEN status ELSE 'cleared' END").
+					WithArgs(testTransactionID, pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+				mock.ExpectCommit()
+			},
+			http.StatusOK, `"succeeded":1`,
+		},
+		{
+			"success clearing cleared dates", http.MethodPost, "/v1/transactions/bulk", true,
+			strings.NewReader(`{"ids":["` + testTransactionID.String() + `"],"operation":"clearCleared"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+				mock.ExpectQuery("SELECT t.transfer_id").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows([]string{"transfer_id", "reconciled"}).AddRow(testNullID, false))
+				mock.ExpectExec("SET cleared_at=NULL, status='uncleared', reconciliation_id=NULL").
+					WithArgs(testTransactionID, pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusOK, `"succeeded":1`,
+		},
+		{
+			"success adding tags", http.MethodPost, "/v1/transactions/bulk", true,
+			strings.NewReader(`{"ids":["` + testTransactionID.String() + `"],"operation":"addTags","tags":["` + testTagName + `"]}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+				mock.ExpectQuery("SELECT t.transfer_id").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows([]string{"transfer_id", "reconciled"}).AddRow(testNullID, false))
+				mock.ExpectExec("INSERT INTO tags").WithArgs(testTransactionID, pgxmock.AnyArg(), []string{testTagName}, pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusOK, `"succeeded":1`,
+		},
+		{
+			"success deleting filtered transactions", http.MethodPost, "/v1/transactions/bulk", true,
+			strings.NewReader(`{"filter":{"q":"uber"},"operation":"delete"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT t.id").WithArgs(allTransactionsFilterArgs([]uuid.UUID{}, "uber")...).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(testTransactionID))
+				mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 0))
+				mock.ExpectQuery("SELECT t.transfer_id").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows([]string{"transfer_id", "reconciled"}).AddRow(testNullID, false))
+				mock.ExpectExec("UPDATE transactions SET deleted_at").WithArgs(testTransactionID, pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusOK, `"total":1`,
+		},
+	}
+	executeTests(t, tests)
+}
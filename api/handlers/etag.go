@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var errPreconditionFailed = errors.New("resource has been modified since it was fetched")
+
+// etag derives the entity tag of a resource from its last update, so clients can also build it from updatedAt.
+func etag(updatedAt time.Time) string {
+	return strconv.Quote(updatedAt.UTC().Format(time.RFC3339Nano))
+}
+
+// matchesIfMatch reports whether the If-Match header of the request, if any, matches the resource version.
+func matchesIfMatch(r *http.Request, updatedAt time.Time) bool {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" || ifMatch == "*" {
+		return true
+	}
+
+	current := etag(updatedAt)
+
+	for _, tag := range strings.Split(ifMatch, ",") {
+		if strings.TrimSpace(tag) == current {
+			return true
+		}
+	}
+
+	return false
+}
+
+// versionQuery returns the updated_at of a resource, locking it until the end of the transaction running it.
+type versionQuery struct {
+	query string
+	args  []any
+}
+
+func lockVersion(query string, args ...any) versionQuery {
+	return versionQuery{query: query, args: args}
+}
+
+// writeIfMatch runs write in a transaction, after checking the If-Match header, if any, against the versions returned
+// by the queries in that same transaction, so no other write can land between the check and the write. It returns
+// errPreconditionFailed when a version does not match or its resource is gone.
+func (h *Handler) writeIfMatch(r *http.Request, versions []versionQuery, write func(db dbExecutor) error) error {
+	ctx := r.Context()
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error creating database txn: %w", err)
+	}
+
+	defer func(ctx context.Context) {
+		if err != nil {
+			rollBackErr := tx.Rollback(ctx)
+			if rollBackErr != nil {
+				slog.Error("error rolling back database txn", "error", rollBackErr)
+			}
+		}
+	}(ctx)
+
+	if r.Header.Get("If-Match") != "" {
+		for _, query := range versions {
+			err = checkVersion(ctx, r, tx, query)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = write(tx)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("error committing database txn: %w", err)
+	}
+
+	return nil
+}
+
+func checkVersion(ctx context.Context, r *http.Request, db dbExecutor, version versionQuery) error {
+	var updatedAt *time.Time
+
+	err := db.QueryRow(ctx, version.query, version.args...).Scan(&updatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errPreconditionFailed
+	}
+
+	if err != nil {
+		return fmt.Errorf("error getting resource version: %w", err)
+	}
+
+	if updatedAt == nil {
+		updatedAt = &time.Time{}
+	}
+
+	if !matchesIfMatch(r, *updatedAt) {
+		return errPreconditionFailed
+	}
+
+	return nil
+}
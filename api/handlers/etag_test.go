@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchesIfMatch(t *testing.T) {
+	updatedAt := time.Date(2024, 10, 18, 10, 30, 0, 0, time.UTC)
+
+	req, err := http.NewRequestWithContext(context.TODO(), http.MethodPatch, "/", nil)
+	require.NoError(t, err)
+	assert.True(t, matchesIfMatch(req, updatedAt))
+
+	req.Header.Set("If-Match", "*")
+	assert.True(t, matchesIfMatch(req, updatedAt))
+
+	req.Header.Set("If-Match", `"stale", `+etag(updatedAt.In(time.FixedZone("IST", 19800))))
+	assert.True(t, matchesIfMatch(req, updatedAt))
+
+	req.Header.Set("If-Match", `"stale"`)
+	assert.False(t, matchesIfMatch(req, updatedAt))
+}
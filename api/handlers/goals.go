@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+type (
+	// Goal model. A balance goal targets an available balance, by a date when given. A monthly goal funds a category
+	// with an amount every month, and a cap goal limits its spending every month.
+	Goal struct {
+		Type   string     `json:"type"`
+		Amount float64    `json:"amount"`
+		Date   *time.Time `json:"date,omitempty"`
+	}
+
+	// GoalStatus model. Needed is what is still to budget in the month to stay on track, and progress the percentage
+	// of the goal reached. For a cap, progress is the percentage of the cap spent.
+	GoalStatus struct {
+		Goal
+		Needed      float64 `json:"needed"`
+		Progress    float64 `json:"progress"`
+		Underfunded bool    `json:"underfunded"`
+		Exceeded    bool    `json:"exceeded,omitempty"`
+	}
+)
+
+const (
+	goalBalance = "balance"
+	goalMonthly = "monthly"
+	goalCap     = "cap"
+)
+
+var (
+	errGoalType   = errors.New("goal type must be balance, monthly or cap")
+	errGoalAmount = errors.New("goal amount must be positive")
+	errGoalDate   = errors.New("goal date is only allowed for balance goals")
+)
+
+func validateGoal(goal *Goal) error {
+	if goal == nil {
+		return nil
+	}
+
+	if goal.Type != goalBalance && goal.Type != goalMonthly && goal.Type != goalCap {
+		return errGoalType
+	}
+
+	if goal.Amount <= 0 {
+		return errGoalAmount
+	}
+
+	if goal.Date != nil {
+		if goal.Type != goalBalance {
+			return errGoalDate
+		}
+
+		date := dateOf(*goal.Date)
+		goal.Date = &date
+	}
+
+	return nil
+}
+
+// newGoal builds a goal from its columns, nil when the category has none.
+func newGoal(goalType *string, amount *float64, date *time.Time) *Goal {
+	if goalType == nil || amount == nil {
+		return nil
+	}
+
+	return &Goal{Type: *goalType, Amount: *amount, Date: date}
+}
+
+// status reports the goal of a category in a month, given its budget figures for the month.
+func (g Goal) status(year, month int, budget BudgetResult) *GoalStatus {
+	status := &GoalStatus{Goal: g}
+
+	switch g.Type {
+	case goalMonthly:
+		status.Needed = max(g.Amount-budget.Budgeted, 0)
+		status.Progress = min(budget.Budgeted/g.Amount, 1)
+	case goalBalance:
+		// The balance still missing at the start of the month is spread over the months left until the date.
+		monthsLeft := 1
+		if g.Date != nil {
+			monthsLeft = max(budgetMonth(g.Date.Year(), int(g.Date.Month()))-budgetMonth(year, month)+1, 1)
+		}
+
+		carriedIn := budget.Available - budget.Budgeted - budget.Spent
+		monthly := max(g.Amount-carriedIn, 0) / float64(monthsLeft)
+		status.Needed = max(monthly-budget.Budgeted, 0)
+		status.Progress = min(max(budget.Available, 0)/g.Amount, 1)
+	case goalCap:
+		status.Progress = max(-budget.Spent, 0) / g.Amount
+		status.Exceeded = -budget.Spent > g.Amount
+	}
+
+	status.Needed = math.Round(status.Needed*100) / 100       //nolint: mnd
+	status.Progress = math.Round(status.Progress*10000) / 100 //nolint: mnd
+	status.Underfunded = status.Needed > 0
+
+	return status
+}
+
+// columns returns the goal type, amount and date columns of a category, all nil when it has no goal.
+func (g *Goal) columns() (*string, *float64, *time.Time) {
+	if g == nil {
+		return nil, nil, nil
+	}
+
+	return &g.Type, &g.Amount, g.Date
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoalStatus(t *testing.T) {
+	march := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	june := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		goal     Goal
+		budget   BudgetResult
+		expected GoalStatus
+	}{
+		{
+			"monthly goal underfunded",
+			Goal{Type: goalMonthly, Amount: 8000},
+			BudgetResult{Budgeted: 5000, Spent: -1000, Available: 4000},
+			GoalStatus{Needed: 3000, Progress: 62.5, Underfunded: true},
+		},
+		{
+			"monthly goal funded",
+			Goal{Type: goalMonthly, Amount: 8000},
+			BudgetResult{Budgeted: 9000, Available: 9000},
+			GoalStatus{Progress: 100},
+		},
+		{
+			"balance goal by a date spreads the missing balance",
+			Goal{Type: goalBalance, Amount: 60000, Date: &march},
+			BudgetResult{Available: 30000},
+			GoalStatus{Needed: 5000, Progress: 50, Underfunded: true},
+		},
+		{
+			"balance goal without a date needs the missing balance now",
+			Goal{Type: goalBalance, Amount: 10000},
+			BudgetResult{Budgeted: 2000, Available: 6000},
+			GoalStatus{Needed: 4000, Progress: 60, Underfunded: true},
+		},
+		{
+			"balance goal past its date",
+			Goal{Type: goalBalance, Amount: 10000, Date: &june},
+			BudgetResult{Budgeted: 1000, Available: 9000},
+			GoalStatus{Needed: 1000, Progress: 90, Underfunded: true},
+		},
+		{
+			"cap goal exceeded",
+			Goal{Type: goalCap, Amount: 8000},
+			BudgetResult{Budgeted: 8000, Spent: -9000, Available: -1000},
+			GoalStatus{Progress: 112.5, Exceeded: true},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expected.Goal = tc.goal
+			assert.Equal(t, &tc.expected, tc.goal.status(2024, 10, tc.budget))
+		})
+	}
+}
+
+func TestValidateGoal(t *testing.T) {
+	date := time.Date(2025, 3, 31, 10, 30, 0, 0, time.UTC)
+
+	require.NoError(t, validateGoal(nil))
+	require.ErrorIs(t, validateGoal(&Goal{Type: "weekly", Amount: 1}), errGoalType)
+	require.ErrorIs(t, validateGoal(&Goal{Type: goalMonthly}), errGoalAmount)
+	require.ErrorIs(t, validateGoal(&Goal{Type: goalCap, Amount: 1, Date: &date}), errGoalDate)
+
+	goal := &Goal{Type: goalBalance, Amount: 1, Date: &date}
+	require.NoError(t, validateGoal(goal))
+	assert.Equal(t, dateOf(date), *goal.Date)
+}
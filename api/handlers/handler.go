@@ -1,26 +1,49 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
 	"vitta/adapters"
 	"vitta/config"
 	"vitta/database"
+	"vitta/storage"
+
+	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// dbExecutor is satisfied by both the database pool and database transactions.
+type dbExecutor interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
 // Handler configuration.
 type Handler struct {
 	cfg      *config.Config
 	db       database.DBIface
 	adapters map[string]adapters.Config
+	storage  storage.Storage
 }
 
-func New(cfg *config.Config, db database.DBIface, adapters map[string]adapters.Config) http.Handler {
+func New(cfg *config.Config, db database.DBIface, adapters map[string]adapters.Config,
+	storage storage.Storage,
+) http.Handler {
 	h := &Handler{
 		cfg:      cfg,
 		db:       db,
 		adapters: adapters,
+		storage:  storage,
 	}
 
 	mux := http.NewServeMux()
@@ -29,33 +52,95 @@ func New(cfg *config.Config, db database.DBIface, adapters map[string]adapters.C
 	mux.HandleFunc("POST /v1/payees", h.CreatePayee)
 	mux.HandleFunc("PATCH /v1/payees/{id}", h.UpdatePayee)
 	mux.HandleFunc("DELETE /v1/payees/{id}", h.DeletePayee)
+	mux.HandleFunc("GET /v1/payees/{id}", h.GetPayee)
 	mux.HandleFunc("GET /v1/payees", h.GetPayees)
+	mux.HandleFunc("GET /v1/payees/{id}/history", h.getHistory("id", "payees"))
 	// accounts
 	mux.HandleFunc("POST /v1/accounts", h.CreateAccount)
 	mux.HandleFunc("PATCH /v1/accounts/{id}", h.UpdateAccount)
 	mux.HandleFunc("DELETE /v1/accounts/{id}", h.DeleteAccount)
 	mux.HandleFunc("GET /v1/accounts/{id}", h.GetAccount)
 	mux.HandleFunc("GET /v1/accounts", h.GetAccounts)
+	mux.HandleFunc("GET /v1/accounts/{id}/history", h.getHistory("id", "accounts"))
+	mux.HandleFunc("POST /v1/accounts/{id}/reconciliations", h.Reconcile)
+	mux.HandleFunc("GET /v1/accounts/{id}/reconciliations", h.GetReconciliations)
 	mux.HandleFunc("GET /v1/adapters", h.GetAdapters)
 	// transactions
 	mux.HandleFunc("POST /v1/accounts/{id}/transactions", h.CreateTransaction)
 	mux.HandleFunc("PUT /v1/accounts/{id}/transactions", h.ImportTransactions)
 	mux.HandleFunc("PATCH /v1/accounts/{id}/transactions/{tId}", h.UpdateTransaction)
 	mux.HandleFunc("DELETE /v1/accounts/{id}/transactions/{tId}", h.DeleteTransaction)
+	mux.HandleFunc("GET /v1/accounts/{id}/transactions/{tId}", h.GetTransaction)
 	mux.HandleFunc("GET /v1/accounts/{id}/transactions", h.GetTransactions)
+	mux.HandleFunc("GET /v1/accounts/{id}/transactions/{tId}/history", h.getHistory("tId", "transactions",
+		"transaction_tags"))
+	mux.HandleFunc("GET /v1/transactions", h.GetAllTransactions)
+	mux.HandleFunc("POST /v1/transactions/bulk", h.BulkTransactions)
+	mux.HandleFunc("PUT /v1/accounts/{id}/transactions/{tId}/splits", h.SetSplits)
+	mux.HandleFunc("DELETE /v1/accounts/{id}/transactions/{tId}/splits", h.DeleteSplits)
+	mux.HandleFunc("GET /v1/accounts/{id}/transactions/{tId}/splits", h.GetSplits)
+	mux.HandleFunc("POST /v1/accounts/{id}/transactions/{tId}/attachments", h.CreateAttachment)
+	mux.HandleFunc("DELETE /v1/accounts/{id}/transactions/{tId}/attachments/{aId}", h.DeleteAttachment)
+	mux.HandleFunc("GET /v1/accounts/{id}/transactions/{tId}/attachments/{aId}", h.GetAttachment)
+	mux.HandleFunc("GET /v1/accounts/{id}/transactions/{tId}/attachments", h.GetAttachments)
+	// transfers
+	mux.HandleFunc("POST /v1/transfers", h.CreateTransfer)
+	mux.HandleFunc("PATCH /v1/transfers/{id}", h.UpdateTransfer)
+	mux.HandleFunc("DELETE /v1/transfers/{id}", h.DeleteTransfer)
+	mux.HandleFunc("GET /v1/accounts/{id}/transactions/{tId}/transfers", h.GetTransferCandidates)
+	mux.HandleFunc("POST /v1/accounts/{id}/transactions/{tId}/transfers", h.LinkTransfer)
+	// recurring transactions
+	mux.HandleFunc("POST /v1/recurring", h.CreateRecurringTransaction)
+	mux.HandleFunc("PATCH /v1/recurring/{id}", h.UpdateRecurringTransaction)
+	mux.HandleFunc("DELETE /v1/recurring/{id}", h.DeleteRecurringTransaction)
+	mux.HandleFunc("GET /v1/recurring/{id}", h.GetRecurringTransaction)
+	mux.HandleFunc("GET /v1/recurring", h.GetRecurringTransactions)
+	mux.HandleFunc("GET /v1/recurring/{id}/history", h.getHistory("id", "recurring_transactions"))
+	// tags
+	mux.HandleFunc("POST /v1/tags", h.CreateTag)
+	mux.HandleFunc("PATCH /v1/tags/{id}", h.UpdateTag)
+	mux.HandleFunc("DELETE /v1/tags/{id}", h.DeleteTag)
+	mux.HandleFunc("GET /v1/tags", h.GetTags)
+	mux.HandleFunc("GET /v1/tags/summary", h.GetTagSummary)
+	mux.HandleFunc("GET /v1/tags/{id}/history", h.getHistory("id", "tags"))
 	// budgets
 	mux.HandleFunc("POST /v1/groups", h.CreateGroup)
 	mux.HandleFunc("PATCH /v1/groups/{id}", h.UpdateGroup)
 	mux.HandleFunc("DELETE /v1/groups/{id}", h.DeleteGroup)
+	mux.HandleFunc("GET /v1/groups/{id}", h.GetGroup)
 	mux.HandleFunc("GET /v1/groups", h.GetGroups)
+	mux.HandleFunc("PUT /v1/groups/order", h.reorder(queryReorderGroups))
+	mux.HandleFunc("GET /v1/groups/{id}/history", h.getHistory("id", "groups"))
 	mux.HandleFunc("POST /v1/categories", h.CreateCategory)
 	mux.HandleFunc("PATCH /v1/categories/{id}", h.UpdateCategory)
 	mux.HandleFunc("DELETE /v1/categories/{id}", h.DeleteCategory)
+	mux.HandleFunc("GET /v1/categories/{id}", h.GetCategory)
 	mux.HandleFunc("GET /v1/categories", h.GetCategories)
+	mux.HandleFunc("PUT /v1/categories/order", h.reorder(queryReorderCategories))
+	mux.HandleFunc("GET /v1/categories/{id}/history", h.getHistory("id", "categories"))
 	mux.HandleFunc("GET /v1/budgets", h.GetBudget)
+	mux.HandleFunc("GET /v1/budgets/range", h.GetBudgetRange)
 	mux.HandleFunc("PUT /v1/budgets", h.SetBudget)
+	mux.HandleFunc("POST /v1/budgets/autofill", h.AutoFillBudget)
+	mux.HandleFunc("POST /v1/budgets/movements", h.MoveBudget)
+	mux.HandleFunc("GET /v1/budgets/movements", h.GetMovements)
+	// scenarios
+	mux.HandleFunc("POST /v1/scenarios", h.CreateScenario)
+	mux.HandleFunc("GET /v1/scenarios", h.GetScenarios)
+	mux.HandleFunc("GET /v1/scenarios/{id}", h.GetScenario)
+	mux.HandleFunc("PATCH /v1/scenarios/{id}", h.UpdateScenario)
+	mux.HandleFunc("DELETE /v1/scenarios/{id}", h.DeleteScenario)
+	mux.HandleFunc("PUT /v1/scenarios/{id}/budgets", h.SetScenarioBudget)
+	mux.HandleFunc("DELETE /v1/scenarios/{id}/budgets/{categoryId}", h.DeleteScenarioBudget)
+	mux.HandleFunc("GET /v1/scenarios/{id}/compare", h.CompareScenario)
+	mux.HandleFunc("POST /v1/scenarios/{id}/apply", h.ApplyScenario)
+	// trash
+	mux.HandleFunc("GET /v1/trash", h.GetTrash)
+	mux.HandleFunc("POST /v1/trash/{kind}/{id}/restore", h.RestoreTrash)
+	// audit
+	mux.HandleFunc("GET /v1/audit", h.GetAudit)
 
-	return h.corsMiddleware(h.basicAuthMiddleware(mux))
+	return h.corsMiddleware(h.basicAuthMiddleware(h.auditMiddleware(mux)))
 }
 
 // ErrorResponse model.
@@ -75,6 +160,74 @@ func buildErrorResponse(w http.ResponseWriter, err string, code int) {
 	}
 }
 
+// Listings return defaultListLimit entries per page unless asked for another limit, up to maxListLimit.
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// listLimit returns the page size asked for by the limit query parameter, within maxListLimit.
+func listLimit(r *http.Request) int {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		return defaultListLimit
+	}
+
+	return min(limit, maxListLimit)
+}
+
+// readOnlyFields are kept by the server, so patches leave them out rather than overwrite the stored values.
+var readOnlyFields = []string{"id", "createdAt", "updatedAt", "deletedAt", "transferId", "reconciliationId"}
+
+// decodeMergePatch applies a JSON merge patch (RFC 7396) onto target, which holds the current state. It returns the
+// fields present in the patch and rejects fields unknown to target. Read-only fields, and the extra ones given, are
+// dropped from the patch.
+func decodeMergePatch(body io.Reader, target any, readOnly ...string) (map[string]json.RawMessage, error) {
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading patch: %w", err)
+	}
+
+	fields := map[string]json.RawMessage{}
+
+	err = json.Unmarshal(content, &fields)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding patch: %w", err)
+	}
+
+	for _, field := range slices.Concat(readOnlyFields, readOnly) {
+		delete(fields, field)
+	}
+
+	content, err = json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding patch: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(target)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding patch: %w", err)
+	}
+
+	return fields, nil
+}
+
+// dbErrorStatus returns the response status for an error getting or writing a single row in the database.
+func dbErrorStatus(err error) int {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return http.StatusNotFound
+	}
+
+	if errors.Is(err, errPreconditionFailed) {
+		return http.StatusPreconditionFailed
+	}
+
+	return http.StatusInternalServerError
+}
+
 // Middleware for checking basic auth credentials.
 func (h *Handler) basicAuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -90,12 +243,52 @@ func (h *Handler) basicAuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// requestIDHeader carries the id of a request, recorded in the audit log along with its changes.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the length of request ids sent by clients.
+const maxRequestIDLength = 255
+
+// Middleware attributing the database changes of a request to the authenticated user and the request id, which is
+// taken from the request or generated, and echoed in the response.
+func (h *Handler) auditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			id, err := uuid.NewV7()
+			if err != nil {
+				slog.Error("error creating request id", "error", err)
+				buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+				return
+			}
+
+			requestID = id.String()
+		}
+
+		w.Header().Set(requestIDHeader, requestID)
+
+		// Only requests that can change data are audited, sparing reads from setting audit details on connections.
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		username, _, _ := r.BasicAuth()
+		ctx := database.WithAudit(r.Context(), database.Audit{Actor: username, RequestID: requestID})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 // Middleware for CORS related operations.
 func (h *Handler) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PATCH, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, X-Request-ID")
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
@@ -3,12 +3,17 @@ package handlers
 import (
 	"context"
 	"io"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 	"vitta/adapters"
 	"vitta/config"
+	"vitta/storage"
 
+	"github.com/google/uuid"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -59,7 +64,7 @@ func executeTests(t *testing.T, tests []testCase) {
 				Credit:          "Amount (INR)",
 				Debit:           "Amount (INR)",
 				TransactionDiff: []string{"cr.", "dr."},
-			}})
+			}}, newTestStorage(t))
 
 			res := httptest.NewRecorder()
 
@@ -71,3 +76,47 @@ func executeTests(t *testing.T, tests []testCase) {
 		})
 	}
 }
+
+func newTestStorage(t *testing.T) storage.Storage {
+	t.Helper()
+
+	store, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = store.Put(context.TODO(), testAttachmentID.String(), strings.NewReader(testAttachmentContent))
+	require.NoError(t, err)
+
+	return store
+}
+
+func TestDecodeMergePatch(t *testing.T) {
+	transferID := uuid.New()
+
+	tests := []struct {
+		name     string
+		body     string
+		expected Transaction
+		fields   []string
+	}{
+		{
+			"applies patched fields", `{"name":"New name"}`,
+			Transaction{Name: "New name", TransferID: &transferID, UpdatedAt: testAccountTime}, []string{"name"},
+		},
+		{
+			"drops read-only fields", `{"name":"New name","transferId":null,"updatedAt":"2020-01-01T00:00:00Z",` +
+				`"accountId":"` + uuid.New().String() + `"}`,
+			Transaction{Name: "New name", TransferID: &transferID, UpdatedAt: testAccountTime}, []string{"name"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transaction := Transaction{Name: "Old name", TransferID: &transferID, UpdatedAt: testAccountTime}
+
+			fields, err := decodeMergePatch(strings.NewReader(tt.body), &transaction, "accountId")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, transaction)
+			assert.ElementsMatch(t, tt.fields, slices.Collect(maps.Keys(fields)))
+		})
+	}
+}
@@ -30,18 +30,22 @@ type (
 		AutoCategoryID *uuid.UUID `json:"autoCategoryId,omitempty"`
 		CreatedAt      time.Time  `json:"createdAt"`
 		UpdatedAt      time.Time  `json:"updatedAt"`
+		DeletedAt      *time.Time `json:"deletedAt,omitempty"`
 	}
 )
 
 const (
 	queryCreatePayee = `INSERT INTO payees (id, name, rules, auto_category_id, created_at, updated_at)` +
 		` VALUES ($1, $2, $3, $4, $5, $6)`
-	queryUpdatePayee    = `UPDATE payees SET name=$1, rules=$2, auto_category_id=$3, updated_at=$4 WHERE id=$5`
-	queryDeletePayee    = `DELETE FROM payees WHERE id=$1`
-	queryGetTotalPayees = `SELECT COUNT(*) as total FROM payees WHERE (name ILIKE '%' ||` +
+	queryUpdatePayee = `UPDATE payees SET name=$1, rules=$2, auto_category_id=$3, updated_at=$4 WHERE id=$5` +
+		` AND deleted_at IS NULL AND updated_at IS NOT DISTINCT FROM $6`
+	queryGetPayeeVersion = `SELECT updated_at FROM payees WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`
+	queryDeletePayee     = `UPDATE payees SET deleted_at=$2 WHERE id=$1 AND deleted_at IS NULL`
+	queryGetPayee        = `SELECT * FROM payees WHERE id=$1 AND deleted_at IS NULL`
+	queryGetTotalPayees  = `SELECT COUNT(*) as total FROM payees WHERE deleted_at IS NULL AND (name ILIKE '%' ||` +
 		` COALESCE(NULLIF($1, ''), '') || '%')`
-	queryGetPayees = `SELECT * FROM payees WHERE (name ILIKE '%' || COALESCE(NULLIF($1, ''), '')` +
-		` || '%') ORDER BY created_at DESC`
+	queryGetPayees = `SELECT * FROM payees WHERE deleted_at IS NULL AND (name ILIKE '%' ||` +
+		` COALESCE(NULLIF($1, ''), '') || '%') ORDER BY created_at DESC`
 )
 
 func (h *Handler) CreatePayee(w http.ResponseWriter, r *http.Request) { //nolint: cyclop
@@ -127,7 +131,25 @@ func (h *Handler) UpdatePayee(w http.ResponseWriter, r *http.Request) {
 
 	var payee Payee
 
-	err = json.NewDecoder(r.Body).Decode(&payee)
+	err = h.db.QueryRow(r.Context(), queryGetPayee, payeeID).Scan(&payee.ID, &payee.Name, &payee.Rules,
+		&payee.AutoCategoryID, &payee.CreatedAt, &payee.UpdatedAt, &payee.DeletedAt)
+	if err != nil {
+		slog.Error("error getting payee from database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	if !matchesIfMatch(r, payee.UpdatedAt) {
+		slog.Error("error matching payee version", "error", errPreconditionFailed)
+		buildErrorResponse(w, errPreconditionFailed.Error(), http.StatusPreconditionFailed)
+
+		return
+	}
+
+	version := payee.UpdatedAt
+
+	fields, err := decodeMergePatch(r.Body, &payee)
 	if err != nil {
 		slog.Error("error decoding update payee request", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
@@ -137,8 +159,8 @@ func (h *Handler) UpdatePayee(w http.ResponseWriter, r *http.Request) {
 
 	payee.UpdatedAt = time.Now()
 
-	_, err = h.db.Exec(r.Context(), queryUpdatePayee,
-		payee.Name, payee.Rules, payee.AutoCategoryID, payee.UpdatedAt, payeeID)
+	result, err := h.db.Exec(r.Context(), queryUpdatePayee,
+		payee.Name, payee.Rules, payee.AutoCategoryID, payee.UpdatedAt, payeeID, version)
 	if err != nil {
 		slog.Error("error updating payee in database", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -146,7 +168,17 @@ func (h *Handler) UpdatePayee(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if updateTransactions && (payee.Rules != nil || payee.AutoCategoryID != nil) {
+	if result.RowsAffected() == 0 {
+		slog.Error("error updating payee in database", "error", errPreconditionFailed)
+		buildErrorResponse(w, errPreconditionFailed.Error(), http.StatusPreconditionFailed)
+
+		return
+	}
+
+	_, rulesChanged := fields["rules"]
+	_, autoCategoryChanged := fields["autoCategoryId"]
+
+	if updateTransactions && (rulesChanged || autoCategoryChanged) {
 		getPayeeCategory, err := h.assignPayeeAndCategory(r.Context(), []Payee{payee})
 		if err != nil {
 			slog.Error("error creating payee category assigner", "error", err)
@@ -177,10 +209,14 @@ func (h *Handler) DeletePayee(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = h.db.Exec(r.Context(), queryDeletePayee, payeeID)
+	err = h.writeIfMatch(r, []versionQuery{lockVersion(queryGetPayeeVersion, payeeID)}, func(db dbExecutor) error {
+		_, err := db.Exec(r.Context(), queryDeletePayee, payeeID, time.Now())
+
+		return err //nolint: wrapcheck
+	})
 	if err != nil {
 		slog.Error("error deleting payee in database", "error", err)
-		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
 
 		return
 	}
@@ -189,6 +225,38 @@ func (h *Handler) DeletePayee(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (h *Handler) GetPayee(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	payeeID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing payee id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var payee Payee
+
+	err = h.db.QueryRow(r.Context(), queryGetPayee, payeeID).Scan(&payee.ID, &payee.Name, &payee.Rules,
+		&payee.AutoCategoryID, &payee.CreatedAt, &payee.UpdatedAt, &payee.DeletedAt)
+	if err != nil {
+		slog.Error("error getting payee from database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(payee.UpdatedAt))
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(payee)
+	if err != nil {
+		slog.Error("error encoding payee response", "error", err)
+	}
+}
+
 func (h *Handler) GetPayees(w http.ResponseWriter, r *http.Request) { //nolint: funlen
 	searchQuery := r.URL.Query().Get("q")
 
@@ -227,7 +295,8 @@ func (h *Handler) GetPayees(w http.ResponseWriter, r *http.Request) { //nolint:
 	for rows.Next() {
 		var payee Payee
 
-		err := rows.Scan(&payee.ID, &payee.Name, &payee.Rules, &payee.AutoCategoryID, &payee.CreatedAt, &payee.UpdatedAt)
+		err := rows.Scan(&payee.ID, &payee.Name, &payee.Rules, &payee.AutoCategoryID, &payee.CreatedAt,
+			&payee.UpdatedAt, &payee.DeletedAt)
 		if err != nil {
 			slog.Error("error scanning payees row from database", "error", err)
 			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -269,7 +338,8 @@ func (h *Handler) assignPayeeAndCategory(ctx context.Context, payees []Payee) (
 		for rows.Next() {
 			var payee Payee
 
-			err := rows.Scan(&payee.ID, &payee.Name, &payee.Rules, &payee.AutoCategoryID, &payee.CreatedAt, &payee.UpdatedAt)
+			err := rows.Scan(&payee.ID, &payee.Name, &payee.Rules, &payee.AutoCategoryID, &payee.CreatedAt,
+				&payee.UpdatedAt, &payee.DeletedAt)
 			if err != nil {
 				slog.Error("error scanning payees row from database", "error", err)
 
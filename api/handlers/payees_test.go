@@ -17,7 +17,7 @@ import (
 var (
 	testPayeeName = "Swiggy"
 	testRules     = Rules{Includes: []string{"abc"}, Excludes: []string{"xyz"}, StartsWith: []string{"abc"}, EndsWith: []string{"xyz"}}
-	payeeRowCols  = []string{"id", "name", "rules", "auto_category_id", "created_at", "updated_at"}
+	payeeRowCols  = []string{"id", "name", "rules", "auto_category_id", "created_at", "updated_at", "deleted_at"}
 )
 
 func TestCreatePayee(t *testing.T) {
@@ -74,7 +74,11 @@ func TestUpdatePayee(t *testing.T) {
 		},
 		{
 			"error due to bad request", http.MethodPatch, "/v1/payees/" + testPayeeID.String(), true, strings.NewReader("invalid-body"),
-			nil, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM payees").WithArgs(testPayeeID).WillReturnRows(pgxmock.NewRows(payeeRowCols).
+					AddRow(testPayeeID, "Old name", &Rules{Includes: []string{"abc"}, Excludes: []string{"xyz"}, StartsWith: []string{"abc"}, EndsWith: []string{"xyz"}}, &testCategoryID, testAccountTime, testAccountTime, testNullTime))
+			},
 			http.StatusBadRequest, "invalid character",
 		},
 		{
@@ -83,9 +87,11 @@ func TestUpdatePayee(t *testing.T) {
 				`"startsWith":["abc"],"endsWith":["xyz"]},"autoCategoryId":"` + testCategoryID.String() + `"}`),
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM payees").WithArgs(testPayeeID).WillReturnRows(pgxmock.NewRows(payeeRowCols).
+					AddRow(testPayeeID, "Old name", &Rules{Includes: []string{"abc"}, Excludes: []string{"xyz"}, StartsWith: []string{"abc"}, EndsWith: []string{"xyz"}}, &testCategoryID, testAccountTime, testAccountTime, testNullTime))
 				mock.ExpectExec("UPDATE payees").WithArgs(
 					testPayeeName, &Rules{Includes: []string{"abc"}, Excludes: []string{"xyz"}, StartsWith: []string{"abc"},
-						EndsWith: []string{"xyz"}}, &testCategoryID, pgxmock.AnyArg(), testPayeeID,
+						EndsWith: []string{"xyz"}}, &testCategoryID, pgxmock.AnyArg(), testPayeeID, testAccountTime,
 				).WillReturnError(pgx.ErrTxClosed)
 			},
 			http.StatusInternalServerError, "tx is closed",
@@ -96,9 +102,42 @@ func TestUpdatePayee(t *testing.T) {
 				`"startsWith":["abc"],"endsWith":["xyz"]},"autoCategoryId":"` + testCategoryID.String() + `"}`),
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM payees").WithArgs(testPayeeID).WillReturnRows(pgxmock.NewRows(payeeRowCols).
+					AddRow(testPayeeID, "Old name", &Rules{Includes: []string{"abc"}, Excludes: []string{"xyz"}, StartsWith: []string{"abc"}, EndsWith: []string{"xyz"}}, &testCategoryID, testAccountTime, testAccountTime, testNullTime))
 				mock.ExpectExec("UPDATE payees").WithArgs(
 					testPayeeName, &Rules{Includes: []string{"abc"}, Excludes: []string{"xyz"}, StartsWith: []string{"abc"},
-						EndsWith: []string{"xyz"}}, &testCategoryID, pgxmock.AnyArg(), testPayeeID,
+						EndsWith: []string{"xyz"}}, &testCategoryID, pgxmock.AnyArg(), testPayeeID, testAccountTime,
+				).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			http.StatusNoContent, "",
+		},
+		{
+			"error due to payee not found", http.MethodPatch, "/v1/payees/" + testPayeeID.String() + "", true, strings.NewReader(`{"name":"x"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM payees").WithArgs(testPayeeID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusNotFound, "no rows",
+		},
+		{
+			"error due to unknown field", http.MethodPatch, "/v1/payees/" + testPayeeID.String() + "", true, strings.NewReader(`{"nmae":"x"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM payees").WithArgs(testPayeeID).WillReturnRows(pgxmock.NewRows(payeeRowCols).
+					AddRow(testPayeeID, "Old name", &Rules{Includes: []string{"abc"}, Excludes: []string{"xyz"}, StartsWith: []string{"abc"}, EndsWith: []string{"xyz"}}, &testCategoryID, testAccountTime, testAccountTime, testNullTime))
+			},
+			http.StatusBadRequest, "unknown field",
+		},
+		{
+			"success updating only supplied fields", http.MethodPatch, "/v1/payees/" + testPayeeID.String() + "", true,
+			strings.NewReader(`{"name":"` + testPayeeName + `","rules":{"includes":["def"]}}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM payees").WithArgs(testPayeeID).WillReturnRows(pgxmock.NewRows(payeeRowCols).
+					AddRow(testPayeeID, "Old name", &Rules{Includes: []string{"abc"}, Excludes: []string{"xyz"}, StartsWith: []string{"abc"}, EndsWith: []string{"xyz"}}, &testCategoryID, testAccountTime, testAccountTime, testNullTime))
+				mock.ExpectExec("UPDATE payees").WithArgs(
+					testPayeeName, &Rules{Includes: []string{"def"}, Excludes: []string{"xyz"}, StartsWith: []string{"abc"},
+						EndsWith: []string{"xyz"}}, &testCategoryID, pgxmock.AnyArg(), testPayeeID, testAccountTime,
 				).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 			},
 			http.StatusNoContent, "",
@@ -124,7 +163,9 @@ func TestDeletePayee(t *testing.T) {
 			nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec("DELETE FROM payees").WithArgs(testPayeeID).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE payees SET deleted_at").WithArgs(testPayeeID, pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
 			},
 			http.StatusInternalServerError, "tx is closed",
 		},
@@ -133,10 +174,57 @@ func TestDeletePayee(t *testing.T) {
 			nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec("DELETE FROM payees").WithArgs(testPayeeID).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE payees SET deleted_at").WithArgs(testPayeeID, pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
 			},
 			http.StatusNoContent, "",
 		},
+		{
+			"error due to stale if-match", http.MethodDelete, "/v1/payees/" + testPayeeID.String(), true,
+			nil,
+			http.Header{"If-Match": []string{`"stale"`}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT updated_at FROM payees").WithArgs(testPayeeID).WillReturnRows(pgxmock.NewRows([]string{"updated_at"}).
+					AddRow(&testAccountTime))
+				mock.ExpectRollback()
+			},
+			http.StatusPreconditionFailed, "resource has been modified",
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestGetPayee(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, "/v1/payees/invalid-uuid", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad payee id", http.MethodGet, "/v1/payees/invalid-uuid", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error due to payee not found", http.MethodGet, "/v1/payees/" + testPayeeID.String(), true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM payees").WithArgs(testPayeeID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusNotFound, "no rows",
+		},
+		{
+			"success getting payee", http.MethodGet, "/v1/payees/" + testPayeeID.String(), true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM payees").WithArgs(testPayeeID).WillReturnRows(pgxmock.NewRows(payeeRowCols).
+					AddRow(testPayeeID, testPayeeName, &Rules{Includes: []string{"abc"}}, &testCategoryID, testAccountTime, testAccountTime, testNullTime))
+			},
+			http.StatusOK, testPayeeID.String(),
+		},
 	}
 	executeTests(t, tests)
 }
@@ -179,7 +267,7 @@ func TestGetPayees(t *testing.T) {
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
 				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnRows(pgxmock.NewRows(payeeRowCols).
-					AddRow("invalid", "ok", "ok", "invalid", "bad-time", "bad-time"))
+					AddRow("invalid", "ok", "ok", "invalid", "bad-time", "bad-time", testNullTime))
 			},
 			http.StatusInternalServerError, "Scanning value error",
 		},
@@ -199,7 +287,7 @@ func TestGetPayees(t *testing.T) {
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
 				mock.ExpectQuery("SELECT *").WithArgs("some").WillReturnRows(pgxmock.NewRows(payeeRowCols).
-					AddRow(testPayeeID.String(), testPayeeName, &testRules, &testCategoryID, testAccountTime, testAccountTime))
+					AddRow(testPayeeID.String(), testPayeeName, &testRules, &testCategoryID, testAccountTime, testAccountTime, testNullTime))
 			},
 			http.StatusOK, testPayeeID.String(),
 		},
@@ -227,7 +315,7 @@ func TestAssignPayeeAndCategory(t *testing.T) {
 			"error scanning payees row",
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WithArgs("").WillReturnRows(pgxmock.NewRows(payeeRowCols).
-					AddRow("invalid", "ok", "ok", "invalid", "bad-time", "bad-time"))
+					AddRow("invalid", "ok", "ok", "invalid", "bad-time", "bad-time", testNullTime))
 			},
 			"error scanning payees row", "", nil, nil,
 		},
@@ -243,7 +331,7 @@ func TestAssignPayeeAndCategory(t *testing.T) {
 			"match includes and excludes",
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WithArgs("").WillReturnRows(pgxmock.NewRows(payeeRowCols).
-					AddRow(testPayeeID.String(), testPayeeName, &Rules{Includes: []string{"ato"}, Excludes: []string{"swiggy"}}, &testCategoryID, testAccountTime, testAccountTime))
+					AddRow(testPayeeID.String(), testPayeeName, &Rules{Includes: []string{"ato"}, Excludes: []string{"swiggy"}}, &testCategoryID, testAccountTime, testAccountTime, testNullTime))
 			},
 			"", "ZOMATO", &testPayeeID, &testCategoryID,
 		},
@@ -251,7 +339,7 @@ func TestAssignPayeeAndCategory(t *testing.T) {
 			"match starts with",
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WithArgs("").WillReturnRows(pgxmock.NewRows(payeeRowCols).
-					AddRow(testPayeeID.String(), testPayeeName, &Rules{StartsWith: []string{"zoma"}}, &testCategoryID, testAccountTime, testAccountTime))
+					AddRow(testPayeeID.String(), testPayeeName, &Rules{StartsWith: []string{"zoma"}}, &testCategoryID, testAccountTime, testAccountTime, testNullTime))
 			},
 			"", "ZOMATO", &testPayeeID, &testCategoryID,
 		},
@@ -259,7 +347,7 @@ func TestAssignPayeeAndCategory(t *testing.T) {
 			"match ends with",
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WithArgs("").WillReturnRows(pgxmock.NewRows(payeeRowCols).
-					AddRow(testPayeeID.String(), testPayeeName, &Rules{EndsWith: []string{"ato"}}, &testCategoryID, testAccountTime, testAccountTime))
+					AddRow(testPayeeID.String(), testPayeeName, &Rules{EndsWith: []string{"ato"}}, &testCategoryID, testAccountTime, testAccountTime, testNullTime))
 			},
 			"", "ZOMATO", &testPayeeID, &testCategoryID,
 		},
@@ -267,7 +355,7 @@ func TestAssignPayeeAndCategory(t *testing.T) {
 			"match with nothing and empty rules",
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WithArgs("").WillReturnRows(pgxmock.NewRows(payeeRowCols).
-					AddRow(testPayeeID.String(), testPayeeName, &Rules{}, &testCategoryID, testAccountTime, testAccountTime))
+					AddRow(testPayeeID.String(), testPayeeName, &Rules{}, &testCategoryID, testAccountTime, testAccountTime, testNullTime))
 			},
 			"", "ZOMATO", nil, nil,
 		},
@@ -276,7 +364,7 @@ func TestAssignPayeeAndCategory(t *testing.T) {
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WithArgs("").WillReturnRows(pgxmock.NewRows(payeeRowCols).
 					AddRow(testPayeeID.String(), testPayeeName, &Rules{Includes: []string{"wig"}, Excludes: []string{"zomato"}, StartsWith: []string{"swi"},
-						EndsWith: []string{"gy"}}, &testCategoryID, testAccountTime, testAccountTime))
+						EndsWith: []string{"gy"}}, &testCategoryID, testAccountTime, testAccountTime, testNullTime))
 			},
 			"", "ZOMATO", nil, nil,
 		},
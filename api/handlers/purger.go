@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+	"vitta/config"
+	"vitta/database"
+	"vitta/storage"
+)
+
+// Purger permanently deletes entities that have been in the trash longer than the retention.
+type Purger struct {
+	cfg     *config.Config
+	db      database.DBIface
+	storage storage.Storage
+}
+
+const (
+	// queryPurgeTransactions also purges the transactions of purged accounts, returning the storage keys of their
+	// attachments.
+	queryPurgeTransactions = `WITH deleted AS (DELETE FROM transactions WHERE deleted_at < $1 OR account_id IN` +
+		` (SELECT id FROM accounts WHERE deleted_at < $1) RETURNING id)` + queryDeleteAttachmentsOf
+	queryPurgeCategories = `DELETE FROM categories WHERE deleted_at < $1 OR group_id IN` +
+		` (SELECT id FROM groups WHERE deleted_at < $1)`
+	queryPurgeGroups   = `DELETE FROM groups WHERE deleted_at < $1`
+	queryPurgePayees   = `DELETE FROM payees WHERE deleted_at < $1`
+	queryPurgeAccounts = `DELETE FROM accounts WHERE deleted_at < $1`
+)
+
+func NewPurger(cfg *config.Config, db database.DBIface, storage storage.Storage) *Purger {
+	return &Purger{cfg: cfg, db: db, storage: storage}
+}
+
+// Run purges the trash every interval, until the context is done.
+func (p *Purger) Run(ctx context.Context) {
+	ctx = database.WithAudit(ctx, database.Audit{Actor: "purger"})
+
+	ticker := time.NewTicker(p.cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		err := p.purge(ctx, time.Now().Add(-p.cfg.TrashRetention))
+		if err != nil {
+			slog.Error("error purging trash", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge permanently deletes the entities moved to the trash before the given time, then their attachment files.
+func (p *Purger) purge(ctx context.Context, before time.Time) error {
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error creating database txn: %w", err)
+	}
+
+	defer func(ctx context.Context) {
+		if err != nil {
+			rollBackErr := tx.Rollback(ctx)
+			if rollBackErr != nil {
+				slog.Error("error rolling back database txn", "error", rollBackErr)
+			}
+		}
+	}(ctx)
+
+	keys, err := queryStorageKeys(ctx, tx, queryPurgeTransactions, before)
+	if err != nil {
+		return fmt.Errorf("error purging transactions: %w", err)
+	}
+
+	for _, query := range []string{queryPurgeCategories, queryPurgeGroups, queryPurgePayees, queryPurgeAccounts} {
+		_, err = tx.Exec(ctx, query, before)
+		if err != nil {
+			return fmt.Errorf("error purging trash: %w", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("error committing database txn: %w", err)
+	}
+
+	deleteAttachmentFiles(ctx, p.storage, keys)
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+	"vitta/config"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgerPurge(t *testing.T) {
+	before := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		mockDBFunc  func(pgxmock.PgxPoolIface)
+		expectedErr string
+	}{
+		{
+			"error creating database txn",
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin().WillReturnError(pgx.ErrTxClosed)
+			},
+			"tx is closed",
+		},
+		{
+			"error purging transactions",
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("DELETE FROM transactions").WithArgs(before).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			"error purging transactions",
+		},
+		{
+			"error purging categories",
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("DELETE FROM transactions").WithArgs(before).
+					WillReturnRows(pgxmock.NewRows([]string{"storage_key"}))
+				mock.ExpectExec("DELETE FROM categories").WithArgs(before).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			"error purging trash",
+		},
+		{
+			"success purging trash",
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("DELETE FROM transactions").WithArgs(before).
+					WillReturnRows(pgxmock.NewRows([]string{"storage_key"}).AddRow(testAttachmentID.String()))
+				mock.ExpectExec("DELETE FROM categories").WithArgs(before).WillReturnResult(pgxmock.NewResult("DELETE", 2))
+				mock.ExpectExec("DELETE FROM groups").WithArgs(before).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectExec("DELETE FROM payees").WithArgs(before).WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				mock.ExpectExec("DELETE FROM accounts").WithArgs(before).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectCommit()
+			},
+			"",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockDB.Close()
+
+			tc.mockDBFunc(mockDB)
+
+			err = NewPurger(&config.Config{}, mockDB, newTestStorage(t)).purge(context.TODO(), before)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
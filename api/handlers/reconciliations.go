@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Reconciliation model.
+type Reconciliation struct {
+	ID               *uuid.UUID `json:"id,omitempty"`
+	AccountID        uuid.UUID  `json:"accountId"`
+	StatementDate    time.Time  `json:"statementDate"`
+	StatementBalance float64    `json:"statementBalance"`
+	ClearedBalance   float64    `json:"clearedBalance"`
+	Difference       float64    `json:"difference"`
+	Transactions     int        `json:"transactions"`
+	CreatedAt        *time.Time `json:"createdAt,omitempty"`
+}
+
+// reconciliationTolerance is the allowed difference between the statement and cleared balances.
+const reconciliationTolerance = 0.005
+
+var (
+	errReconciliationDate       = errors.New("statementDate is required")
+	errReconciliationDifference = errors.New("cleared balance does not match the statement balance")
+	errReconciliationChanged    = errors.New("cleared transactions changed while reconciling, retry")
+	errTransactionReconciled    = errors.New("transaction is reconciled, retry with force=true to change it")
+)
+
+const (
+	// queryGetReconciliationBalance returns the balance of cleared transactions dated before $2 and how many of them
+	// are not reconciled yet.
+	queryGetReconciliationBalance = `SELECT COALESCE(SUM(credit)-SUM(debit), 0),` +
+		` COUNT(*) FILTER (WHERE status = 'cleared') FROM (SELECT credit, debit, status FROM transactions` +
+		` WHERE account_id=$1 AND status <> 'uncleared' AND date < $2 AND deleted_at IS NULL) AS t`
+	// queryLockReconciliationBalance is queryGetReconciliationBalance locking the transactions it sums until the end
+	// of the transaction running it.
+	queryLockReconciliationBalance = `SELECT COALESCE(SUM(credit)-SUM(debit), 0),` +
+		` COUNT(*) FILTER (WHERE status = 'cleared') FROM (SELECT credit, debit, status FROM transactions` +
+		` WHERE account_id=$1 AND status <> 'uncleared' AND date < $2 AND deleted_at IS NULL FOR UPDATE) AS t`
+	queryCreateReconciliation = `INSERT INTO reconciliations (id, account_id, statement_date, statement_balance,` +
+		` cleared_balance, transactions, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	queryReconcileTransactions = `UPDATE transactions SET status='reconciled', reconciliation_id=$2, updated_at=$4` +
+		` WHERE account_id=$1 AND status = 'cleared' AND date < $3 AND deleted_at IS NULL`
+	queryGetReconciliations = `SELECT id, account_id, statement_date, statement_balance, cleared_balance,` +
+		` statement_balance - cleared_balance AS difference, transactions, created_at FROM reconciliations` +
+		` WHERE account_id=$1 ORDER BY statement_date DESC, created_at DESC`
+	// queryIsTransactionReconciled checks a transaction and the other side of its transfer.
+	queryIsTransactionReconciled = `SELECT EXISTS (SELECT 1 FROM transactions WHERE ((account_id=$1 AND id=$2) OR` +
+		` transfer_id IN (SELECT transfer_id FROM transactions WHERE account_id=$1 AND id=$2))` +
+		` AND status = 'reconciled')`
+	queryIsTransferReconciled = `SELECT EXISTS (SELECT 1 FROM transactions WHERE transfer_id=$1` +
+		` AND status = 'reconciled')`
+)
+
+// Reconcile reports the difference between a statement balance and the cleared balance of the account up to the
+// statement date. With confirm=true, it locks the cleared transactions up to that date as reconciled.
+func (h *Handler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	accountID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing account id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	confirm, err := strconv.ParseBool(r.URL.Query().Get("confirm"))
+	if err != nil {
+		confirm = false
+	}
+
+	var reconciliation Reconciliation
+
+	err = json.NewDecoder(r.Body).Decode(&reconciliation)
+	if err != nil {
+		slog.Error("error decoding reconcile request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	if reconciliation.StatementDate.IsZero() {
+		slog.Error("error validating reconcile request", "error", errReconciliationDate)
+		buildErrorResponse(w, errReconciliationDate.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	reconciliation.AccountID = accountID
+	reconciliation.StatementDate = dateOf(reconciliation.StatementDate)
+	datedBefore := reconciliation.StatementDate.AddDate(0, 0, 1)
+
+	if confirm {
+		h.confirmReconciliation(w, r, reconciliation, datedBefore)
+
+		return
+	}
+
+	err = getReconciliationBalance(r.Context(), h.db, queryGetReconciliationBalance, &reconciliation, datedBefore)
+	if err != nil {
+		slog.Error("error getting cleared balance from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(reconciliation)
+	if err != nil {
+		slog.Error("error encoding reconciliation response", "error", err)
+	}
+}
+
+// confirmReconciliation creates the reconciliation and marks the cleared transactions up to the statement date as
+// reconciled, as long as their balance still matches the statement balance.
+func (h *Handler) confirmReconciliation(w http.ResponseWriter, r *http.Request, reconciliation Reconciliation,
+	datedBefore time.Time,
+) {
+	reconciliationID, err := uuid.NewV7()
+	if err != nil {
+		slog.Error("error creating reconciliation id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	createdAt := time.Now()
+	reconciliation.ID = &reconciliationID
+	reconciliation.CreatedAt = &createdAt
+
+	err = h.createReconciliation(r.Context(), &reconciliation, datedBefore)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, errReconciliationDifference) || errors.Is(err, errReconciliationChanged) {
+			code = http.StatusConflict
+		}
+
+		slog.Error("error creating reconciliation", "error", err)
+		buildErrorResponse(w, err.Error(), code)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	err = json.NewEncoder(w).Encode(reconciliation)
+	if err != nil {
+		slog.Error("error encoding reconciliation response", "error", err)
+	}
+}
+
+func (h *Handler) GetReconciliations(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	accountID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing account id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	rows, err := h.db.Query(r.Context(), queryGetReconciliations, accountID)
+	if err != nil {
+		slog.Error("error getting reconciliations from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+	defer rows.Close()
+
+	reconciliations := []Reconciliation{}
+
+	for rows.Next() {
+		var reconciliation Reconciliation
+
+		err := rows.Scan(&reconciliation.ID, &reconciliation.AccountID, &reconciliation.StatementDate,
+			&reconciliation.StatementBalance, &reconciliation.ClearedBalance, &reconciliation.Difference,
+			&reconciliation.Transactions, &reconciliation.CreatedAt)
+		if err != nil {
+			slog.Error("error scanning reconciliations row from database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		reconciliations = append(reconciliations, reconciliation)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error reading reconciliations rows from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(reconciliations)
+	if err != nil {
+		slog.Error("error encoding reconciliations response", "error", err)
+	}
+}
+
+// getReconciliationBalance sets the cleared balance, the difference and the count of transactions to reconcile.
+func getReconciliationBalance(ctx context.Context, db dbExecutor, query string, reconciliation *Reconciliation,
+	datedBefore time.Time,
+) error {
+	err := db.QueryRow(ctx, query, reconciliation.AccountID, datedBefore).Scan(&reconciliation.ClearedBalance,
+		&reconciliation.Transactions)
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	reconciliation.Difference = math.Round((reconciliation.StatementBalance-reconciliation.ClearedBalance)*100) / 100 //nolint: mnd,lll
+
+	return nil
+}
+
+func (h *Handler) createReconciliation(ctx context.Context, reconciliation *Reconciliation,
+	datedBefore time.Time,
+) error {
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error creating database txn: %w", err)
+	}
+
+	defer func(ctx context.Context) {
+		if err != nil {
+			rollBackErr := tx.Rollback(ctx)
+			if rollBackErr != nil {
+				slog.Error("error rolling back database txn", "error", rollBackErr)
+			}
+		}
+	}(ctx)
+
+	err = getReconciliationBalance(ctx, tx, queryLockReconciliationBalance, reconciliation, datedBefore)
+	if err != nil {
+		return fmt.Errorf("error getting cleared balance: %w", err)
+	}
+
+	if math.Abs(reconciliation.Difference) >= reconciliationTolerance {
+		err = fmt.Errorf("%w: difference of %.2f", errReconciliationDifference, reconciliation.Difference)
+
+		return err
+	}
+
+	_, err = tx.Exec(ctx, queryCreateReconciliation, reconciliation.ID, reconciliation.AccountID,
+		reconciliation.StatementDate, reconciliation.StatementBalance, reconciliation.ClearedBalance,
+		reconciliation.Transactions, reconciliation.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting reconciliation: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, queryReconcileTransactions, reconciliation.AccountID, reconciliation.ID, datedBefore,
+		reconciliation.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error reconciling transactions: %w", err)
+	}
+
+	// A cleared transaction committed after the balance was locked would be reconciled without being counted.
+	if tag.RowsAffected() != int64(reconciliation.Transactions) {
+		err = errReconciliationChanged
+
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("error committing database txn: %w", err)
+	}
+
+	return nil
+}
+
+// forced reports whether the request asks to change reconciled transactions anyway.
+func forced(r *http.Request) bool {
+	force, err := strconv.ParseBool(r.URL.Query().Get("force"))
+
+	return err == nil && force
+}
+
+// checkReconciled writes a 409 when the query finds a reconciled transaction, unless the request is forced.
+func (h *Handler) checkReconciled(w http.ResponseWriter, r *http.Request, query string, args ...any) bool {
+	if forced(r) {
+		return true
+	}
+
+	var reconciled bool
+
+	err := h.db.QueryRow(r.Context(), query, args...).Scan(&reconciled)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.Error("error getting reconciliation status from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return false
+	}
+
+	if reconciled {
+		slog.Error("error changing transaction", "error", errTransactionReconciled)
+		buildErrorResponse(w, errTransactionReconciled.Error(), http.StatusConflict)
+
+		return false
+	}
+
+	return true
+}
+
+// reconciledFieldsChanged reports whether an update changes the amounts, date or status a reconciliation relies on.
+func reconciledFieldsChanged(before, after Transaction) bool {
+	return before.Credit != after.Credit || before.Debit != after.Debit || !before.Date.Equal(after.Date) ||
+		before.Status != after.Status
+}
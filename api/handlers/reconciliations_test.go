@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+var (
+	testReconciliationID      = uuid.MustParse("01927f3e-6ecf-7091-987f-8aa23adcda31")
+	testStatementDate         = time.Date(2024, 10, 31, 0, 0, 0, 0, time.UTC)
+	testDatedBefore           = time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	testReconciliationBody    = `{"statementDate":"2024-10-31T00:00:00Z","statementBalance":100.50}`
+	reconciliationBalanceCols = []string{"balance", "count"}
+	reconciliationRowCols     = []string{"id", "account_id", "statement_date", "statement_balance", "cleared_balance",
+		"difference", "transactions", "created_at"}
+)
+
+func TestReconcile(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodPost, "/v1/accounts/" + testAccountID.String() + "/reconciliations", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad account id", http.MethodPost, "/v1/accounts/invalid-uuid/reconciliations", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error due to bad request", http.MethodPost, "/v1/accounts/" + testAccountID.String() + "/reconciliations", true,
+			strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusBadRequest, "invalid character",
+		},
+		{
+			"error due to missing statement date", http.MethodPost, "/v1/accounts/" + testAccountID.String() + "/reconciliations", true,
+			strings.NewReader(`{"statementBalance":100.50}`),
+			nil, nil,
+			http.StatusBadRequest, "statementDate is required",
+		},
+		{
+			"error getting cleared balance from database", http.MethodPost, "/v1/accounts/" + testAccountID.String() + "/reconciliations", true,
+			strings.NewReader(testReconciliationBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT COALESCE").WithArgs(testAccountID, testDatedBefore).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success previewing reconciliation", http.MethodPost, "/v1/accounts/" + testAccountID.String() + "/reconciliations", true,
+			strings.NewReader(testReconciliationBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT COALESCE").WithArgs(testAccountID, testDatedBefore).
+					WillReturnRows(pgxmock.NewRows(reconciliationBalanceCols).AddRow(90.25, 3))
+			},
+			http.StatusOK, `"clearedBalance":90.25,"difference":10.25,"transactions":3`,
+		},
+		{
+			"error confirming reconciliation with a difference", http.MethodPost, "/v1/accounts/" + testAccountID.String() + "/reconciliations?confirm=true", true,
+			strings.NewReader(testReconciliationBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT COALESCE.+FOR UPDATE").WithArgs(testAccountID, testDatedBefore).
+					WillReturnRows(pgxmock.NewRows(reconciliationBalanceCols).AddRow(90.25, 3))
+				mock.ExpectRollback()
+			},
+			http.StatusConflict, "difference of 10.25",
+		},
+		{
+			"error reconciling transactions in database", http.MethodPost, "/v1/accounts/" + testAccountID.String() + "/reconciliations?confirm=true", true,
+			strings.NewReader(testReconciliationBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT COALESCE.+FOR UPDATE").WithArgs(testAccountID, testDatedBefore).
+					WillReturnRows(pgxmock.NewRows(reconciliationBalanceCols).AddRow(100.50, 3))
+				mock.ExpectExec("INSERT INTO reconciliations").WithArgs(pgxmock.AnyArg(), testAccountID, testStatementDate, 100.50,
+					100.50, 3, pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectExec("UPDATE transactions SET status").WithArgs(testAccountID, pgxmock.AnyArg(), testDatedBefore,
+					pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error due to transactions cleared while reconciling", http.MethodPost, "/v1/accounts/" + testAccountID.String() + "/reconciliations?confirm=true", true,
+			strings.NewReader(testReconciliationBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT COALESCE.+FOR UPDATE").WithArgs(testAccountID, testDatedBefore).
+					WillReturnRows(pgxmock.NewRows(reconciliationBalanceCols).AddRow(100.50, 3))
+				mock.ExpectExec("INSERT INTO reconciliations").WithArgs(pgxmock.AnyArg(), testAccountID, testStatementDate, 100.50,
+					100.50, 3, pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectExec("UPDATE transactions SET status").WithArgs(testAccountID, pgxmock.AnyArg(), testDatedBefore,
+					pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("UPDATE", 4))
+				mock.ExpectRollback()
+			},
+			http.StatusConflict, "cleared transactions changed while reconciling",
+		},
+		{
+			"success confirming reconciliation", http.MethodPost, "/v1/accounts/" + testAccountID.String() + "/reconciliations?confirm=true", true,
+			strings.NewReader(testReconciliationBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT COALESCE.+FOR UPDATE").WithArgs(testAccountID, testDatedBefore).
+					WillReturnRows(pgxmock.NewRows(reconciliationBalanceCols).AddRow(100.50, 3))
+				mock.ExpectExec("INSERT INTO reconciliations").WithArgs(pgxmock.AnyArg(), testAccountID, testStatementDate, 100.50,
+					100.50, 3, pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectExec("UPDATE transactions SET status").WithArgs(testAccountID, pgxmock.AnyArg(), testDatedBefore,
+					pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+				mock.ExpectCommit()
+			},
+			http.StatusCreated, `"difference":0,"transactions":3`,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestGetReconciliations(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/reconciliations", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad account id", http.MethodGet, "/v1/accounts/invalid-uuid/reconciliations", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error getting reconciliations from database", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/reconciliations", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT id").WithArgs(testAccountID).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error reading reconciliations rows from database", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/reconciliations", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT id").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(reconciliationRowCols).
+					RowError(0, errors.New("some error in db")))
+			},
+			http.StatusInternalServerError, "some error in db",
+		},
+		{
+			"success getting reconciliations", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/reconciliations", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT id").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(reconciliationRowCols).
+					AddRow(&testReconciliationID, testAccountID, testStatementDate, 100.50, 100.50, 0.0, 3, &testAccountTime))
+			},
+			http.StatusOK, `"id":"` + testReconciliationID.String() + `"`,
+		},
+	}
+	executeTests(t, tests)
+}
@@ -0,0 +1,447 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// RecurringTransaction model.
+type RecurringTransaction struct {
+	ID         uuid.UUID  `json:"id"`
+	AccountID  uuid.UUID  `json:"accountId"`
+	CategoryID *uuid.UUID `json:"categoryId,omitempty"`
+	PayeeID    *uuid.UUID `json:"payeeId,omitempty"`
+	Name       string     `json:"name"`
+	Credit     float64    `json:"credit,omitempty"`
+	Debit      float64    `json:"debit,omitempty"`
+	Notes      string     `json:"notes,omitempty"`
+	Frequency  string     `json:"frequency"`
+	Interval   int        `json:"interval"`
+	DayOfMonth *int       `json:"dayOfMonth,omitempty"`
+	StartDate  time.Time  `json:"startDate"`
+	EndDate    *time.Time `json:"endDate,omitempty"`
+	NextDate   *time.Time `json:"nextDate,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+const (
+	frequencyWeekly  = "weekly"
+	frequencyMonthly = "monthly"
+
+	// scheduledMatchDays is how many days an imported transaction may be away from a scheduled instance to match it.
+	scheduledMatchDays = 4
+)
+
+var (
+	errRecurringFrequency = errors.New("frequency must be weekly or monthly")
+	errRecurringInterval  = errors.New("interval must be positive")
+	errRecurringDay       = errors.New("dayOfMonth must be between 1 and 31")
+	errRecurringStart     = errors.New("startDate is required")
+	errRecurringEnd       = errors.New("endDate must not be before startDate")
+	errRecurringAmount    = errors.New("credit or debit must be positive")
+)
+
+const (
+	queryCreateRecurringTransaction = `INSERT INTO recurring_transactions (id, account_id, category_id, payee_id,` +
+		` name, credit, debit, notes, frequency, interval, day_of_month, start_date, end_date, next_date, created_at,` +
+		` updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`
+	queryUpdateRecurringTransaction = `UPDATE recurring_transactions SET account_id=$1, category_id=$2, payee_id=$3,` +
+		` name=$4, credit=$5, debit=$6, notes=$7, frequency=$8, interval=$9, day_of_month=$10, start_date=$11,` +
+		` end_date=$12, next_date=$13, updated_at=$14 WHERE id=$15 AND updated_at IS NOT DISTINCT FROM $16`
+	queryGetRecurringTransactionVersion = `SELECT updated_at FROM recurring_transactions WHERE id=$1 FOR UPDATE`
+	// queryUpdateScheduledTransactions carries the category, payee and amounts of template $1 over to its instances
+	// dated from $7 on that have not cleared yet, each only when $8, $9 and $10 are set respectively.
+	queryUpdateScheduledTransactions = `UPDATE transactions SET category_id = CASE WHEN $8::boolean THEN $2` +
+		` ELSE category_id END, payee_id = CASE WHEN $9::boolean THEN $3 ELSE payee_id END,` +
+		` credit = CASE WHEN $10::boolean THEN $4 ELSE credit END, debit = CASE WHEN $10::boolean THEN $5` +
+		` ELSE debit END, updated_at=$6 WHERE status = 'uncleared' AND deleted_at IS NULL AND date >= $7` +
+		` AND id IN (SELECT transaction_id FROM scheduled_transactions WHERE recurring_id=$1)`
+	// queryDeleteRecurringTransaction also moves the instances of the template that have not cleared yet to the trash.
+	queryDeleteRecurringTransaction = `WITH template AS (DELETE FROM recurring_transactions WHERE id=$1)` +
+		` UPDATE transactions SET deleted_at=$2 WHERE status = 'uncleared' AND deleted_at IS NULL AND id IN` +
+		` (SELECT transaction_id FROM scheduled_transactions WHERE recurring_id=$1)`
+	queryGetRecurringTransaction  = `SELECT * FROM recurring_transactions WHERE id=$1`
+	queryGetRecurringTransactions = `SELECT * FROM recurring_transactions ORDER BY next_date ASC NULLS LAST,` +
+		` created_at ASC`
+)
+
+func (h *Handler) CreateRecurringTransaction(w http.ResponseWriter, r *http.Request) {
+	var recurring RecurringTransaction
+
+	err := json.NewDecoder(r.Body).Decode(&recurring)
+	if err != nil {
+		slog.Error("error decoding create recurring transaction request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	err = validateRecurringTransaction(&recurring)
+	if err != nil {
+		slog.Error("error validating recurring transaction", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	recurring.ID, err = uuid.NewV7()
+	if err != nil {
+		slog.Error("error creating recurring transaction id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	recurring.NextDate = recurring.nextOccurrence(recurring.StartDate)
+	recurring.CreatedAt = time.Now()
+	recurring.UpdatedAt = recurring.CreatedAt
+
+	_, err = h.db.Exec(r.Context(), queryCreateRecurringTransaction, recurring.ID, recurring.AccountID,
+		recurring.CategoryID, recurring.PayeeID, recurring.Name, recurring.Credit, recurring.Debit, recurring.Notes,
+		recurring.Frequency, recurring.Interval, recurring.DayOfMonth, recurring.StartDate, recurring.EndDate,
+		recurring.NextDate, recurring.CreatedAt, recurring.UpdatedAt)
+	if err != nil {
+		slog.Error("error creating recurring transaction in database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	err = json.NewEncoder(w).Encode(recurring)
+	if err != nil {
+		slog.Error("error encoding recurring transaction response", "error", err)
+	}
+}
+
+func (h *Handler) UpdateRecurringTransaction(w http.ResponseWriter, r *http.Request) { //nolint: funlen
+	id := r.PathValue("id")
+
+	recurringID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing recurring transaction id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var recurring RecurringTransaction
+
+	err = scanRecurringTransaction(h.db.QueryRow(r.Context(), queryGetRecurringTransaction, recurringID), &recurring)
+	if err != nil {
+		slog.Error("error getting recurring transaction from database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	if !matchesIfMatch(r, recurring.UpdatedAt) {
+		slog.Error("error matching recurring transaction version", "error", errPreconditionFailed)
+		buildErrorResponse(w, errPreconditionFailed.Error(), http.StatusPreconditionFailed)
+
+		return
+	}
+
+	nextDate := recurring.NextDate
+	version := recurring.UpdatedAt
+
+	fields, err := decodeMergePatch(r.Body, &recurring)
+	if err != nil {
+		slog.Error("error decoding update recurring transaction request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	err = validateRecurringTransaction(&recurring)
+	if err != nil {
+		slog.Error("error validating recurring transaction", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	// A changed schedule restarts from today, occurrences already materialized are skipped by the scheduler.
+	recurring.NextDate = nextDate
+
+	for _, field := range []string{"frequency", "interval", "dayOfMonth", "startDate", "endDate"} {
+		if _, ok := fields[field]; ok {
+			recurring.NextDate = recurring.nextOccurrence(time.Now())
+
+			break
+		}
+	}
+
+	recurring.UpdatedAt = time.Now()
+
+	err = h.updateRecurringTransaction(r.Context(), recurring, version, fields)
+	if err != nil {
+		slog.Error("error updating recurring transaction in database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) DeleteRecurringTransaction(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	recurringID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing recurring transaction id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	err = h.writeIfMatch(r, []versionQuery{lockVersion(queryGetRecurringTransactionVersion, recurringID)},
+		func(db dbExecutor) error {
+			_, err := db.Exec(r.Context(), queryDeleteRecurringTransaction, recurringID, time.Now())
+
+			return err //nolint: wrapcheck
+		})
+	if err != nil {
+		slog.Error("error deleting recurring transaction in database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) GetRecurringTransaction(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	recurringID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing recurring transaction id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var recurring RecurringTransaction
+
+	err = scanRecurringTransaction(h.db.QueryRow(r.Context(), queryGetRecurringTransaction, recurringID), &recurring)
+	if err != nil {
+		slog.Error("error getting recurring transaction from database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(recurring.UpdatedAt))
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(recurring)
+	if err != nil {
+		slog.Error("error encoding recurring transaction response", "error", err)
+	}
+}
+
+func (h *Handler) GetRecurringTransactions(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.Query(r.Context(), queryGetRecurringTransactions)
+	if err != nil {
+		slog.Error("error getting recurring transactions from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+	defer rows.Close()
+
+	recurringTransactions := []RecurringTransaction{}
+
+	for rows.Next() {
+		var recurring RecurringTransaction
+
+		err := scanRecurringTransaction(rows, &recurring)
+		if err != nil {
+			slog.Error("error scanning recurring transactions row from database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		recurringTransactions = append(recurringTransactions, recurring)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error reading recurring transactions rows from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(map[string]interface{}{"recurringTransactions": recurringTransactions})
+	if err != nil {
+		slog.Error("error encoding recurring transactions response", "error", err)
+	}
+}
+
+// updateRecurringTransaction updates a template still at version, along with the instances it has not cleared yet
+// from today on, for the category, payee and amounts changed by the patch fields.
+func (h *Handler) updateRecurringTransaction(ctx context.Context, recurring RecurringTransaction,
+	version time.Time, fields map[string]json.RawMessage,
+) error {
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error creating database txn: %w", err)
+	}
+
+	defer func(ctx context.Context) {
+		if err != nil {
+			rollBackErr := tx.Rollback(ctx)
+			if rollBackErr != nil {
+				slog.Error("error rolling back database txn", "error", rollBackErr)
+			}
+		}
+	}(ctx)
+
+	result, err := tx.Exec(ctx, queryUpdateRecurringTransaction, recurring.AccountID, recurring.CategoryID,
+		recurring.PayeeID, recurring.Name, recurring.Credit, recurring.Debit, recurring.Notes, recurring.Frequency,
+		recurring.Interval, recurring.DayOfMonth, recurring.StartDate, recurring.EndDate, recurring.NextDate,
+		recurring.UpdatedAt, recurring.ID, version)
+	if err != nil {
+		return fmt.Errorf("error updating recurring transaction: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		err = errPreconditionFailed
+
+		return err
+	}
+
+	_, categorySet := fields["categoryId"]
+	_, payeeSet := fields["payeeId"]
+	_, creditSet := fields["credit"]
+	_, debitSet := fields["debit"]
+
+	if categorySet || payeeSet || creditSet || debitSet {
+		_, err = tx.Exec(ctx, queryUpdateScheduledTransactions, recurring.ID, recurring.CategoryID, recurring.PayeeID,
+			recurring.Credit, recurring.Debit, recurring.UpdatedAt, dateOf(recurring.UpdatedAt), categorySet, payeeSet,
+			creditSet || debitSet)
+		if err != nil {
+			return fmt.Errorf("error updating scheduled transactions: %w", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("error committing database txn: %w", err)
+	}
+
+	return nil
+}
+
+// nextOccurrence returns the first occurrence of the schedule on or after from, or nil once the schedule has ended.
+func (rt RecurringTransaction) nextOccurrence(from time.Time) *time.Time {
+	start := dateOf(rt.StartDate)
+
+	from = dateOf(from)
+	if from.Before(start) {
+		from = start
+	}
+
+	var next time.Time
+
+	switch rt.Frequency {
+	case frequencyWeekly:
+		step := 7 * rt.Interval
+		days := int(from.Sub(start).Hours() / 24)
+		next = start.AddDate(0, 0, (days+step-1)/step*step)
+	case frequencyMonthly:
+		months := (from.Year()-start.Year())*12 + int(from.Month()-start.Month())
+
+		for offset := months / rt.Interval * rt.Interval; ; offset += rt.Interval {
+			next = monthDay(start.Year(), start.Month()+time.Month(offset), *rt.DayOfMonth)
+			if !next.Before(from) {
+				break
+			}
+		}
+	}
+
+	if rt.EndDate != nil && next.After(dateOf(*rt.EndDate)) {
+		return nil
+	}
+
+	return &next
+}
+
+func validateRecurringTransaction(recurring *RecurringTransaction) error {
+	if recurring.Credit < 0 || recurring.Debit < 0 || recurring.Credit+recurring.Debit == 0 {
+		return errRecurringAmount
+	}
+
+	if recurring.Interval == 0 {
+		recurring.Interval = 1
+	}
+
+	if recurring.Interval < 0 {
+		return errRecurringInterval
+	}
+
+	if recurring.StartDate.IsZero() {
+		return errRecurringStart
+	}
+
+	if recurring.EndDate != nil && recurring.EndDate.Before(recurring.StartDate) {
+		return errRecurringEnd
+	}
+
+	switch recurring.Frequency {
+	case frequencyWeekly:
+		recurring.DayOfMonth = nil
+	case frequencyMonthly:
+		if recurring.DayOfMonth == nil {
+			day := recurring.StartDate.Day()
+			recurring.DayOfMonth = &day
+		}
+
+		if *recurring.DayOfMonth < 1 || *recurring.DayOfMonth > 31 {
+			return errRecurringDay
+		}
+	default:
+		return errRecurringFrequency
+	}
+
+	return nil
+}
+
+func scanRecurringTransaction(row pgx.Row, recurring *RecurringTransaction) error {
+	return row.Scan(&recurring.ID, &recurring.AccountID, &recurring.CategoryID, &recurring.PayeeID, //nolint: wrapcheck
+		&recurring.Name, &recurring.Credit, &recurring.Debit, &recurring.Notes, &recurring.Frequency,
+		&recurring.Interval, &recurring.DayOfMonth, &recurring.StartDate, &recurring.EndDate, &recurring.NextDate,
+		&recurring.CreatedAt, &recurring.UpdatedAt)
+}
+
+// dateOf truncates a time to its calendar date in UTC.
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// monthDay returns the day of the month, clamped to the last day of shorter months.
+func monthDay(year int, month time.Month, day int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+
+	return first.AddDate(0, 0, min(day, first.AddDate(0, 1, -1).Day())-1)
+}
@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testRecurringID    = uuid.MustParse("01927f40-0a1b-7c2d-8e3f-4a5b6c7d8e9f")
+	testRecurringStart = time.Date(2024, 10, 5, 0, 0, 0, 0, time.UTC)
+	testRecurringDay   = 5
+	testNullTime       *time.Time
+	recurringRowCols   = []string{"id", "account_id", "category_id", "payee_id", "name", "credit", "debit", "notes",
+		"frequency", "interval", "day_of_month", "start_date", "end_date", "next_date", "created_at", "updated_at"}
+)
+
+func TestCreateRecurringTransaction(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodPost, "/v1/recurring", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad request", http.MethodPost, "/v1/recurring", true, strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusBadRequest, "invalid character",
+		},
+		{
+			"error due to invalid frequency", http.MethodPost, "/v1/recurring", true,
+			strings.NewReader(`{"accountId":"` + testAccountID.String() + `","debit":25000,"frequency":"daily",` +
+				`"startDate":"2024-10-05T00:00:00Z"}`),
+			nil, nil,
+			http.StatusBadRequest, "frequency must be weekly or monthly",
+		},
+		{
+			"error due to missing amount", http.MethodPost, "/v1/recurring", true,
+			strings.NewReader(`{"accountId":"` + testAccountID.String() + `","frequency":"monthly",` +
+				`"startDate":"2024-10-05T00:00:00Z"}`),
+			nil, nil,
+			http.StatusBadRequest, "credit or debit must be positive",
+		},
+		{
+			"error due to end before start", http.MethodPost, "/v1/recurring", true,
+			strings.NewReader(`{"accountId":"` + testAccountID.String() + `","debit":25000,"frequency":"monthly",` +
+				`"startDate":"2024-10-05T00:00:00Z","endDate":"2024-09-05T00:00:00Z"}`),
+			nil, nil,
+			http.StatusBadRequest, "endDate must not be before startDate",
+		},
+		{
+			"error creating recurring transaction in database", http.MethodPost, "/v1/recurring", true,
+			strings.NewReader(`{"accountId":"` + testAccountID.String() + `","name":"Rent","debit":25000,` +
+				`"frequency":"monthly","startDate":"2024-10-05T00:00:00Z"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("INSERT INTO recurring_transactions").WithArgs(pgxmock.AnyArg(), testAccountID, testNullID, testNullID,
+					"Rent", 0.0, 25000.0, "", frequencyMonthly, 1, &testRecurringDay, testRecurringStart, testNullTime,
+					&testRecurringStart, pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success creating recurring transaction", http.MethodPost, "/v1/recurring", true,
+			strings.NewReader(`{"accountId":"` + testAccountID.String() + `","name":"Rent","debit":25000,` +
+				`"frequency":"monthly","startDate":"2024-10-05T00:00:00Z"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("INSERT INTO recurring_transactions").WithArgs(pgxmock.AnyArg(), testAccountID, testNullID, testNullID,
+					"Rent", 0.0, 25000.0, "", frequencyMonthly, 1, &testRecurringDay, testRecurringStart, testNullTime,
+					&testRecurringStart, pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			},
+			http.StatusCreated, `"nextDate":"2024-10-05T00:00:00Z"`,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestUpdateRecurringTransaction(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodPatch, "/v1/recurring/invalid-uuid", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad recurring transaction id", http.MethodPatch, "/v1/recurring/invalid-uuid", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error due to recurring transaction not found", http.MethodPatch, "/v1/recurring/" + testRecurringID.String(), true,
+			strings.NewReader(`{"name":"Rent"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM recurring_transactions").WithArgs(testRecurringID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusNotFound, "no rows",
+		},
+		{
+			"error due to stale if-match", http.MethodPatch, "/v1/recurring/" + testRecurringID.String(), true,
+			strings.NewReader(`{"name":"Rent"}`),
+			http.Header{"If-Match": []string{`"stale"`}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM recurring_transactions").WithArgs(testRecurringID).WillReturnRows(recurringRows())
+			},
+			http.StatusPreconditionFailed, "resource has been modified",
+		},
+		{
+			"error due to invalid interval", http.MethodPatch, "/v1/recurring/" + testRecurringID.String(), true,
+			strings.NewReader(`{"interval":-1}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM recurring_transactions").WithArgs(testRecurringID).WillReturnRows(recurringRows())
+			},
+			http.StatusBadRequest, "interval must be positive",
+		},
+		{
+			"success updating recurring transaction", http.MethodPatch, "/v1/recurring/" + testRecurringID.String(), true,
+			strings.NewReader(`{"name":"House rent","debit":27000}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM recurring_transactions").WithArgs(testRecurringID).WillReturnRows(recurringRows())
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE recurring_transactions").WithArgs(testAccountID, testNullID, testNullID, "House rent", 0.0,
+					27000.0, "", frequencyMonthly, 1, &testRecurringDay, testRecurringStart, testNullTime, &testRecurringStart,
+					pgxmock.AnyArg(), testRecurringID, testAccountTime).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectExec("UPDATE transactions SET category_id").WithArgs(testRecurringID, testNullID, testNullID, 0.0,
+					27000.0, pgxmock.AnyArg(), pgxmock.AnyArg(), false, false, true).WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+				mock.ExpectCommit()
+			},
+			http.StatusNoContent, "",
+		},
+		{
+			"error updating scheduled transactions in database", http.MethodPatch, "/v1/recurring/" + testRecurringID.String(), true,
+			strings.NewReader(`{"categoryId":"` + testCategoryID.String() + `"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM recurring_transactions").WithArgs(testRecurringID).WillReturnRows(recurringRows())
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE recurring_transactions").WithArgs(testAccountID, &testCategoryID, testNullID, "Rent", 0.0,
+					25000.0, "", frequencyMonthly, 1, &testRecurringDay, testRecurringStart, testNullTime, &testRecurringStart,
+					pgxmock.AnyArg(), testRecurringID, testAccountTime).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectExec("UPDATE transactions SET category_id").WithArgs(testRecurringID, &testCategoryID, testNullID, 0.0,
+					25000.0, pgxmock.AnyArg(), pgxmock.AnyArg(), true, false, false).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error due to concurrent update", http.MethodPatch, "/v1/recurring/" + testRecurringID.String(), true,
+			strings.NewReader(`{"name":"House rent"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM recurring_transactions").WithArgs(testRecurringID).WillReturnRows(recurringRows())
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE recurring_transactions").WithArgs(testAccountID, testNullID, testNullID, "House rent", 0.0,
+					25000.0, "", frequencyMonthly, 1, &testRecurringDay, testRecurringStart, testNullTime, &testRecurringStart,
+					pgxmock.AnyArg(), testRecurringID, testAccountTime).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+				mock.ExpectRollback()
+			},
+			http.StatusPreconditionFailed, "resource has been modified",
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestDeleteRecurringTransaction(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodDelete, "/v1/recurring/invalid-uuid", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad recurring transaction id", http.MethodDelete, "/v1/recurring/invalid-uuid", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error deleting recurring transaction in database", http.MethodDelete, "/v1/recurring/" + testRecurringID.String(), true,
+			nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM recurring_transactions").WithArgs(testRecurringID, pgxmock.AnyArg()).
+					WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success deleting recurring transaction", http.MethodDelete, "/v1/recurring/" + testRecurringID.String(), true,
+			nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM recurring_transactions").WithArgs(testRecurringID, pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusNoContent, "",
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestGetRecurringTransaction(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, "/v1/recurring/invalid-uuid", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad recurring transaction id", http.MethodGet, "/v1/recurring/invalid-uuid", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error due to recurring transaction not found", http.MethodGet, "/v1/recurring/" + testRecurringID.String(), true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM recurring_transactions").WithArgs(testRecurringID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusNotFound, "no rows",
+		},
+		{
+			"success getting recurring transaction", http.MethodGet, "/v1/recurring/" + testRecurringID.String(), true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM recurring_transactions").WithArgs(testRecurringID).WillReturnRows(recurringRows())
+			},
+			http.StatusOK, testRecurringID.String(),
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestGetRecurringTransactions(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, "/v1/recurring", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error getting recurring transactions from db", http.MethodGet, "/v1/recurring", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM recurring_transactions").WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error reading recurring transactions rows from db", http.MethodGet, "/v1/recurring", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM recurring_transactions").WillReturnRows(pgxmock.NewRows(recurringRowCols).
+					RowError(0, errors.New("some error in db")))
+			},
+			http.StatusInternalServerError, "some error in db",
+		},
+		{
+			"success", http.MethodGet, "/v1/recurring", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM recurring_transactions").WillReturnRows(recurringRows())
+			},
+			http.StatusOK, `"frequency":"monthly"`,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestNextOccurrence(t *testing.T) {
+	end := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	day := 31
+	monthly := RecurringTransaction{Frequency: frequencyMonthly, Interval: 1, DayOfMonth: &day,
+		StartDate: time.Date(2024, 10, 5, 0, 0, 0, 0, time.UTC), EndDate: &end}
+
+	assert.Equal(t, time.Date(2024, 10, 31, 0, 0, 0, 0, time.UTC), *monthly.nextOccurrence(monthly.StartDate))
+	assert.Equal(t, time.Date(2024, 11, 30, 0, 0, 0, 0, time.UTC),
+		*monthly.nextOccurrence(time.Date(2024, 11, 1, 9, 30, 0, 0, time.Local)))
+	assert.Equal(t, time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC),
+		*monthly.nextOccurrence(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Nil(t, monthly.nextOccurrence(time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)))
+
+	monthly.Interval = 3
+	assert.Equal(t, time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+		*monthly.nextOccurrence(time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)))
+
+	weekly := RecurringTransaction{Frequency: frequencyWeekly, Interval: 2,
+		StartDate: time.Date(2024, 10, 5, 0, 0, 0, 0, time.UTC)}
+
+	assert.Equal(t, time.Date(2024, 10, 5, 0, 0, 0, 0, time.UTC),
+		*weekly.nextOccurrence(time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2024, 10, 19, 0, 0, 0, 0, time.UTC),
+		*weekly.nextOccurrence(time.Date(2024, 10, 6, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2024, 10, 19, 0, 0, 0, 0, time.UTC),
+		*weekly.nextOccurrence(time.Date(2024, 10, 19, 0, 0, 0, 0, time.UTC)))
+}
+
+func recurringRows() *pgxmock.Rows {
+	return pgxmock.NewRows(recurringRowCols).AddRow(testRecurringID, testAccountID, testNullID, testNullID, "Rent", 0.0, 25000.0, "",
+		frequencyMonthly, 1, &testRecurringDay, testRecurringStart, testNullTime, &testRecurringStart, testAccountTime,
+		testAccountTime)
+}
@@ -0,0 +1,750 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type (
+	// Scenario model. A scenario is a named draft of the budget over a range of months, kept apart from the real
+	// budgets until it is applied.
+	Scenario struct {
+		ID        uuid.UUID        `json:"id"`
+		Name      string           `json:"name"`
+		Notes     *string          `json:"notes"`
+		From      string           `json:"from"`
+		To        string           `json:"to"`
+		CreatedAt time.Time        `json:"createdAt"`
+		UpdatedAt time.Time        `json:"updatedAt"`
+		Budgets   []ScenarioBudget `json:"budgets,omitempty"`
+	}
+
+	// ScenarioBudget model. The amount a scenario budgets for a category in a month.
+	ScenarioBudget struct {
+		CategoryID uuid.UUID `json:"categoryId"`
+		Year       uint16    `json:"year"`
+		Month      uint8     `json:"month"`
+		Budgeted   float64   `json:"budgeted"`
+	}
+
+	// ComparisonAmounts model. The difference is the scenario amount minus the budgeted one.
+	ComparisonAmounts struct {
+		Scenario   float64 `json:"scenario"`
+		Budgeted   float64 `json:"budgeted"`
+		Spent      float64 `json:"spent"`
+		Difference float64 `json:"difference"`
+	}
+
+	// ComparisonMonth model.
+	ComparisonMonth struct {
+		Year  int `json:"year"`
+		Month int `json:"month"`
+		ComparisonAmounts
+	}
+
+	// ComparisonCategory model.
+	ComparisonCategory struct {
+		GroupID      uuid.UUID         `json:"groupId"`
+		GroupName    string            `json:"groupName"`
+		CategoryID   uuid.UUID         `json:"categoryId"`
+		CategoryName string            `json:"categoryName"`
+		Months       []ComparisonMonth `json:"months"`
+		Totals       ComparisonAmounts `json:"totals"`
+	}
+
+	// ScenarioComparison model. Its months and totals add up those of all categories.
+	ScenarioComparison struct {
+		ScenarioID uuid.UUID            `json:"scenarioId"`
+		Name       string               `json:"name"`
+		From       string               `json:"from"`
+		To         string               `json:"to"`
+		Months     []ComparisonMonth    `json:"months"`
+		Totals     ComparisonAmounts    `json:"totals"`
+		Categories []ComparisonCategory `json:"categories"`
+	}
+
+	scenarioKey struct {
+		categoryID uuid.UUID
+		month      int
+	}
+)
+
+var (
+	errScenarioName     = errors.New("name must not be empty")
+	errScenarioMonth    = errors.New("month must be within the range of the scenario")
+	errScenarioCategory = errors.New("category must exist and not be an income category")
+)
+
+const (
+	queryCreateScenario = `INSERT INTO scenarios (id, name, notes, from_month, to_month, created_at, updated_at)` +
+		` VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	queryGetScenario = `SELECT id, name, notes, from_month, to_month, created_at, updated_at FROM scenarios` +
+		` WHERE id=$1`
+	queryGetScenarios = `SELECT id, name, notes, from_month, to_month, created_at, updated_at FROM scenarios` +
+		` ORDER BY created_at ASC`
+	queryUpdateScenario = `UPDATE scenarios SET name=$2, notes=$3, from_month=$4, to_month=$5, updated_at=$6` +
+		` WHERE id=$1`
+	queryDeleteScenario     = `DELETE FROM scenarios WHERE id=$1`
+	queryGetScenarioVersion = `SELECT updated_at FROM scenarios WHERE id=$1 FOR UPDATE`
+	// queryTouchScenario moves the version of a scenario forward when one of its budgets changes.
+	queryTouchScenario = `UPDATE scenarios SET updated_at=$2 WHERE id=$1`
+	// queryGetScenarioStart returns the real budgets from $1 to $2 of the categories that can be budgeted, to start
+	// a scenario from.
+	queryGetScenarioStart = `SELECT b.category_id, b.year, b.month, b.budgeted FROM budgets AS b JOIN categories` +
+		` AS cg ON cg.id = b.category_id AND cg.deleted_at IS NULL AND NOT cg.income WHERE b.budgeted IS NOT NULL` +
+		` AND make_date(b.year, b.month, 1) BETWEEN $1 AND $2 ORDER BY b.year, b.month`
+	// queryGetScenarioBudgets returns the budgets of scenario $1 for the categories that can still be budgeted.
+	queryGetScenarioBudgets = `SELECT sb.category_id, sb.year, sb.month, sb.budgeted FROM scenario_budgets AS sb` +
+		` JOIN categories AS cg ON cg.id = sb.category_id AND cg.deleted_at IS NULL AND NOT cg.income` +
+		` WHERE sb.scenario_id=$1 ORDER BY sb.year, sb.month`
+	querySetScenarioBudget = `INSERT INTO scenario_budgets (id, scenario_id, category_id, year, month, budgeted,` +
+		` created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $7) ON CONFLICT (scenario_id, year, month,` +
+		` category_id) DO UPDATE SET budgeted=$6, updated_at=$7`
+	queryDeleteScenarioBudget = `DELETE FROM scenario_budgets WHERE scenario_id=$1 AND category_id=$2 AND year=$3` +
+		` AND month=$4`
+	// queryTrimScenarioBudgets drops the budgets of scenario $1 outside of its range from $2 to $3.
+	queryTrimScenarioBudgets = `DELETE FROM scenario_budgets WHERE scenario_id=$1` +
+		` AND make_date(year, month, 1) NOT BETWEEN $2 AND $3`
+)
+
+// CreateScenario creates a scenario over a range of months, starting from a copy of the real budgets.
+func (h *Handler) CreateScenario(w http.ResponseWriter, r *http.Request) { //nolint: funlen
+	var scenario Scenario
+
+	err := json.NewDecoder(r.Body).Decode(&scenario)
+	if err != nil {
+		slog.Error("error decoding create scenario request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	if scenario.Name == "" {
+		slog.Error("error validating create scenario request", "error", errScenarioName)
+		buildErrorResponse(w, errScenarioName.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	from, to, err := parseMonthRange(scenario.From, scenario.To)
+	if err != nil {
+		slog.Error("error parsing scenario range", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	scenario.ID, err = uuid.NewV7()
+	if err != nil {
+		slog.Error("error creating scenario id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	scenario.CreatedAt = time.Now()
+	scenario.UpdatedAt = scenario.CreatedAt
+
+	scenario.Budgets, err = h.createScenario(r.Context(), scenario, from, to)
+	if err != nil {
+		slog.Error("error creating scenario in database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	err = json.NewEncoder(w).Encode(scenario)
+	if err != nil {
+		slog.Error("error encoding scenario response", "error", err)
+	}
+}
+
+func (h *Handler) GetScenarios(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.Query(r.Context(), queryGetScenarios)
+	if err != nil {
+		slog.Error("error getting scenarios from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+	defer rows.Close()
+
+	scenarios := []Scenario{}
+
+	for rows.Next() {
+		var scenario Scenario
+
+		err := scanScenario(rows, &scenario)
+		if err != nil {
+			slog.Error("error scanning scenarios row from database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		scenarios = append(scenarios, scenario)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error reading scenarios rows from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(scenarios)
+	if err != nil {
+		slog.Error("error encoding scenarios response", "error", err)
+	}
+}
+
+// GetScenario returns a scenario with its budgets.
+func (h *Handler) GetScenario(w http.ResponseWriter, r *http.Request) {
+	scenario, ok := h.getScenario(w, r)
+	if !ok {
+		return
+	}
+
+	budgets, err := h.getScenarioBudgets(r.Context(), scenario.ID)
+	if err != nil {
+		slog.Error("error getting scenario budgets from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	scenario.Budgets = budgets
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(scenario.UpdatedAt))
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(scenario)
+	if err != nil {
+		slog.Error("error encoding scenario response", "error", err)
+	}
+}
+
+// UpdateScenario renames a scenario or changes its range, dropping its budgets outside of the new range. Its budgets
+// are changed one at a time through SetScenarioBudget and DeleteScenarioBudget.
+func (h *Handler) UpdateScenario(w http.ResponseWriter, r *http.Request) {
+	scenario, ok := h.getScenario(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := decodeMergePatch(r.Body, &scenario, "budgets")
+	if err != nil {
+		slog.Error("error decoding update scenario request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	if scenario.Name == "" {
+		slog.Error("error validating update scenario request", "error", errScenarioName)
+		buildErrorResponse(w, errScenarioName.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	from, to, err := parseMonthRange(scenario.From, scenario.To)
+	if err != nil {
+		slog.Error("error parsing scenario range", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	scenario.UpdatedAt = time.Now()
+
+	err = h.writeIfMatch(r, []versionQuery{lockVersion(queryGetScenarioVersion, scenario.ID)},
+		func(db dbExecutor) error {
+			result, err := db.Exec(r.Context(), queryUpdateScenario, scenario.ID, scenario.Name, scenario.Notes, from,
+				to, scenario.UpdatedAt)
+			if err != nil {
+				return err //nolint: wrapcheck
+			}
+
+			if result.RowsAffected() == 0 {
+				return pgx.ErrNoRows
+			}
+
+			_, err = db.Exec(r.Context(), queryTrimScenarioBudgets, scenario.ID, from, to)
+
+			return err //nolint: wrapcheck
+		})
+	if err != nil {
+		slog.Error("error updating scenario in database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) DeleteScenario(w http.ResponseWriter, r *http.Request) {
+	scenarioID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		slog.Error("error parsing scenario id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	err = h.writeIfMatch(r, []versionQuery{lockVersion(queryGetScenarioVersion, scenarioID)},
+		func(db dbExecutor) error {
+			_, err := db.Exec(r.Context(), queryDeleteScenario, scenarioID)
+
+			return err //nolint: wrapcheck
+		})
+	if err != nil {
+		slog.Error("error deleting scenario from database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetScenarioBudget sets the amount a scenario budgets for a category in one of its months.
+func (h *Handler) SetScenarioBudget(w http.ResponseWriter, r *http.Request) { //nolint: funlen
+	scenario, ok := h.getScenario(w, r)
+	if !ok {
+		return
+	}
+
+	var budget ScenarioBudget
+
+	err := json.NewDecoder(r.Body).Decode(&budget)
+	if err != nil {
+		slog.Error("error decoding set scenario budget request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	month := fmt.Sprintf("%04d-%02d", budget.Year, budget.Month)
+	if budget.Month < 1 || budget.Month > 12 || month < scenario.From || month > scenario.To {
+		slog.Error("error validating scenario budget month", "error", errScenarioMonth)
+		buildErrorResponse(w, errScenarioMonth.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var count int
+
+	err = h.db.QueryRow(r.Context(), queryCountBudgetCategories, []uuid.UUID{budget.CategoryID}).Scan(&count)
+	if err != nil {
+		slog.Error("error getting scenario budget category from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	if count != 1 {
+		slog.Error("error validating scenario budget category", "error", errScenarioCategory)
+		buildErrorResponse(w, errScenarioCategory.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		slog.Error("error creating scenario budget id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	now := time.Now()
+
+	err = h.writeIfMatch(r, []versionQuery{lockVersion(queryGetScenarioVersion, scenario.ID)},
+		func(db dbExecutor) error {
+			_, err := db.Exec(r.Context(), querySetScenarioBudget, id, scenario.ID, budget.CategoryID, budget.Year,
+				budget.Month, budget.Budgeted, now)
+			if err != nil {
+				return err //nolint: wrapcheck
+			}
+
+			return touchScenario(r.Context(), db, scenario.ID, now)
+		})
+	if err != nil {
+		slog.Error("error setting scenario budget in database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(budget)
+	if err != nil {
+		slog.Error("error encoding scenario budget response", "error", err)
+	}
+}
+
+// DeleteScenarioBudget drops the amount a scenario budgets for a category in the month given by the year and month
+// query parameters.
+func (h *Handler) DeleteScenarioBudget(w http.ResponseWriter, r *http.Request) { //nolint: funlen
+	scenario, ok := h.getScenario(w, r)
+	if !ok {
+		return
+	}
+
+	categoryID, err := uuid.Parse(r.PathValue("categoryId"))
+	if err != nil {
+		slog.Error("error parsing category id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	year, err := strconv.Atoi(r.URL.Query().Get("year"))
+	if err != nil {
+		slog.Error("error converting year to int", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	month, err := strconv.Atoi(r.URL.Query().Get("month"))
+	if err != nil {
+		slog.Error("error converting month to int", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	err = h.writeIfMatch(r, []versionQuery{lockVersion(queryGetScenarioVersion, scenario.ID)},
+		func(db dbExecutor) error {
+			result, err := db.Exec(r.Context(), queryDeleteScenarioBudget, scenario.ID, categoryID, year, month)
+			if err != nil {
+				return err //nolint: wrapcheck
+			}
+
+			if result.RowsAffected() == 0 {
+				return pgx.ErrNoRows
+			}
+
+			return touchScenario(r.Context(), db, scenario.ID, time.Now())
+		})
+	if err != nil {
+		slog.Error("error deleting scenario budget from database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CompareScenario compares, for every category and month of a scenario, the scenario amount with the real budgeted
+// and spent amounts.
+func (h *Handler) CompareScenario(w http.ResponseWriter, r *http.Request) { //nolint: funlen
+	scenario, ok := h.getScenario(w, r)
+	if !ok {
+		return
+	}
+
+	budgets, err := h.getScenarioBudgets(r.Context(), scenario.ID)
+	if err != nil {
+		slog.Error("error getting scenario budgets from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	amounts := map[scenarioKey]float64{}
+	for _, budget := range budgets {
+		amounts[scenarioKey{budget.CategoryID, budgetMonth(int(budget.Year), int(budget.Month))}] = budget.Budgeted
+	}
+
+	from, _ := time.Parse(rangeMonthLayout, scenario.From)
+	to, _ := time.Parse(rangeMonthLayout, scenario.To)
+
+	rows, err := h.db.Query(r.Context(), queryGetBudgetRange, from.Year(), int(from.Month()), to.Year(),
+		int(to.Month()))
+	if err != nil {
+		slog.Error("error getting budget range from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+	defer rows.Close()
+
+	months := budgetMonth(to.Year(), int(to.Month())) - budgetMonth(from.Year(), int(from.Month())) + 1
+	result := ScenarioComparison{
+		ScenarioID: scenario.ID,
+		Name:       scenario.Name,
+		From:       scenario.From,
+		To:         scenario.To,
+		Months:     newComparisonMonths(from, months),
+		Categories: []ComparisonCategory{},
+	}
+
+	for rows.Next() {
+		var (
+			category ComparisonCategory
+			month    ComparisonMonth
+		)
+
+		err := rows.Scan(&category.GroupID, &category.GroupName, &category.CategoryID, &category.CategoryName,
+			&month.Year, &month.Month, &month.Budgeted, &month.Spent)
+		if err != nil {
+			slog.Error("error scanning budget range row from database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		if len(result.Categories) == 0 || result.Categories[len(result.Categories)-1].CategoryID != category.CategoryID {
+			result.Categories = append(result.Categories, category)
+		}
+
+		month.Scenario = amounts[scenarioKey{category.CategoryID, budgetMonth(month.Year, month.Month)}]
+		month.Difference = month.Scenario - month.Budgeted
+
+		last := &result.Categories[len(result.Categories)-1]
+		last.Months = append(last.Months, month)
+		last.Totals.add(month.ComparisonAmounts)
+
+		index := budgetMonth(month.Year, month.Month) - budgetMonth(from.Year(), int(from.Month()))
+		result.Months[index].add(month.ComparisonAmounts)
+		result.Totals.add(month.ComparisonAmounts)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error reading budget range rows from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		slog.Error("error encoding scenario comparison response", "error", err)
+	}
+}
+
+// ApplyScenario copies the amounts of a scenario into the real budgets of its months. With If-Match, it must list
+// the current ETag of every month the scenario changes.
+func (h *Handler) ApplyScenario(w http.ResponseWriter, r *http.Request) {
+	scenario, ok := h.getScenario(w, r)
+	if !ok {
+		return
+	}
+
+	scenarioBudgets, err := h.getScenarioBudgets(r.Context(), scenario.ID)
+	if err != nil {
+		slog.Error("error getting scenario budgets from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	budgets := make([]Budget, len(scenarioBudgets))
+	versions := []versionQuery{}
+
+	for i, budget := range scenarioBudgets {
+		budgets[i] = Budget{
+			CategoryID: budget.CategoryID, Year: budget.Year, Month: budget.Month, Budgeted: budget.Budgeted,
+		}
+
+		// Scenario budgets come sorted by month, so each month is locked once.
+		if i == 0 || budget.Year != scenarioBudgets[i-1].Year || budget.Month != scenarioBudgets[i-1].Month {
+			versions = append(versions, lockVersion(queryGetBudgetVersion, budget.Year, budget.Month))
+		}
+	}
+
+	err = h.writeIfMatch(r, versions, func(db dbExecutor) error {
+		return setBudgets(r.Context(), db, budgets)
+	})
+	if err != nil {
+		slog.Error("error applying scenario in database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getScenario gets the scenario of the request path, writing the error response when it cannot.
+func (h *Handler) getScenario(w http.ResponseWriter, r *http.Request) (Scenario, bool) {
+	var scenario Scenario
+
+	scenarioID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		slog.Error("error parsing scenario id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return scenario, false
+	}
+
+	err = scanScenario(h.db.QueryRow(r.Context(), queryGetScenario, scenarioID), &scenario)
+	if err != nil {
+		slog.Error("error getting scenario from database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return scenario, false
+	}
+
+	return scenario, true
+}
+
+func (h *Handler) getScenarioBudgets(ctx context.Context, scenarioID uuid.UUID) ([]ScenarioBudget, error) {
+	rows, err := h.db.Query(ctx, queryGetScenarioBudgets, scenarioID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting scenario budgets: %w", err)
+	}
+
+	return collectScenarioBudgets(rows)
+}
+
+// createScenario inserts the scenario and copies into it the real budgets from its first to its last month.
+func (h *Handler) createScenario(ctx context.Context, scenario Scenario, from, to time.Time) ([]ScenarioBudget,
+	error,
+) {
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error creating database txn: %w", err)
+	}
+
+	defer func(ctx context.Context) {
+		if err != nil {
+			rollBackErr := tx.Rollback(ctx)
+			if rollBackErr != nil {
+				slog.Error("error rolling back database txn", "error", rollBackErr)
+			}
+		}
+	}(ctx)
+
+	_, err = tx.Exec(ctx, queryCreateScenario, scenario.ID, scenario.Name, scenario.Notes, from, to,
+		scenario.CreatedAt, scenario.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error inserting scenario: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, queryGetScenarioStart, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error getting budgets: %w", err)
+	}
+
+	budgets, err := collectScenarioBudgets(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, budget := range budgets {
+		var id uuid.UUID
+
+		id, err = uuid.NewV7()
+		if err != nil {
+			return nil, fmt.Errorf("error creating scenario budget id: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, querySetScenarioBudget, id, scenario.ID, budget.CategoryID, budget.Year, budget.Month,
+			budget.Budgeted, scenario.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error inserting scenario budget: %w", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error committing database txn: %w", err)
+	}
+
+	return budgets, nil
+}
+
+// touchScenario sets the version of a scenario, so its ETag changes along with its budgets.
+func touchScenario(ctx context.Context, db dbExecutor, scenarioID uuid.UUID, now time.Time) error {
+	_, err := db.Exec(ctx, queryTouchScenario, scenarioID, now)
+	if err != nil {
+		return fmt.Errorf("error updating scenario version: %w", err)
+	}
+
+	return nil
+}
+
+func collectScenarioBudgets(rows pgx.Rows) ([]ScenarioBudget, error) {
+	defer rows.Close()
+
+	budgets := []ScenarioBudget{}
+
+	for rows.Next() {
+		var budget ScenarioBudget
+
+		err := rows.Scan(&budget.CategoryID, &budget.Year, &budget.Month, &budget.Budgeted)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning scenario budget: %w", err)
+		}
+
+		budgets = append(budgets, budget)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading scenario budgets: %w", err)
+	}
+
+	return budgets, nil
+}
+
+func scanScenario(row pgx.Row, scenario *Scenario) error {
+	var from, to time.Time
+
+	err := row.Scan(&scenario.ID, &scenario.Name, &scenario.Notes, &from, &to, &scenario.CreatedAt,
+		&scenario.UpdatedAt)
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	scenario.From = from.Format(rangeMonthLayout)
+	scenario.To = to.Format(rangeMonthLayout)
+
+	return nil
+}
+
+// newComparisonMonths returns the given number of months starting from a month, with zero amounts.
+func newComparisonMonths(from time.Time, months int) []ComparisonMonth {
+	result := make([]ComparisonMonth, months)
+
+	for i, month := range newRangeMonths(from, months) {
+		result[i] = ComparisonMonth{Year: month.Year, Month: month.Month}
+	}
+
+	return result
+}
+
+func (a *ComparisonAmounts) add(amounts ComparisonAmounts) {
+	a.Scenario += amounts.Scenario
+	a.Budgeted += amounts.Budgeted
+	a.Spent += amounts.Spent
+	a.Difference += amounts.Difference
+}
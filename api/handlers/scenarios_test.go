@@ -0,0 +1,472 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+var (
+	testScenarioID      = uuid.MustParse("01927f36-44b8-7e62-a7a9-395eacab5631")
+	testScenarioFrom    = time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC)
+	testScenarioTo      = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	testScenarioBody    = `{"name":"New job","from":"2024-12","to":"2025-01"}`
+	testScenarioPath    = "/v1/scenarios/" + testScenarioID.String()
+	scenarioRowCols     = []string{"id", "name", "notes", "from_month", "to_month", "created_at", "updated_at"}
+	scenarioBudgetCols  = []string{"category_id", "year", "month", "budgeted"}
+	testScenarioRowFunc = func() *pgxmock.Rows {
+		return pgxmock.NewRows(scenarioRowCols).AddRow(testScenarioID, "New job", (*string)(nil), testScenarioFrom,
+			testScenarioTo, testAccountTime, testAccountTime)
+	}
+)
+
+func TestCreateScenario(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodPost, "/v1/scenarios", false, strings.NewReader(testScenarioBody),
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad request", http.MethodPost, "/v1/scenarios", true, strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusBadRequest, "invalid character",
+		},
+		{
+			"error due to empty name", http.MethodPost, "/v1/scenarios", true,
+			strings.NewReader(strings.Replace(testScenarioBody, "New job", "", 1)),
+			nil, nil,
+			http.StatusBadRequest, "name must not be empty",
+		},
+		{
+			"error due to reversed range", http.MethodPost, "/v1/scenarios", true,
+			strings.NewReader(`{"name":"New job","from":"2025-01","to":"2024-12"}`),
+			nil, nil,
+			http.StatusBadRequest, "range must go from an earlier month",
+		},
+		{
+			"error copying budgets in db", http.MethodPost, "/v1/scenarios", true, strings.NewReader(testScenarioBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO scenarios").WithArgs(pgxmock.AnyArg(), "New job", (*string)(nil),
+					testScenarioFrom, testScenarioTo, pgxmock.AnyArg(), pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectQuery("FROM budgets").WithArgs(testScenarioFrom, testScenarioTo).
+					WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success creating scenario", http.MethodPost, "/v1/scenarios", true, strings.NewReader(testScenarioBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO scenarios").WithArgs(pgxmock.AnyArg(), "New job", (*string)(nil),
+					testScenarioFrom, testScenarioTo, pgxmock.AnyArg(), pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectQuery("FROM budgets").WithArgs(testScenarioFrom, testScenarioTo).WillReturnRows(
+					pgxmock.NewRows(scenarioBudgetCols).AddRow(testCategoryID, uint16(2024), uint8(12), 100.0))
+				mock.ExpectExec("INSERT INTO scenario_budgets").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(),
+					testCategoryID, uint16(2024), uint8(12), 100.0, pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusCreated, `"budgets":[{"categoryId":"` + testCategoryID.String() +
+				`","year":2024,"month":12,"budgeted":100}]`,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestGetScenarios(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, "/v1/scenarios", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error reading scenarios from db", http.MethodGet, "/v1/scenarios", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WillReturnRows(
+					pgxmock.NewRows(scenarioRowCols).RowError(0, errors.New("some error in db")))
+			},
+			http.StatusInternalServerError, "some error in db",
+		},
+		{
+			"success getting scenarios", http.MethodGet, "/v1/scenarios", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WillReturnRows(testScenarioRowFunc())
+			},
+			http.StatusOK, `"name":"New job","notes":null,"from":"2024-12","to":"2025-01"`,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestGetScenario(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to bad id", http.MethodGet, "/v1/scenarios/NA", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error due to missing scenario", http.MethodGet, testScenarioPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusNotFound, "no rows",
+		},
+		{
+			"success getting scenario", http.MethodGet, testScenarioPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+				mock.ExpectQuery("FROM scenario_budgets").WithArgs(testScenarioID).WillReturnRows(
+					pgxmock.NewRows(scenarioBudgetCols).AddRow(testCategoryID, uint16(2025), uint8(1), 80.0))
+			},
+			http.StatusOK, `"year":2025,"month":1,"budgeted":80`,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestUpdateScenario(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to missing scenario", http.MethodPatch, testScenarioPath, true, strings.NewReader(`{"name":"x"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusNotFound, "no rows",
+		},
+		{
+			"error due to unknown field", http.MethodPatch, testScenarioPath, true, strings.NewReader(`{"nmae":"x"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+			},
+			http.StatusBadRequest, "unknown field",
+		},
+		{
+			"error due to empty name", http.MethodPatch, testScenarioPath, true, strings.NewReader(`{"name":""}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+			},
+			http.StatusBadRequest, "name must not be empty",
+		},
+		{
+			"error due to reversed range", http.MethodPatch, testScenarioPath, true, strings.NewReader(`{"to":"2024-11"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+			},
+			http.StatusBadRequest, "range",
+		},
+		{
+			"error due to stale if-match", http.MethodPatch, testScenarioPath, true, strings.NewReader(`{"name":"x"}`),
+			http.Header{"If-Match": []string{`"stale"`}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT updated_at FROM scenarios").WithArgs(testScenarioID).
+					WillReturnRows(pgxmock.NewRows([]string{"updated_at"}).AddRow(&testAccountTime))
+				mock.ExpectRollback()
+			},
+			http.StatusPreconditionFailed, "resource has been modified",
+		},
+		{
+			"success updating scenario", http.MethodPatch, testScenarioPath, true,
+			strings.NewReader(`{"name":"Old job","to":"2024-12"}`),
+			http.Header{"If-Match": []string{etag(testAccountTime)}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT updated_at FROM scenarios").WithArgs(testScenarioID).
+					WillReturnRows(pgxmock.NewRows([]string{"updated_at"}).AddRow(&testAccountTime))
+				mock.ExpectExec("UPDATE scenarios SET name").WithArgs(testScenarioID, "Old job", (*string)(nil),
+					testScenarioFrom, testScenarioFrom, pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectExec("DELETE FROM scenario_budgets").WithArgs(testScenarioID, testScenarioFrom,
+					testScenarioFrom).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusNoContent, "",
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestDeleteScenario(t *testing.T) {
+	tests := []testCase{
+		{
+			"error deleting scenario from db", http.MethodDelete, testScenarioPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM scenarios").WithArgs(testScenarioID).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error due to stale if-match", http.MethodDelete, testScenarioPath, true, nil,
+			http.Header{"If-Match": []string{`"stale"`}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT updated_at FROM scenarios").WithArgs(testScenarioID).
+					WillReturnRows(pgxmock.NewRows([]string{"updated_at"}).AddRow(&testAccountTime))
+				mock.ExpectRollback()
+			},
+			http.StatusPreconditionFailed, "resource has been modified",
+		},
+		{
+			"success deleting scenario", http.MethodDelete, testScenarioPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM scenarios").WithArgs(testScenarioID).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusNoContent, "",
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestSetScenarioBudget(t *testing.T) {
+	body := `{"categoryId":"` + testCategoryID.String() + `","year":2025,"month":1,"budgeted":120}`
+
+	tests := []testCase{
+		{
+			"error due to bad request", http.MethodPut, testScenarioPath + "/budgets", true,
+			strings.NewReader("invalid-body"),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+			},
+			http.StatusBadRequest, "invalid character",
+		},
+		{
+			"error due to month out of range", http.MethodPut, testScenarioPath + "/budgets", true,
+			strings.NewReader(strings.Replace(body, `"month":1`, `"month":2`, 1)),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+			},
+			http.StatusBadRequest, "month must be within the range",
+		},
+		{
+			"error due to income category", http.MethodPut, testScenarioPath + "/budgets", true,
+			strings.NewReader(body),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+				mock.ExpectQuery("SELECT COUNT").WithArgs([]uuid.UUID{testCategoryID}).
+					WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(0))
+			},
+			http.StatusBadRequest, "category must exist",
+		},
+		{
+			"success setting scenario budget", http.MethodPut, testScenarioPath + "/budgets", true,
+			strings.NewReader(body),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+				mock.ExpectQuery("SELECT COUNT").WithArgs([]uuid.UUID{testCategoryID}).
+					WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO scenario_budgets").WithArgs(pgxmock.AnyArg(), testScenarioID,
+					testCategoryID, uint16(2025), uint8(1), 120.0, pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectExec("UPDATE scenarios SET updated_at").WithArgs(testScenarioID, pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusOK, `"budgeted":120`,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestDeleteScenarioBudget(t *testing.T) {
+	path := testScenarioPath + "/budgets/" + testCategoryID.String()
+
+	tests := []testCase{
+		{
+			"error due to bad category id", http.MethodDelete, testScenarioPath + "/budgets/NA?year=2025&month=1", true,
+			nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+			},
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error due to bad month", http.MethodDelete, path + "?year=2025&month=NA", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+			},
+			http.StatusBadRequest, "invalid syntax",
+		},
+		{
+			"error due to missing scenario budget", http.MethodDelete, path + "?year=2025&month=1", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM scenario_budgets").WithArgs(testScenarioID, testCategoryID, 2025, 1).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				mock.ExpectRollback()
+			},
+			http.StatusNotFound, "no rows",
+		},
+		{
+			"success deleting scenario budget", http.MethodDelete, path + "?year=2025&month=1", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM scenario_budgets").WithArgs(testScenarioID, testCategoryID, 2025, 1).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectExec("UPDATE scenarios SET updated_at").WithArgs(testScenarioID, pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusNoContent, "",
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestCompareScenario(t *testing.T) {
+	tests := []testCase{
+		{
+			"error getting budget range from db", http.MethodGet, testScenarioPath + "/compare", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+				mock.ExpectQuery("FROM scenario_budgets").WithArgs(testScenarioID).WillReturnRows(
+					pgxmock.NewRows(scenarioBudgetCols))
+				mock.ExpectQuery("generate_series").WithArgs(2024, 12, 2025, 1).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success comparing scenario", http.MethodGet, testScenarioPath + "/compare", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+				mock.ExpectQuery("FROM scenario_budgets").WithArgs(testScenarioID).WillReturnRows(
+					pgxmock.NewRows(scenarioBudgetCols).
+						AddRow(testCategoryID, uint16(2024), uint8(12), 150.0).
+						AddRow(testCategoryID, uint16(2025), uint8(1), 60.0))
+				mock.ExpectQuery("generate_series").WithArgs(2024, 12, 2025, 1).WillReturnRows(
+					pgxmock.NewRows(budgetRangeRowCols).
+						AddRow(testGroupID, testGroupName, testCategoryID, testCategoryName, 2024, 12, 100.0, -80.0).
+						AddRow(testGroupID, testGroupName, testCategoryID, testCategoryName, 2025, 1, 50.0, -20.0))
+			},
+			http.StatusOK, `"months":[{"year":2024,"month":12,"scenario":150,"budgeted":100,"spent":-80,` +
+				`"difference":50},{"year":2025,"month":1,"scenario":60,"budgeted":50,"spent":-20,"difference":10}],` +
+				`"totals":{"scenario":210,"budgeted":150,"spent":-100,"difference":60}`,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestApplyScenario(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to missing scenario", http.MethodPost, testScenarioPath + "/apply", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusNotFound, "no rows",
+		},
+		{
+			"error setting budgets in db", http.MethodPost, testScenarioPath + "/apply", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+				mock.ExpectQuery("FROM scenario_budgets").WithArgs(testScenarioID).WillReturnRows(
+					pgxmock.NewRows(scenarioBudgetCols).AddRow(testCategoryID, uint16(2024), uint8(12), 150.0))
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO budgets").WithArgs(pgxmock.AnyArg(), testCategoryID, uint16(2024),
+					uint8(12), 150.0, pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error due to stale if-match for one month", http.MethodPost, testScenarioPath + "/apply", true, nil,
+			http.Header{"If-Match": []string{etag(testAccountTime)}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+				mock.ExpectQuery("FROM scenario_budgets").WithArgs(testScenarioID).WillReturnRows(
+					pgxmock.NewRows(scenarioBudgetCols).AddRow(testCategoryID, uint16(2024), uint8(12), 150.0).
+						AddRow(testGroupID, uint16(2024), uint8(12), 50.0).
+						AddRow(testCategoryID, uint16(2025), uint8(1), 150.0))
+				later := testAccountTime.Add(time.Hour)
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT MAX").WithArgs(uint16(2024), uint8(12)).WillReturnRows(
+					pgxmock.NewRows([]string{"max"}).AddRow(&testAccountTime))
+				mock.ExpectQuery("SELECT MAX").WithArgs(uint16(2025), uint8(1)).WillReturnRows(
+					pgxmock.NewRows([]string{"max"}).AddRow(&later))
+				mock.ExpectRollback()
+			},
+			http.StatusPreconditionFailed, "resource has been modified",
+		},
+		{
+			"success applying scenario with matching if-match", http.MethodPost, testScenarioPath + "/apply", true, nil,
+			http.Header{"If-Match": []string{etag(testAccountTime) + ", " + etag(time.Time{})}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+				mock.ExpectQuery("FROM scenario_budgets").WithArgs(testScenarioID).WillReturnRows(
+					pgxmock.NewRows(scenarioBudgetCols).AddRow(testCategoryID, uint16(2024), uint8(12), 150.0).
+						AddRow(testCategoryID, uint16(2025), uint8(1), 150.0))
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT MAX").WithArgs(uint16(2024), uint8(12)).WillReturnRows(
+					pgxmock.NewRows([]string{"max"}).AddRow(&testAccountTime))
+				mock.ExpectQuery("SELECT MAX").WithArgs(uint16(2025), uint8(1)).WillReturnRows(
+					pgxmock.NewRows([]string{"max"}).AddRow(nil))
+				mock.ExpectExec("INSERT INTO budgets").WithArgs(pgxmock.AnyArg(), testCategoryID, uint16(2024),
+					uint8(12), 150.0, pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectExec("INSERT INTO budgets").WithArgs(pgxmock.AnyArg(), testCategoryID, uint16(2025),
+					uint8(1), 150.0, pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusNoContent, "",
+		},
+		{
+			"success applying scenario", http.MethodPost, testScenarioPath + "/apply", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("FROM scenarios").WithArgs(testScenarioID).WillReturnRows(testScenarioRowFunc())
+				mock.ExpectQuery("FROM scenario_budgets").WithArgs(testScenarioID).WillReturnRows(
+					pgxmock.NewRows(scenarioBudgetCols).AddRow(testCategoryID, uint16(2024), uint8(12), 150.0))
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO budgets").WithArgs(pgxmock.AnyArg(), testCategoryID, uint16(2024),
+					uint8(12), 150.0, pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusNoContent, "",
+		},
+	}
+	executeTests(t, tests)
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+	"vitta/config"
+	"vitta/database"
+
+	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Scheduler materializes recurring transactions as uncleared transactions ahead of their dates.
+type Scheduler struct {
+	cfg *config.Config
+	db  database.DBIface
+}
+
+const (
+	// queryGetDueRecurringTransactions leaves out the templates of accounts in the trash.
+	queryGetDueRecurringTransactions = `SELECT * FROM recurring_transactions WHERE next_date <= $1 AND account_id IN` +
+		` (SELECT id FROM accounts WHERE deleted_at IS NULL)`
+	// queryScheduleTransaction links an instance to its template, doing nothing when the date was already scheduled.
+	queryScheduleTransaction = `INSERT INTO scheduled_transactions (transaction_id, recurring_id, scheduled_on)` +
+		` VALUES ($1, $2, $3) ON CONFLICT (recurring_id, scheduled_on) DO NOTHING`
+	queryAdvanceRecurringTransaction = `UPDATE recurring_transactions SET next_date=$2 WHERE id=$1`
+)
+
+func NewScheduler(cfg *config.Config, db database.DBIface) *Scheduler {
+	return &Scheduler{cfg: cfg, db: db}
+}
+
+// Run materializes the recurring transactions due within the schedule horizon every interval, until the context is
+// done.
+func (s *Scheduler) Run(ctx context.Context) {
+	ctx = database.WithAudit(ctx, database.Audit{Actor: "scheduler"})
+
+	ticker := time.NewTicker(s.cfg.SchedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		created, err := s.materialize(ctx, time.Now().Add(s.cfg.SchedulerHorizon))
+		if err != nil {
+			slog.Error("error materializing recurring transactions", "error", err)
+		} else if created > 0 {
+			slog.Info("materialized recurring transactions", "created", created)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// materialize creates the instances of all recurring transactions due until the given time, returning how many
+// transactions were created.
+func (s *Scheduler) materialize(ctx context.Context, until time.Time) (int, error) {
+	rows, err := s.db.Query(ctx, queryGetDueRecurringTransactions, dateOf(until))
+	if err != nil {
+		return 0, fmt.Errorf("error getting due recurring transactions: %w", err)
+	}
+	defer rows.Close()
+
+	due := []RecurringTransaction{}
+
+	for rows.Next() {
+		var recurring RecurringTransaction
+
+		err := scanRecurringTransaction(rows, &recurring)
+		if err != nil {
+			return 0, fmt.Errorf("error scanning recurring transactions row: %w", err)
+		}
+
+		due = append(due, recurring)
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error reading recurring transactions rows: %w", err)
+	}
+
+	created := 0
+
+	for _, recurring := range due {
+		count, err := s.materializeRecurring(ctx, recurring, dateOf(until))
+		if err != nil {
+			slog.Error("error materializing recurring transaction", "error", err, "recurring", recurring.ID)
+
+			continue
+		}
+
+		created += count
+	}
+
+	return created, nil
+}
+
+// materializeRecurring creates the instances of a recurring transaction up to until and advances its next date.
+func (s *Scheduler) materializeRecurring(ctx context.Context, recurring RecurringTransaction, //nolint: cyclop
+	until time.Time,
+) (int, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error creating database txn: %w", err)
+	}
+
+	defer func(ctx context.Context) {
+		if err != nil {
+			rollBackErr := tx.Rollback(ctx)
+			if rollBackErr != nil {
+				slog.Error("error rolling back database txn", "error", rollBackErr)
+			}
+		}
+	}(ctx)
+
+	created := 0
+	now := time.Now()
+
+	for recurring.NextDate != nil && !recurring.NextDate.After(until) {
+		var (
+			transactionID uuid.UUID
+			result        pgconn.CommandTag
+		)
+
+		transactionID, err = uuid.NewV7()
+		if err != nil {
+			return 0, fmt.Errorf("error creating transaction id: %w", err)
+		}
+
+		result, err = tx.Exec(ctx, queryScheduleTransaction, transactionID, recurring.ID, *recurring.NextDate)
+		if err != nil {
+			return 0, fmt.Errorf("error scheduling transaction: %w", err)
+		}
+
+		if result.RowsAffected() > 0 {
+			_, err = tx.Exec(ctx, queryCreateTransaction, transactionID, recurring.AccountID, recurring.CategoryID,
+				recurring.PayeeID, recurring.Credit, recurring.Debit, recurring.Name, recurring.Notes, *recurring.NextDate,
+				statusUncleared, nil, now, now)
+			if err != nil {
+				return 0, fmt.Errorf("error creating scheduled transaction: %w", err)
+			}
+
+			created++
+		}
+
+		recurring.NextDate = recurring.nextOccurrence(recurring.NextDate.AddDate(0, 0, 1))
+	}
+
+	_, err = tx.Exec(ctx, queryAdvanceRecurringTransaction, recurring.ID, recurring.NextDate)
+	if err != nil {
+		return 0, fmt.Errorf("error advancing recurring transaction: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error committing database txn: %w", err)
+	}
+
+	return created, nil
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+	"vitta/config"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerMaterialize(t *testing.T) {
+	until := time.Date(2024, 11, 10, 0, 0, 0, 0, time.UTC)
+	nextDate := time.Date(2024, 12, 5, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name            string
+		mockDBFunc      func(pgxmock.PgxPoolIface)
+		expectedCreated int
+		expectedErr     string
+	}{
+		{
+			"error getting due recurring transactions",
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM recurring_transactions").WithArgs(until).WillReturnError(pgx.ErrTxClosed)
+			},
+			0, "tx is closed",
+		},
+		{
+			"skipping recurring transaction failing to schedule",
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM recurring_transactions").WithArgs(until).WillReturnRows(recurringRows())
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO scheduled_transactions").WithArgs(pgxmock.AnyArg(), testRecurringID,
+					testRecurringStart).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			0, "",
+		},
+		{
+			"success skipping already scheduled dates",
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM recurring_transactions").WithArgs(until).WillReturnRows(recurringRows())
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO scheduled_transactions").WithArgs(pgxmock.AnyArg(), testRecurringID,
+					testRecurringStart).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectExec("INSERT INTO transactions").WithArgs(pgxmock.AnyArg(), testAccountID, testNullID, testNullID,
+					0.0, 25000.0, "Rent", "", testRecurringStart, statusUncleared, nil, pgxmock.AnyArg(),
+					pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectExec("INSERT INTO scheduled_transactions").WithArgs(pgxmock.AnyArg(), testRecurringID,
+					time.Date(2024, 11, 5, 0, 0, 0, 0, time.UTC)).WillReturnResult(pgxmock.NewResult("INSERT", 0))
+				mock.ExpectExec("UPDATE recurring_transactions SET next_date").WithArgs(testRecurringID, &nextDate).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+			1, "",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockDB.Close()
+
+			tc.mockDBFunc(mockDB)
+
+			created, err := NewScheduler(&config.Config{}, mockDB).materialize(context.TODO(), until)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.expectedCreated, created)
+			require.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+
+	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Split model.
+type Split struct {
+	ID            uuid.UUID  `json:"id"`
+	TransactionID uuid.UUID  `json:"transactionId"`
+	CategoryID    *uuid.UUID `json:"categoryId,omitempty"`
+	CategoryName  *string    `json:"categoryName,omitempty"`
+	PayeeID       *uuid.UUID `json:"payeeId,omitempty"`
+	PayeeName     *string    `json:"payeeName,omitempty"`
+	Credit        float64    `json:"credit,omitempty"`
+	Debit         float64    `json:"debit,omitempty"`
+	Notes         string     `json:"notes,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// splitAmountTolerance is the allowed difference between split lines and their transaction.
+const splitAmountTolerance = 0.005
+
+var errSplitAmountMismatch = errors.New("split amounts do not match transaction amounts")
+
+const (
+	queryGetTransactionAmounts = `SELECT credit, debit FROM transactions WHERE account_id=$1 AND id=$2` +
+		` AND deleted_at IS NULL`
+	queryGetSplitAmounts = `SELECT COUNT(*), COALESCE(SUM(credit), 0), COALESCE(SUM(debit), 0) FROM splits` +
+		` WHERE transaction_id=$1`
+	queryCreateSplit = `INSERT INTO splits (id, transaction_id, category_id, payee_id, credit, debit, notes,` +
+		` created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	queryDeleteSplits = `DELETE FROM splits AS s USING transactions AS t WHERE s.transaction_id = t.id AND` +
+		` t.account_id=$1 AND t.id=$2`
+	queryGetSplits = `SELECT s.*, c.name as category_name, p.name as payee_name FROM splits AS s` +
+		` JOIN transactions AS t ON s.transaction_id = t.id LEFT JOIN categories AS c ON s.category_id = c.id` +
+		` LEFT JOIN payees AS p ON s.payee_id = p.id WHERE t.account_id=$1 AND t.id=$2 ORDER BY s.id ASC`
+)
+
+func (h *Handler) SetSplits(w http.ResponseWriter, r *http.Request) { //nolint: funlen,cyclop
+	id := r.PathValue("id")
+	tID := r.PathValue("tId")
+
+	accountID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing account id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	transactionID, err := uuid.Parse(tID)
+	if err != nil {
+		slog.Error("error parsing transaction id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var splits []Split
+
+	err = json.NewDecoder(r.Body).Decode(&splits)
+	if err != nil {
+		slog.Error("error decoding set splits request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var credit, debit float64
+
+	err = h.db.QueryRow(r.Context(), queryGetTransactionAmounts, accountID, transactionID).Scan(&credit, &debit)
+	if errors.Is(err, pgx.ErrNoRows) {
+		slog.Error("error finding transaction for splits", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusNotFound)
+
+		return
+	} else if err != nil {
+		slog.Error("error getting transaction from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	err = validateSplitAmounts(splits, credit, debit)
+	if err != nil {
+		slog.Error("error validating splits", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	splitTime := time.Now()
+
+	for idx := range splits {
+		splits[idx].ID, err = uuid.NewV7()
+		if err != nil {
+			slog.Error("error creating split id", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		splits[idx].TransactionID = transactionID
+		splits[idx].CreatedAt = splitTime
+		splits[idx].UpdatedAt = splitTime
+	}
+
+	err = h.replaceSplits(r.Context(), accountID, transactionID, splits)
+	if err != nil {
+		slog.Error("error setting splits", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(splits)
+	if err != nil {
+		slog.Error("error encoding splits response", "error", err)
+	}
+}
+
+func (h *Handler) DeleteSplits(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	tID := r.PathValue("tId")
+
+	accountID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing account id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	transactionID, err := uuid.Parse(tID)
+	if err != nil {
+		slog.Error("error parsing transaction id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	_, err = h.db.Exec(r.Context(), queryDeleteSplits, accountID, transactionID)
+	if err != nil {
+		slog.Error("error deleting splits in database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) GetSplits(w http.ResponseWriter, r *http.Request) { //nolint: funlen
+	id := r.PathValue("id")
+	tID := r.PathValue("tId")
+
+	accountID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing account id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	transactionID, err := uuid.Parse(tID)
+	if err != nil {
+		slog.Error("error parsing transaction id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	rows, err := h.db.Query(r.Context(), queryGetSplits, accountID, transactionID)
+	if err != nil {
+		slog.Error("error getting splits from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+	defer rows.Close()
+
+	splits := []Split{}
+
+	for rows.Next() {
+		var split Split
+
+		err := rows.Scan(&split.ID, &split.TransactionID, &split.CategoryID, &split.PayeeID, &split.Credit,
+			&split.Debit, &split.Notes, &split.CreatedAt, &split.UpdatedAt, &split.CategoryName, &split.PayeeName)
+		if err != nil {
+			slog.Error("error scanning splits row from database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		splits = append(splits, split)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error reading splits rows from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(splits)
+	if err != nil {
+		slog.Error("error encoding splits response", "error", err)
+	}
+}
+
+func (h *Handler) replaceSplits(ctx context.Context, accountID, transactionID uuid.UUID, splits []Split) error {
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		slog.Error("error creating database txn", "error", err)
+
+		return fmt.Errorf("error creating database txn: %w", err)
+	}
+
+	defer func(ctx context.Context) {
+		if err != nil {
+			rollBackErr := tx.Rollback(ctx)
+			if rollBackErr != nil {
+				slog.Error("error rolling back database txn", "error", rollBackErr)
+			}
+		}
+	}(ctx)
+
+	_, err = tx.Exec(ctx, queryDeleteSplits, accountID, transactionID)
+	if err != nil {
+		slog.Error("error deleting splits", "error", err)
+
+		return fmt.Errorf("error deleting splits: %w", err)
+	}
+
+	for _, split := range splits {
+		_, err = tx.Exec(ctx, queryCreateSplit, split.ID, split.TransactionID, split.CategoryID, split.PayeeID,
+			split.Credit, split.Debit, split.Notes, split.CreatedAt, split.UpdatedAt)
+		if err != nil {
+			slog.Error("error inserting split", "error", err, "split", split)
+
+			return fmt.Errorf("error inserting split: %w", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		slog.Error("error committing database txn", "error", err)
+
+		return fmt.Errorf("error committing database txn: %w", err)
+	}
+
+	return nil
+}
+
+// validateSplitAmounts checks that split lines add up to the credit and debit of their transaction.
+func validateSplitAmounts(splits []Split, credit, debit float64) error {
+	var splitCredit, splitDebit float64
+
+	for _, split := range splits {
+		if split.Credit < 0 || split.Debit < 0 {
+			return fmt.Errorf("%w: amounts must not be negative", errSplitAmountMismatch)
+		}
+
+		splitCredit += split.Credit
+		splitDebit += split.Debit
+	}
+
+	if math.Abs(splitCredit-credit) > splitAmountTolerance || math.Abs(splitDebit-debit) > splitAmountTolerance {
+		return fmt.Errorf("%w: expected credit %.2f and debit %.2f, got credit %.2f and debit %.2f",
+			errSplitAmountMismatch, credit, debit, splitCredit, splitDebit)
+	}
+
+	return nil
+}
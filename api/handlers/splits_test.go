@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testSplitID  = uuid.MustParse("01927f3e-6ecf-7091-987f-8aa23addda10")
+	splitRowCols = []string{"id", "transaction_id", "category_id", "payee_id", "credit", "debit", "notes",
+		"created_at", "updated_at", "category_name", "payee_name"}
+	splitAmountCols       = []string{"count", "credit", "debit"}
+	transactionAmountCols = []string{"credit", "debit"}
+	testSplitsPath        = "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String() + "/splits"
+	testSplitsBody        = `[{"categoryId":"` + testCategoryID.String() + `","debit":3.00,"notes":"Food"},` +
+		`{"payeeId":"` + testPayeeID.String() + `","debit":1.20,"notes":"Gift"}]`
+)
+
+func TestSetSplits(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodPut, testSplitsPath, false, strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to invalid account id", http.MethodPut, "/v1/accounts/invalid-account-id/transactions/" + testTransactionID.String() + "/splits", true,
+			strings.NewReader("invalid-body"), nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error due to bad transaction id", http.MethodPut, "/v1/accounts/" + testAccountID.String() + "/transactions/invalid-uuid/splits", true,
+			strings.NewReader("invalid-body"), nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error due to bad request", http.MethodPut, testSplitsPath, true, strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusBadRequest, "invalid character",
+		},
+		{
+			"error due to transaction not found", http.MethodPut, testSplitsPath, true, strings.NewReader(testSplitsBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT credit, debit").WithArgs(testAccountID, testTransactionID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusNotFound, "no rows",
+		},
+		{
+			"error getting transaction from database", http.MethodPut, testSplitsPath, true, strings.NewReader(testSplitsBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT credit, debit").WithArgs(testAccountID, testTransactionID).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error due to amounts not matching", http.MethodPut, testSplitsPath, true, strings.NewReader(testSplitsBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT credit, debit").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionAmountCols).AddRow(0.0, 5.0))
+			},
+			http.StatusBadRequest, "split amounts do not match",
+		},
+		{
+			"error deleting existing splits", http.MethodPut, testSplitsPath, true, strings.NewReader(testSplitsBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT credit, debit").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionAmountCols).AddRow(0.0, 4.20))
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM splits").WithArgs(testAccountID, testTransactionID).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "error deleting splits",
+		},
+		{
+			"error inserting split", http.MethodPut, testSplitsPath, true, strings.NewReader(testSplitsBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT credit, debit").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionAmountCols).AddRow(0.0, 4.20))
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM splits").WithArgs(testAccountID, testTransactionID).WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				mock.ExpectExec("INSERT INTO splits").WithArgs(pgxmock.AnyArg(), testTransactionID, &testCategoryID, testNullID,
+					0.0, 3.00, "Food", pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "error inserting split",
+		},
+		{
+			"success setting splits", http.MethodPut, testSplitsPath, true, strings.NewReader(testSplitsBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT credit, debit").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionAmountCols).AddRow(0.0, 4.20))
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM splits").WithArgs(testAccountID, testTransactionID).WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				mock.ExpectExec("INSERT INTO splits").WithArgs(pgxmock.AnyArg(), testTransactionID, &testCategoryID, testNullID,
+					0.0, 3.00, "Food", pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectExec("INSERT INTO splits").WithArgs(pgxmock.AnyArg(), testTransactionID, testNullID, &testPayeeID,
+					0.0, 1.20, "Gift", pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusOK, testTransactionID.String(),
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestDeleteSplits(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodDelete, testSplitsPath, false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad transaction id", http.MethodDelete, "/v1/accounts/" + testAccountID.String() + "/transactions/invalid-uuid/splits", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error deleting splits in database", http.MethodDelete, testSplitsPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("DELETE FROM splits").WithArgs(testAccountID, testTransactionID).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success deleting splits", http.MethodDelete, testSplitsPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("DELETE FROM splits").WithArgs(testAccountID, testTransactionID).WillReturnResult(pgxmock.NewResult("DELETE", 2))
+			},
+			http.StatusNoContent, "",
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestGetSplits(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, testSplitsPath, false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to invalid account id", http.MethodGet, "/v1/accounts/invalid-account-id/transactions/" + testTransactionID.String() + "/splits", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error getting splits from db", http.MethodGet, testSplitsPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT *").WithArgs(testAccountID, testTransactionID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusInternalServerError, "no rows",
+		},
+		{
+			"error scanning splits rows from db", http.MethodGet, testSplitsPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT *").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(splitRowCols).AddRow("invalid", "invalid",
+					"invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid"))
+			},
+			http.StatusInternalServerError, "Scanning value error",
+		},
+		{
+			"error reading splits rows from db", http.MethodGet, testSplitsPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT *").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(splitRowCols).RowError(0, errors.New("some error in db")))
+			},
+			http.StatusInternalServerError, "some error in db",
+		},
+		{
+			"success", http.MethodGet, testSplitsPath, true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT *").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(splitRowCols).AddRow(testSplitID,
+					testTransactionID, &testCategoryID, &testPayeeID, 0.0, 4.20, "Food", testAccountTime, testAccountTime,
+					&testCategoryName, &testPayeeName))
+			},
+			http.StatusOK, testSplitID.String(),
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestValidateSplitAmounts(t *testing.T) {
+	tests := []struct {
+		name        string
+		splits      []Split
+		credit      float64
+		debit       float64
+		errContains string
+	}{
+		{"matching amounts", []Split{{Debit: 3.10}, {Debit: 1.10}}, 0, 4.20, ""},
+		{"mismatched debit", []Split{{Debit: 3.10}}, 0, 4.20, "split amounts do not match"},
+		{"mismatched credit", []Split{{Credit: 1}, {Debit: 4.20}}, 0, 4.20, "split amounts do not match"},
+		{"negative amount", []Split{{Debit: -1}, {Debit: 5.20}}, 0, 4.20, "must not be negative"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateSplitAmounts(tc.splits, tc.credit, tc.debit)
+			if len(tc.errContains) > 0 {
+				assert.ErrorContains(t, err, tc.errContains)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	uuid "github.com/google/uuid"
+)
+
+type (
+	// Tag model.
+	Tag struct {
+		ID        uuid.UUID `json:"id"`
+		Name      string    `json:"name"`
+		CreatedAt time.Time `json:"createdAt"`
+		UpdatedAt time.Time `json:"updatedAt"`
+	}
+
+	// TagSummary model.
+	TagSummary struct {
+		ID           uuid.UUID `json:"id"`
+		Name         string    `json:"name"`
+		Credit       float64   `json:"credit"`
+		Debit        float64   `json:"debit"`
+		Total        float64   `json:"total"`
+		Transactions int       `json:"transactions"`
+	}
+)
+
+const (
+	queryCreateTag    = `INSERT INTO tags (id, name, created_at, updated_at) VALUES ($1, $2, $3, $4)`
+	queryUpdateTag    = `UPDATE tags SET name=$1, updated_at=$2 WHERE id=$3`
+	queryDeleteTag    = `DELETE FROM tags WHERE id=$1`
+	queryGetTotalTags = `SELECT COUNT(*) as total FROM tags WHERE (name ILIKE '%' ||` +
+		` COALESCE(NULLIF($1, ''), '') || '%')`
+	queryGetTags = `SELECT * FROM tags WHERE (name ILIKE '%' || COALESCE(NULLIF($1, ''), '')` +
+		` || '%') ORDER BY name ASC`
+	queryGetTagSummary = `SELECT tg.id, tg.name, COALESCE(SUM(t.credit), 0) AS credit, COALESCE(SUM(t.debit), 0)` +
+		` AS debit, COUNT(t.id) AS transactions FROM tags AS tg LEFT JOIN transaction_tags AS tt ON tt.tag_id = tg.id` +
+		` LEFT JOIN transactions AS t ON t.id = tt.transaction_id AND t.deleted_at IS NULL AND t.date >= $1` +
+		` AND t.date < $2` +
+		` GROUP BY tg.id ORDER BY tg.name ASC`
+	// querySetTransactionTags replaces the tags of a transaction, creating tags that do not exist yet.
+	querySetTransactionTags = `WITH removed AS (DELETE FROM transaction_tags WHERE transaction_id=$1 AND tag_id` +
+		` NOT IN (SELECT id FROM tags WHERE name = ANY($3::text[]))), created AS (INSERT INTO tags (id, name,` +
+		` created_at, updated_at) SELECT n.id, n.name, $4, $4 FROM unnest($2::uuid[], $3::text[]) AS n(id, name)` +
+		` ON CONFLICT (name) DO NOTHING RETURNING id) INSERT INTO transaction_tags (transaction_id, tag_id)` +
+		` SELECT $1, id FROM created UNION SELECT $1, id FROM tags WHERE name = ANY($3::text[]) ON CONFLICT DO NOTHING`
+	// queryAddTransactionTags adds tags to a transaction, creating tags that do not exist yet.
+	queryAddTransactionTags = `WITH created AS (INSERT INTO tags (id, name, created_at, updated_at) SELECT n.id,` +
+		` n.name, $4, $4 FROM unnest($2::uuid[], $3::text[]) AS n(id, name) ON CONFLICT (name) DO NOTHING` +
+		` RETURNING id) INSERT INTO transaction_tags (transaction_id, tag_id) SELECT $1, id FROM created UNION` +
+		` SELECT $1, id FROM tags WHERE name = ANY($3::text[]) ON CONFLICT DO NOTHING`
+	queryRemoveTransactionTags = `DELETE FROM transaction_tags WHERE transaction_id=$1 AND tag_id IN` +
+		` (SELECT id FROM tags WHERE name = ANY($2::text[]))`
+	// queryTransactionTags aggregates the tag names of transaction t.
+	queryTransactionTags = `COALESCE((SELECT array_agg(tg.name ORDER BY tg.name) FROM transaction_tags AS tt JOIN` +
+		` tags AS tg ON tt.tag_id = tg.id WHERE tt.transaction_id = t.id), '{}') AS tags`
+	// queryTransactionTagsFilter matches transaction t against tag names in $3 using the mode in $4.
+	queryTransactionTagsFilter = `(cardinality($3::text[]) = 0 OR (SELECT COUNT(*) FROM transaction_tags AS tt` +
+		` JOIN tags AS tg ON tt.tag_id = tg.id WHERE tt.transaction_id = t.id AND tg.name = ANY($3::text[])) >=` +
+		` CASE WHEN $4 = 'all' THEN cardinality($3::text[]) ELSE 1 END)`
+)
+
+func (h *Handler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	var tag Tag
+
+	err := json.NewDecoder(r.Body).Decode(&tag)
+	if err != nil {
+		slog.Error("error decoding create tag request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	tag.ID, err = uuid.NewV7()
+	if err != nil {
+		slog.Error("error creating tag id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	tag.Name = strings.TrimSpace(tag.Name)
+	tag.CreatedAt = time.Now()
+	tag.UpdatedAt = tag.CreatedAt
+
+	_, err = h.db.Exec(r.Context(), queryCreateTag, tag.ID, tag.Name, tag.CreatedAt, tag.UpdatedAt)
+	if err != nil {
+		slog.Error("error creating tag in database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	err = json.NewEncoder(w).Encode(tag)
+	if err != nil {
+		slog.Error("error encoding tag response", "error", err)
+	}
+}
+
+func (h *Handler) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	tagID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing tag id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var tag Tag
+
+	err = json.NewDecoder(r.Body).Decode(&tag)
+	if err != nil {
+		slog.Error("error decoding update tag request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	tag.Name = strings.TrimSpace(tag.Name)
+	tag.UpdatedAt = time.Now()
+
+	_, err = h.db.Exec(r.Context(), queryUpdateTag, tag.Name, tag.UpdatedAt, tagID)
+	if err != nil {
+		slog.Error("error updating tag in database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	tagID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing tag id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	_, err = h.db.Exec(r.Context(), queryDeleteTag, tagID)
+	if err != nil {
+		slog.Error("error deleting tag in database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) GetTags(w http.ResponseWriter, r *http.Request) { //nolint: funlen
+	searchQuery := r.URL.Query().Get("q")
+
+	rows, err := h.db.Query(r.Context(), queryGetTotalTags, searchQuery)
+	if err != nil {
+		slog.Error("error getting total tags from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+	defer rows.Close()
+
+	var total int
+
+	for rows.Next() {
+		err = rows.Scan(&total)
+		if err != nil {
+			slog.Error("error scanning total tags row from database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+	}
+
+	rows, err = h.db.Query(r.Context(), queryGetTags, searchQuery)
+	if err != nil {
+		slog.Error("error getting tags from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+	defer rows.Close()
+
+	tags := []Tag{}
+
+	for rows.Next() {
+		var tag Tag
+
+		err := rows.Scan(&tag.ID, &tag.Name, &tag.CreatedAt, &tag.UpdatedAt)
+		if err != nil {
+			slog.Error("error scanning tags row from database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error reading tags rows from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(map[string]interface{}{"total": total, "tags": tags})
+	if err != nil {
+		slog.Error("error encoding tags response", "error", err)
+	}
+}
+
+func (h *Handler) GetTagSummary(w http.ResponseWriter, r *http.Request) { //nolint: funlen
+	from, err := time.Parse(time.DateOnly, r.URL.Query().Get("from"))
+	if err != nil {
+		slog.Error("error parsing from date", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	to, err := time.Parse(time.DateOnly, r.URL.Query().Get("to"))
+	if err != nil {
+		slog.Error("error parsing to date", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	rows, err := h.db.Query(r.Context(), queryGetTagSummary, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		slog.Error("error getting tag summary from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+	defer rows.Close()
+
+	summaries := []TagSummary{}
+
+	for rows.Next() {
+		var summary TagSummary
+
+		err := rows.Scan(&summary.ID, &summary.Name, &summary.Credit, &summary.Debit, &summary.Transactions)
+		if err != nil {
+			slog.Error("error scanning tag summary row from database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		summary.Total = summary.Credit - summary.Debit
+
+		summaries = append(summaries, summary)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error reading tag summary rows from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(summaries)
+	if err != nil {
+		slog.Error("error encoding tag summary response", "error", err)
+	}
+}
+
+func setTransactionTags(ctx context.Context, db dbExecutor, transactionID uuid.UUID, tags []string) error {
+	tagIDs, err := newTagIDs(len(tags))
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(ctx, querySetTransactionTags, transactionID, tagIDs, tags, time.Now())
+	if err != nil {
+		slog.Error("error setting transaction tags", "error", err)
+
+		return fmt.Errorf("error setting transaction tags: %w", err)
+	}
+
+	return nil
+}
+
+func addTransactionTags(ctx context.Context, db dbExecutor, transactionID uuid.UUID, tags []string) error {
+	tagIDs, err := newTagIDs(len(tags))
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(ctx, queryAddTransactionTags, transactionID, tagIDs, tags, time.Now())
+	if err != nil {
+		slog.Error("error adding transaction tags", "error", err)
+
+		return fmt.Errorf("error adding transaction tags: %w", err)
+	}
+
+	return nil
+}
+
+func removeTransactionTags(ctx context.Context, db dbExecutor, transactionID uuid.UUID, tags []string) error {
+	_, err := db.Exec(ctx, queryRemoveTransactionTags, transactionID, tags)
+	if err != nil {
+		slog.Error("error removing transaction tags", "error", err)
+
+		return fmt.Errorf("error removing transaction tags: %w", err)
+	}
+
+	return nil
+}
+
+// newTagIDs creates ids for tags that may not exist yet.
+func newTagIDs(count int) ([]uuid.UUID, error) {
+	tagIDs := make([]uuid.UUID, count)
+
+	for idx := range tagIDs {
+		tagID, err := uuid.NewV7()
+		if err != nil {
+			return nil, fmt.Errorf("error creating tag id: %w", err)
+		}
+
+		tagIDs[idx] = tagID
+	}
+
+	return tagIDs, nil
+}
+
+// parseTags trims, deduplicates and drops empty tag names.
+func parseTags(tags []string) []string {
+	parsed := []string{}
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(parsed, tag) {
+			parsed = append(parsed, tag)
+		}
+	}
+
+	return parsed
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testTagName       = "vacation-2026"
+	testTagID         = uuid.MustParse("01927f3e-6ecf-7091-987f-8aa23adcda12")
+	tagRowCols        = []string{"id", "name", "created_at", "updated_at"}
+	tagSummaryRowCols = []string{"id", "name", "credit", "debit", "transactions"}
+)
+
+func TestCreateTag(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodPost, "/v1/tags", false, strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad request", http.MethodPost, "/v1/tags", true, strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusBadRequest, "invalid character",
+		},
+		{
+			"error inserting tag to database", http.MethodPost, "/v1/tags", true,
+			strings.NewReader(`{"name":" ` + testTagName + ` "}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("INSERT INTO tags").WithArgs(pgxmock.AnyArg(), testTagName,
+					pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success creating tag", http.MethodPost, "/v1/tags", true,
+			strings.NewReader(`{"name":" ` + testTagName + ` "}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("INSERT INTO tags").WithArgs(pgxmock.AnyArg(), testTagName,
+					pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			},
+			http.StatusCreated, testTagName,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestUpdateTag(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodPatch, "/v1/tags/invalid-uuid", false, strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad tag id", http.MethodPatch, "/v1/tags/invalid-uuid", true, strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error due to bad request", http.MethodPatch, "/v1/tags/" + testTagID.String(), true, strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusBadRequest, "invalid character",
+		},
+		{
+			"error updating tag in database", http.MethodPatch, "/v1/tags/" + testTagID.String(), true,
+			strings.NewReader(`{"name":"` + testTagName + `"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("UPDATE tags").WithArgs(testTagName, pgxmock.AnyArg(), testTagID).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success updating tag", http.MethodPatch, "/v1/tags/" + testTagID.String(), true,
+			strings.NewReader(`{"name":"` + testTagName + `"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("UPDATE tags").WithArgs(testTagName, pgxmock.AnyArg(), testTagID).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			http.StatusNoContent, "",
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestDeleteTag(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodDelete, "/v1/tags/invalid-uuid", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad tag id", http.MethodDelete, "/v1/tags/invalid-uuid", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error deleting tag in database", http.MethodDelete, "/v1/tags/" + testTagID.String(), true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("DELETE FROM tags").WithArgs(testTagID).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success deleting tag", http.MethodDelete, "/v1/tags/" + testTagID.String(), true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec("DELETE FROM tags").WithArgs(testTagID).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			},
+			http.StatusNoContent, "",
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestGetTags(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, "/v1/tags", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error getting total tags from db", http.MethodGet, "/v1/tags?q=vac", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT *").WithArgs("vac").WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusInternalServerError, "no rows",
+		},
+		{
+			"error scanning total tags rows from db", http.MethodGet, "/v1/tags?q=vac", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT *").WithArgs("vac").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(""))
+			},
+			http.StatusInternalServerError, "not supported",
+		},
+		{
+			"error getting tags from db", http.MethodGet, "/v1/tags?q=vac", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT *").WithArgs("vac").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
+				mock.ExpectQuery("SELECT *").WithArgs("vac").WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusInternalServerError, "no rows",
+		},
+		{
+			"error scanning tags rows from db", http.MethodGet, "/v1/tags?q=vac", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT *").WithArgs("vac").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
+				mock.ExpectQuery("SELECT *").WithArgs("vac").WillReturnRows(pgxmock.NewRows(tagRowCols).AddRow("invalid", "invalid", "invalid", "invalid"))
+			},
+			http.StatusInternalServerError, "Scanning value error",
+		},
+		{
+			"error reading tags rows from db", http.MethodGet, "/v1/tags?q=vac", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT *").WithArgs("vac").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
+				mock.ExpectQuery("SELECT *").WithArgs("vac").WillReturnRows(pgxmock.NewRows(tagRowCols).RowError(0, errors.New("some error in db")))
+			},
+			http.StatusInternalServerError, "some error in db",
+		},
+		{
+			"success", http.MethodGet, "/v1/tags?q=vac", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT *").WithArgs("vac").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
+				mock.ExpectQuery("SELECT *").WithArgs("vac").WillReturnRows(pgxmock.NewRows(tagRowCols).AddRow(testTagID, testTagName, testAccountTime, testAccountTime))
+			},
+			http.StatusOK, testTagName,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestGetTagSummary(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, "/v1/tags/summary", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to invalid from date", http.MethodGet, "/v1/tags/summary?from=invalid&to=2026-12-31", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "cannot parse",
+		},
+		{
+			"error due to invalid to date", http.MethodGet, "/v1/tags/summary?from=2026-01-01&to=invalid", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "cannot parse",
+		},
+		{
+			"error getting tag summary from db", http.MethodGet, "/v1/tags/summary?from=2026-01-01&to=2026-12-31", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT tg.id").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusInternalServerError, "no rows",
+		},
+		{
+			"error scanning tag summary rows from db", http.MethodGet, "/v1/tags/summary?from=2026-01-01&to=2026-12-31", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT tg.id").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(pgxmock.NewRows(tagSummaryRowCols).
+					AddRow("invalid", "invalid", "invalid", "invalid", "invalid"))
+			},
+			http.StatusInternalServerError, "Scanning value error",
+		},
+		{
+			"error reading tag summary rows from db", http.MethodGet, "/v1/tags/summary?from=2026-01-01&to=2026-12-31", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT tg.id").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(pgxmock.NewRows(tagSummaryRowCols).
+					RowError(0, errors.New("some error in db")))
+			},
+			http.StatusInternalServerError, "some error in db",
+		},
+		{
+			"success", http.MethodGet, "/v1/tags/summary?from=2026-01-01&to=2026-12-31", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT tg.id").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(pgxmock.NewRows(tagSummaryRowCols).
+					AddRow(testTagID, testTagName, 10.0, 4.0, 3))
+			},
+			http.StatusOK, `"total":6,"transactions":3`,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestParseTags(t *testing.T) {
+	assert.Equal(t, []string{}, parseTags([]string{""}))
+	assert.Equal(t, []string{"vacation", "tax-deductible"}, parseTags([]string{" vacation", "tax-deductible", "vacation ", ""}))
+}
@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	uuid "github.com/google/uuid"
+)
+
+// transactionFilter holds the filters and sorting of a transactions listing.
+type transactionFilter struct {
+	Query         string
+	Tags          []string
+	TagMode       string
+	From          *time.Time
+	To            *time.Time
+	MinAmount     *float64
+	MaxAmount     *float64
+	Type          string
+	CategoryID    *uuid.UUID
+	Uncategorized bool
+	PayeeID       *uuid.UUID
+	Cleared       *bool
+	Sort          string
+	Order         string
+	Cursor        *transactionCursor
+	Total         bool
+}
+
+// transactionCursor is the position after which the next page of transactions sorted by date starts.
+type transactionCursor struct {
+	Date *time.Time `json:"d"`
+	ID   uuid.UUID  `json:"id"`
+}
+
+const (
+	transactionTypeCredit = "credit"
+	transactionTypeDebit  = "debit"
+	uncategorized         = "uncategorized"
+	sortAsc               = "asc"
+	sortDesc              = "desc"
+	tagModeAny            = "any"
+	tagModeAll            = "all"
+)
+
+var (
+	errInvalidTransactionType = errors.New("type must be credit or debit")
+	errInvalidTagMode         = errors.New("tagMode must be any or all")
+	errInvalidSort            = errors.New("sort must be date, amount, payee or category")
+	errInvalidOrder           = errors.New("order must be asc or desc")
+	errInvalidCursorSort      = errors.New("cursor can only be used when sorting by date")
+	errInvalidPage            = errors.New("page must be a positive number")
+
+	// transactionSortColumns maps the sort parameter to the column used in ORDER BY.
+	transactionSortColumns = map[string]string{
+		"date":     "t.date",
+		"amount":   "ABS(t.credit - t.debit)",
+		"payee":    "p.name",
+		"category": "c.name",
+	}
+)
+
+// queryTransactionsFilter restricts transactions t outside of the trash to the accounts in $1 (all when empty) and the
+// filters in $2 to $13.
+const queryTransactionsFilter = ` WHERE t.deleted_at IS NULL` +
+	` AND (cardinality($1::uuid[]) = 0 OR t.account_id = ANY($1::uuid[]))` +
+	` AND ((t.name ILIKE '%' || COALESCE(NULLIF($2, ''), '') || '%')` +
+	` OR (t.notes ILIKE '%' || COALESCE(NULLIF($2, ''), '') || '%')) AND ` + queryTransactionTagsFilter +
+	` AND ($5::date IS NULL OR t.date >= $5) AND ($6::date IS NULL OR t.date < $6)` +
+	` AND ($7::float8 IS NULL OR ABS(t.credit - t.debit) >= $7)` +
+	` AND ($8::float8 IS NULL OR ABS(t.credit - t.debit) <= $8)` +
+	` AND ($9 = '' OR ($9 = 'credit' AND t.credit > 0) OR ($9 = 'debit' AND t.debit > 0))` +
+	` AND ($10::uuid IS NULL OR t.category_id = $10 OR EXISTS (SELECT 1 FROM splits AS s` +
+	` WHERE s.transaction_id = t.id AND s.category_id = $10))` +
+	` AND (NOT $11 OR (t.category_id IS NULL AND NOT EXISTS (SELECT 1 FROM splits AS s` +
+	` WHERE s.transaction_id = t.id AND s.category_id IS NOT NULL)))` +
+	` AND ($12::uuid IS NULL OR t.payee_id = $12) AND ($13::boolean IS NULL OR (t.status <> 'uncleared') = $13)`
+
+const (
+	queryTransactionsPage = ` OFFSET $14 LIMIT $15`
+	// queryTransactionsAfter and queryTransactionsBefore seek past the cursor in $14 and $15 when sorting by date.
+	queryTransactionsAfter       = ` AND (t.date, t.id) > ($14::date, $15::uuid)`
+	queryTransactionsBefore      = ` AND (t.date, t.id) < ($14::date, $15::uuid)`
+	queryTransactionsCursorLimit = ` LIMIT $16`
+)
+
+func parseTransactionFilter(values url.Values) (transactionFilter, error) { //nolint: funlen,cyclop
+	filter := transactionFilter{
+		Query:   values.Get("q"),
+		Tags:    parseTags(strings.Split(values.Get("tags"), ",")),
+		TagMode: values.Get("tagMode"),
+		Type:    values.Get("type"),
+		Sort:    values.Get("sort"),
+		Order:   strings.ToLower(values.Get("order")),
+	}
+
+	var err error
+
+	filter.From, err = parseFilterDate(values, "from")
+	if err != nil {
+		return filter, err
+	}
+
+	filter.To, err = parseFilterDate(values, "to")
+	if err != nil {
+		return filter, err
+	}
+
+	if filter.To != nil {
+		to := filter.To.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	filter.MinAmount, err = parseFilterAmount(values, "minAmount")
+	if err != nil {
+		return filter, err
+	}
+
+	filter.MaxAmount, err = parseFilterAmount(values, "maxAmount")
+	if err != nil {
+		return filter, err
+	}
+
+	if filter.Type != "" && filter.Type != transactionTypeCredit && filter.Type != transactionTypeDebit {
+		return filter, errInvalidTransactionType
+	}
+
+	if filter.TagMode != "" && filter.TagMode != tagModeAny && filter.TagMode != tagModeAll {
+		return filter, errInvalidTagMode
+	}
+
+	if category := values.Get("categoryId"); category == uncategorized {
+		filter.Uncategorized = true
+	} else if category != "" {
+		categoryID, err := uuid.Parse(category)
+		if err != nil {
+			return filter, fmt.Errorf("error parsing category id: %w", err)
+		}
+
+		filter.CategoryID = &categoryID
+	}
+
+	if payee := values.Get("payeeId"); payee != "" {
+		payeeID, err := uuid.Parse(payee)
+		if err != nil {
+			return filter, fmt.Errorf("error parsing payee id: %w", err)
+		}
+
+		filter.PayeeID = &payeeID
+	}
+
+	if cleared := values.Get("cleared"); cleared != "" {
+		isCleared, err := strconv.ParseBool(cleared)
+		if err != nil {
+			return filter, fmt.Errorf("error parsing cleared: %w", err)
+		}
+
+		filter.Cleared = &isCleared
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = "date"
+	}
+
+	if _, ok := transactionSortColumns[filter.Sort]; !ok {
+		return filter, errInvalidSort
+	}
+
+	if filter.Order == "" {
+		filter.Order = sortDesc
+	}
+
+	if filter.Order != sortAsc && filter.Order != sortDesc {
+		return filter, errInvalidOrder
+	}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		filter.Cursor, err = decodeTransactionCursor(cursor)
+		if err != nil {
+			return filter, err
+		}
+
+		if filter.Sort != "date" {
+			return filter, errInvalidCursorSort
+		}
+	}
+
+	filter.Total = filter.Cursor == nil
+
+	if total := values.Get("total"); total != "" {
+		filter.Total, err = strconv.ParseBool(total)
+		if err != nil {
+			return filter, fmt.Errorf("error parsing total: %w", err)
+		}
+	}
+
+	return filter, nil
+}
+
+// args returns the query arguments $2 to $13 of queryTransactionsFilter.
+func (f transactionFilter) args() []any {
+	args := []any{f.Query, f.Tags, f.TagMode, nil, nil, nil, nil, f.Type, nil, f.Uncategorized, nil, nil}
+
+	if f.From != nil {
+		args[3] = *f.From
+	}
+
+	if f.To != nil {
+		args[4] = *f.To
+	}
+
+	if f.MinAmount != nil {
+		args[5] = *f.MinAmount
+	}
+
+	if f.MaxAmount != nil {
+		args[6] = *f.MaxAmount
+	}
+
+	if f.CategoryID != nil {
+		args[8] = *f.CategoryID
+	}
+
+	if f.PayeeID != nil {
+		args[10] = *f.PayeeID
+	}
+
+	if f.Cleared != nil {
+		args[11] = *f.Cleared
+	}
+
+	return args
+}
+
+// narrows reports whether the filter leaves out any transaction, regardless of its sorting and paging.
+func (f transactionFilter) narrows() bool {
+	return f.Query != "" || len(f.Tags) > 0 || f.From != nil || f.To != nil || f.MinAmount != nil ||
+		f.MaxAmount != nil || f.Type != "" || f.CategoryID != nil || f.Uncategorized || f.PayeeID != nil ||
+		f.Cleared != nil
+}
+
+// orderBy returns the ORDER BY clause for the sorting of the filter, using the id as tie breaker.
+func (f transactionFilter) orderBy() string {
+	direction := strings.ToUpper(f.Order)
+
+	return ` ORDER BY ` + transactionSortColumns[f.Sort] + ` ` + direction + ` NULLS LAST, t.id ` + direction
+}
+
+// page returns the query suffix and its arguments selecting limit rows from the offset, or from the cursor when one
+// is set.
+func (f transactionFilter) page(offset, limit int) (string, []any) {
+	if f.Cursor == nil {
+		return f.orderBy() + queryTransactionsPage, []any{offset, limit}
+	}
+
+	var date any
+	if f.Cursor.Date != nil {
+		date = *f.Cursor.Date
+	}
+
+	seek := queryTransactionsBefore
+	if f.Order == sortAsc {
+		seek = queryTransactionsAfter
+	}
+
+	return seek + f.orderBy() + queryTransactionsCursorLimit, []any{date, f.Cursor.ID, limit}
+}
+
+func (c transactionCursor) encode() string {
+	cursor, _ := json.Marshal(c) //nolint: errchkjson
+
+	return base64.RawURLEncoding.EncodeToString(cursor)
+}
+
+func decodeTransactionCursor(value string) (*transactionCursor, error) {
+	content, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing cursor: %w", err)
+	}
+
+	var cursor transactionCursor
+
+	err = json.Unmarshal(content, &cursor)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing cursor: %w", err)
+	}
+
+	return &cursor, nil
+}
+
+// parseAccountIDs parses a comma separated list of account ids.
+func parseAccountIDs(value string) ([]uuid.UUID, error) {
+	accountIDs := []uuid.UUID{}
+
+	for _, id := range splitList(value) {
+		accountID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing account id: %w", err)
+		}
+
+		accountIDs = append(accountIDs, accountID)
+	}
+
+	return accountIDs, nil
+}
+
+// splitList returns the non-empty items of a comma-separated query parameter.
+func splitList(value string) []string {
+	items := []string{}
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// parsePage returns the zero-based index of a page numbered from 1, the first page when it is not given.
+func parsePage(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	page, err := strconv.Atoi(value)
+	if err != nil || page < 1 {
+		return 0, errInvalidPage
+	}
+
+	return page - 1, nil
+}
+
+func parseFilterDate(values url.Values, key string) (*time.Time, error) {
+	if values.Get(key) == "" {
+		return nil, nil //nolint: nilnil
+	}
+
+	date, err := time.Parse(time.DateOnly, values.Get(key))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s date: %w", key, err)
+	}
+
+	return &date, nil
+}
+
+func parseFilterAmount(values url.Values, key string) (*float64, error) {
+	if values.Get(key) == "" {
+		return nil, nil //nolint: nilnil
+	}
+
+	amount, err := strconv.ParseFloat(values.Get(key), 64)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", key, err)
+	}
+
+	return &amount, nil
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTransactionFilter(t *testing.T) {
+	filter, err := parseTransactionFilter(url.Values{})
+	require.NoError(t, err)
+	assert.Equal(t, " ORDER BY t.date DESC NULLS LAST, t.id DESC", filter.orderBy())
+
+	filter, err = parseTransactionFilter(url.Values{"sort": {"payee"}, "order": {"ASC"}})
+	require.NoError(t, err)
+	assert.Equal(t, " ORDER BY p.name ASC NULLS LAST, t.id ASC", filter.orderBy())
+
+	filter, err = parseTransactionFilter(url.Values{"categoryId": {testCategoryID.String()}, "cleared": {"false"}})
+	require.NoError(t, err)
+	assert.Equal(t, &testCategoryID, filter.CategoryID)
+	assert.False(t, filter.Uncategorized)
+	assert.Equal(t, []any{"", []string{}, "", nil, nil, nil, nil, "", testCategoryID, false, nil, false}, filter.args())
+
+	invalid := []url.Values{
+		{"to": {"2024-13-01"}},
+		{"minAmount": {"ten"}},
+		{"maxAmount": {"ten"}},
+		{"type": {"transfer"}},
+		{"categoryId": {"invalid-uuid"}},
+		{"payeeId": {"invalid-uuid"}},
+		{"cleared": {"maybe"}},
+		{"order": {"sideways"}},
+		{"cursor": {"not a cursor"}},
+		{"cursor": {transactionCursor{ID: testTransactionID}.encode()}, "sort": {"amount"}},
+		{"total": {"sometimes"}},
+	}
+	for _, values := range invalid {
+		_, err = parseTransactionFilter(values)
+		assert.Error(t, err, values)
+	}
+}
+
+func TestTransactionFilterPage(t *testing.T) {
+	filter, err := parseTransactionFilter(url.Values{})
+	require.NoError(t, err)
+	assert.True(t, filter.Total)
+
+	query, args := filter.page(100, 51)
+	assert.Equal(t, filter.orderBy()+queryTransactionsPage, query)
+	assert.Equal(t, []any{100, 51}, args)
+
+	cursorTime := time.Date(2024, 10, 18, 0, 0, 0, 0, time.UTC)
+	cursor := transactionCursor{Date: &cursorTime, ID: testTransactionID}.encode()
+
+	filter, err = parseTransactionFilter(url.Values{"cursor": {cursor}})
+	require.NoError(t, err)
+	assert.False(t, filter.Total)
+
+	query, args = filter.page(100, 51)
+	assert.Equal(t, queryTransactionsBefore+filter.orderBy()+queryTransactionsCursorLimit, query)
+	assert.Equal(t, []any{cursorTime, testTransactionID, 51}, args)
+
+	cursor = transactionCursor{ID: testTransactionID}.encode()
+
+	filter, err = parseTransactionFilter(url.Values{"cursor": {cursor}, "order": {"asc"}, "total": {"true"}})
+	require.NoError(t, err)
+	assert.True(t, filter.Total)
+
+	query, args = filter.page(0, 51)
+	assert.Equal(t, queryTransactionsAfter+filter.orderBy()+queryTransactionsCursorLimit, query)
+	assert.Equal(t, []any{nil, testTransactionID, 51}, args)
+}
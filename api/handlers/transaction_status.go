@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+)
+
+// Transaction statuses. A transaction is uncleared until it shows up on the bank statement, and reconciled once a
+// reconciliation of its account included it.
+const (
+	statusUncleared  = "uncleared"
+	statusCleared    = "cleared"
+	statusReconciled = "reconciled"
+)
+
+var (
+	errTransactionStatus       = errors.New("status must be uncleared or cleared")
+	errTransactionToReconciled = errors.New("status can only become reconciled through a reconciliation")
+)
+
+// transactionClearing validates a status against the previous status of the transaction and returns it along with
+// the cleared date matching it. Without a status, the status follows the cleared date.
+func transactionClearing(status string, clearedAt *time.Time, previous string, now time.Time) (string,
+	*time.Time, error,
+) {
+	if status == "" {
+		status = statusUncleared
+		if clearedAt != nil {
+			status = statusCleared
+		}
+	}
+
+	switch status {
+	case statusUncleared:
+		return status, nil, nil
+	case statusCleared, statusReconciled:
+		if status == statusReconciled && previous != statusReconciled {
+			return "", nil, errTransactionToReconciled
+		}
+
+		if clearedAt == nil {
+			clearedAt = &now
+		}
+
+		return status, clearedAt, nil
+	default:
+		return "", nil, errTransactionStatus
+	}
+}
+
+// transactionDate returns the day of a transaction date, today when it is not set.
+func transactionDate(date time.Time, now time.Time) time.Time {
+	if date.IsZero() {
+		return dateOf(now)
+	}
+
+	return dateOf(date)
+}
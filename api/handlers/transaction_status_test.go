@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionClearing(t *testing.T) {
+	now := time.Date(2024, 10, 18, 10, 30, 0, 0, time.UTC)
+	clearedAt := time.Date(2024, 10, 15, 0, 0, 0, 0, time.UTC)
+
+	status, cleared, err := transactionClearing("", nil, "", now)
+	require.NoError(t, err)
+	assert.Equal(t, statusUncleared, status)
+	assert.Nil(t, cleared)
+
+	status, cleared, err = transactionClearing("", &clearedAt, "", now)
+	require.NoError(t, err)
+	assert.Equal(t, statusCleared, status)
+	assert.Equal(t, &clearedAt, cleared)
+
+	status, cleared, err = transactionClearing(statusCleared, nil, statusUncleared, now)
+	require.NoError(t, err)
+	assert.Equal(t, statusCleared, status)
+	assert.Equal(t, &now, cleared)
+
+	status, cleared, err = transactionClearing(statusUncleared, &clearedAt, statusCleared, now)
+	require.NoError(t, err)
+	assert.Equal(t, statusUncleared, status)
+	assert.Nil(t, cleared)
+
+	status, _, err = transactionClearing(statusReconciled, &clearedAt, statusReconciled, now)
+	require.NoError(t, err)
+	assert.Equal(t, statusReconciled, status)
+
+	_, _, err = transactionClearing(statusReconciled, &clearedAt, statusCleared, now)
+	require.ErrorIs(t, err, errTransactionToReconciled)
+
+	_, _, err = transactionClearing("pending", nil, "", now)
+	require.ErrorIs(t, err, errTransactionStatus)
+}
@@ -8,57 +8,96 @@ import (
 	"log/slog"
 	"mime/multipart"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 	"vitta/adapters"
 
 	"github.com/extrame/xls"
 	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/xuri/excelize/v2"
 )
 
 type (
 	// Transaction model.
 	Transaction struct {
-		ID           uuid.UUID  `json:"id"`
-		AccountID    uuid.UUID  `json:"accountId"`
-		CategoryID   *uuid.UUID `json:"categoryId,omitempty"`
-		CategoryName *string    `json:"categoryName,omitempty"`
-		PayeeID      *uuid.UUID `json:"payeeId,omitempty"`
-		PayeeName    *string    `json:"payeeName,omitempty"`
-		Credit       float64    `json:"credit,omitempty"`
-		Debit        float64    `json:"debit,omitempty"`
-		Name         string     `json:"name"`
-		Notes        string     `json:"notes,omitempty"`
-		ClearedAt    *time.Time `json:"clearedAt,omitempty"`
-		CreatedAt    time.Time  `json:"createdAt"`
-		UpdatedAt    time.Time  `json:"updatedAt"`
+		ID               uuid.UUID  `json:"id"`
+		AccountID        uuid.UUID  `json:"accountId"`
+		AccountName      *string    `json:"accountName,omitempty"`
+		CategoryID       *uuid.UUID `json:"categoryId,omitempty"`
+		CategoryName     *string    `json:"categoryName,omitempty"`
+		PayeeID          *uuid.UUID `json:"payeeId,omitempty"`
+		PayeeName        *string    `json:"payeeName,omitempty"`
+		Credit           float64    `json:"credit,omitempty"`
+		Debit            float64    `json:"debit,omitempty"`
+		Name             string     `json:"name"`
+		Notes            string     `json:"notes,omitempty"`
+		Date             time.Time  `json:"date"`
+		Status           string     `json:"status"`
+		ClearedAt        *time.Time `json:"clearedAt,omitempty"`
+		CreatedAt        time.Time  `json:"createdAt"`
+		UpdatedAt        time.Time  `json:"updatedAt"`
+		DeletedAt        *time.Time `json:"deletedAt,omitempty"`
+		TransferID       *uuid.UUID `json:"transferId,omitempty"`
+		ReconciliationID *uuid.UUID `json:"reconciliationId,omitempty"`
+		Tags             []string   `json:"tags,omitempty"`
 	}
 
 	// TransactionsResult model.
 	TransactionsResult struct {
 		Total    int `json:"total"`
 		Imported int `json:"imported"`
+		// TransferCandidates counts the imported transactions that could be one side of a transfer, to confirm with
+		// LinkTransfer.
+		TransferCandidates int `json:"transferCandidates"`
+		Scheduled          int `json:"scheduled"`
 	}
 )
 
 const (
-	queryCreateTransaction = `INSERT INTO transactions (id, account_id, category_id, payee_id, credit,` +
-		` debit, name, notes, cleared_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
-	queryUpdateTransaction = `UPDATE transactions SET category_id=$2, payee_id=$3,` +
-		` credit=$4, debit=$5, name=$6, notes=$7, cleared_at=$8, updated_at=$9 WHERE account_id=$1 AND id=$10`
-	queryDeleteTransaction    = `DELETE FROM transactions WHERE account_id=$1 AND id=$2`
-	queryGetTotalTransactions = `SELECT COUNT(*) as total FROM transactions WHERE account_id=$1 AND (name ILIKE '%' ||` +
-		` COALESCE(NULLIF($2, ''), '') || '%')`
-	queryGetTransactionsForUsage = `SELECT * FROM transactions`
-	queryGetTransactions         = `SELECT t.*, c.name as category_name, p.name as payee_name FROM transactions AS t` +
-		` LEFT JOIN categories AS c ON t.category_id = c.id LEFT JOIN payees AS p ON t.payee_id = p.id` +
-		` WHERE t.account_id=$1 AND ((t.name ILIKE '%' || COALESCE(NULLIF($2, ''), '') || '%') OR (t.notes ILIKE` +
-		` '%' || COALESCE(NULLIF($2, ''), '') || '%')) ORDER BY t.created_at DESC OFFSET $3 LIMIT $4`
+	queryCreateTransaction = `INSERT INTO transactions (id, account_id, category_id, payee_id, credit, debit, name,` +
+		` notes, date, status, cleared_at, created_at, updated_at)` +
+		` VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+	// queryUpdateTransaction updates a transaction still at version $11, or at any version without one, mirrors
+	// amounts, date and clearing onto the other side of a transfer and returns the number of updated transactions.
+	// Transactions leaving the reconciled status drop their reconciliation.
+	queryUpdateTransaction = `WITH updated AS (UPDATE transactions SET category_id=$2, payee_id=$3,` +
+		` credit=$4, debit=$5, name=$6, notes=$7, cleared_at=$8, updated_at=$9, date=$12, status=$13,` +
+		` reconciliation_id=CASE WHEN $13 = 'reconciled' THEN reconciliation_id END WHERE account_id=$1 AND id=$10` +
+		` AND deleted_at IS NULL AND ($11::timestamp IS NULL OR updated_at IS NOT DISTINCT FROM $11)` +
+		` RETURNING id, transfer_id, credit, debit, date, status, cleared_at, updated_at),` +
+		` mirrored AS (UPDATE transactions AS o SET credit=u.debit, debit=u.credit, date=u.date,` +
+		` status=CASE WHEN u.status = 'uncleared' THEN 'uncleared' WHEN o.reconciliation_id IS NOT NULL` +
+		` THEN 'reconciled' ELSE 'cleared' END, reconciliation_id=CASE WHEN u.status <> 'uncleared'` +
+		` THEN o.reconciliation_id END, cleared_at=u.cleared_at, updated_at=u.updated_at FROM updated AS u` +
+		` WHERE o.transfer_id = u.transfer_id AND o.id <> u.id) SELECT COUNT(*) FROM updated`
+	// queryDeleteTransaction moves a transaction and the other side of its transfer to the trash.
+	queryDeleteTransaction = `UPDATE transactions SET deleted_at=$3 WHERE ((account_id=$1 AND id=$2) OR` +
+		` transfer_id IN (SELECT transfer_id FROM transactions WHERE account_id=$1 AND id=$2)) AND deleted_at IS NULL`
+	queryGetTotalTransactions    = `SELECT COUNT(*) as total FROM transactions AS t` + queryTransactionsFilter
+	queryGetTransactionsForUsage = `SELECT * FROM transactions WHERE deleted_at IS NULL`
+	queryGetTransaction          = `SELECT * FROM transactions WHERE account_id=$1 AND id=$2 AND deleted_at IS NULL`
+	queryGetTransactionVersion   = `SELECT updated_at FROM transactions WHERE account_id=$1 AND id=$2` +
+		` AND deleted_at IS NULL FOR UPDATE`
+	// queryTransactionsWithNames selects transactions t along with their account, category and payee names and tags.
+	queryTransactionsWithNames = `SELECT t.*, a.name as account_name, c.name as category_name,` +
+		` p.name as payee_name, ` + queryTransactionTags + ` FROM transactions AS t LEFT JOIN accounts AS a` +
+		` ON t.account_id = a.id LEFT JOIN categories AS c ON t.category_id = c.id` +
+		` LEFT JOIN payees AS p ON t.payee_id = p.id`
+	queryGetTransactions         = queryTransactionsWithNames + queryTransactionsFilter
+	queryGetTransactionWithNames = queryTransactionsWithNames + ` WHERE t.account_id=$1 AND t.id=$2` +
+		` AND t.deleted_at IS NULL`
+	// queryMatchScheduledTransaction clears the uncleared scheduled instance with the same amounts closest to the
+	// imported date, within $7 days, instead of importing a duplicate.
+	queryMatchScheduledTransaction = `UPDATE transactions SET notes=$5, date=$4, status='cleared', cleared_at=$4,` +
+		` updated_at=$6 WHERE id = (SELECT s.transaction_id FROM scheduled_transactions AS s JOIN transactions AS t` +
+		` ON t.id = s.transaction_id WHERE t.account_id=$1 AND t.status='uncleared' AND t.credit=$2 AND t.debit=$3` +
+		` AND t.deleted_at IS NULL AND ABS(s.scheduled_on - $4::date) <= $7` +
+		` ORDER BY ABS(s.scheduled_on - $4::date), s.scheduled_on LIMIT 1)`
 )
 
-func (h *Handler) CreateTransaction(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CreateTransaction(w http.ResponseWriter, r *http.Request) { //nolint: funlen
 	id := r.PathValue("id")
 
 	accountID, err := uuid.Parse(id)
@@ -90,11 +129,42 @@ func (h *Handler) CreateTransaction(w http.ResponseWriter, r *http.Request) {
 	transaction.AccountID = accountID
 	transaction.CreatedAt = time.Now()
 	transaction.UpdatedAt = transaction.CreatedAt
+	transaction.Date = transactionDate(transaction.Date, transaction.CreatedAt)
 
-	_, err = h.db.Exec(r.Context(), queryCreateTransaction,
+	transaction.Status, transaction.ClearedAt, err = transactionClearing(transaction.Status, transaction.ClearedAt,
+		"", transaction.CreatedAt)
+	if err != nil {
+		slog.Error("error validating transaction status", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	if len(transaction.Tags) > 0 {
+		transaction.Tags = parseTags(transaction.Tags)
+	}
+
+	tx, err := h.db.Begin(r.Context())
+	if err != nil {
+		slog.Error("error creating database txn", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	defer func(ctx context.Context) {
+		if err != nil {
+			rollBackErr := tx.Rollback(ctx)
+			if rollBackErr != nil {
+				slog.Error("error rolling back database txn", "error", rollBackErr)
+			}
+		}
+	}(r.Context())
+
+	_, err = tx.Exec(r.Context(), queryCreateTransaction,
 		transaction.ID, accountID, transaction.CategoryID, transaction.PayeeID,
-		transaction.Credit, transaction.Debit, transaction.Name, transaction.Notes,
-		nil, transaction.CreatedAt, transaction.UpdatedAt)
+		transaction.Credit, transaction.Debit, transaction.Name, transaction.Notes, transaction.Date,
+		transaction.Status, transaction.ClearedAt, transaction.CreatedAt, transaction.UpdatedAt)
 	if err != nil {
 		slog.Error("error creating transaction in database", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -102,6 +172,24 @@ func (h *Handler) CreateTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(transaction.Tags) > 0 {
+		err = setTransactionTags(r.Context(), tx, transaction.ID, transaction.Tags)
+		if err != nil {
+			slog.Error("error setting transaction tags in database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+	}
+
+	err = tx.Commit(r.Context())
+	if err != nil {
+		slog.Error("error committing database txn", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 
@@ -111,7 +199,7 @@ func (h *Handler) CreateTransaction(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *Handler) UpdateTransaction(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdateTransaction(w http.ResponseWriter, r *http.Request) { //nolint: funlen,cyclop
 	id := r.PathValue("id")
 	tID := r.PathValue("tId")
 
@@ -133,7 +221,29 @@ func (h *Handler) UpdateTransaction(w http.ResponseWriter, r *http.Request) {
 
 	var transaction Transaction
 
-	err = json.NewDecoder(r.Body).Decode(&transaction)
+	err = h.db.QueryRow(r.Context(), queryGetTransaction, accountID, transactionID).Scan(&transaction.ID,
+		&transaction.AccountID, &transaction.CategoryID, &transaction.PayeeID, &transaction.Name,
+		&transaction.Credit, &transaction.Debit, &transaction.Notes, &transaction.ClearedAt,
+		&transaction.CreatedAt, &transaction.UpdatedAt, &transaction.TransferID, &transaction.ReconciliationID,
+		&transaction.Date, &transaction.Status, &transaction.DeletedAt)
+	if err != nil {
+		slog.Error("error getting transaction from database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	if !matchesIfMatch(r, transaction.UpdatedAt) {
+		slog.Error("error matching transaction version", "error", errPreconditionFailed)
+		buildErrorResponse(w, errPreconditionFailed.Error(), http.StatusPreconditionFailed)
+
+		return
+	}
+
+	loaded := transaction
+	version := transaction.UpdatedAt
+
+	fields, err := decodeMergePatch(r.Body, &transaction, "accountId")
 	if err != nil {
 		slog.Error("error decoding update transaction request", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
@@ -141,19 +251,92 @@ func (h *Handler) UpdateTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	transaction.UpdatedAt = time.Now()
+	now := time.Now()
+	transaction.Date = transactionDate(transaction.Date, now)
+
+	_, statusSet := fields["status"]
+	if _, clearedAtSet := fields["clearedAt"]; clearedAtSet && !statusSet {
+		transaction.Status = ""
+	}
 
-	_, err = h.db.Exec(r.Context(), queryUpdateTransaction,
-		accountID, transaction.CategoryID, transaction.PayeeID,
-		transaction.Credit, transaction.Debit, transaction.Name, transaction.Notes,
-		transaction.ClearedAt, transaction.UpdatedAt, transactionID)
+	transaction.Status, transaction.ClearedAt, err = transactionClearing(transaction.Status, transaction.ClearedAt,
+		loaded.Status, now)
 	if err != nil {
-		slog.Error("error updating transaction in database", "error", err)
+		slog.Error("error validating transaction status", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	if reconciledFieldsChanged(loaded, transaction) &&
+		!h.checkReconciled(w, r, queryIsTransactionReconciled, accountID, transactionID) {
+		return
+	}
+
+	var (
+		splitCount              int
+		splitCredit, splitDebit float64
+	)
+
+	err = h.db.QueryRow(r.Context(), queryGetSplitAmounts, transactionID).Scan(&splitCount, &splitCredit, &splitDebit)
+	if err != nil {
+		slog.Error("error getting split amounts from database", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
 
 		return
 	}
 
+	if splitCount > 0 {
+		err = validateSplitAmounts([]Split{{Credit: splitCredit, Debit: splitDebit}}, transaction.Credit, transaction.Debit)
+		if err != nil {
+			slog.Error("error validating transaction against splits", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	transaction.UpdatedAt = now
+
+	// The update only has to start from the version the client saw when it sent If-Match.
+	var expected *time.Time
+	if r.Header.Get("If-Match") != "" {
+		expected = &version
+	}
+
+	err = h.writeIfMatch(r, nil, func(db dbExecutor) error {
+		var updated int
+
+		err := db.QueryRow(r.Context(), queryUpdateTransaction,
+			accountID, transaction.CategoryID, transaction.PayeeID,
+			transaction.Credit, transaction.Debit, transaction.Name, transaction.Notes,
+			transaction.ClearedAt, transaction.UpdatedAt, transactionID, expected, transaction.Date,
+			transaction.Status).Scan(&updated)
+		if err != nil {
+			return err //nolint: wrapcheck
+		}
+
+		if updated == 0 && expected != nil {
+			return errPreconditionFailed
+		}
+
+		if updated == 0 {
+			return pgx.ErrNoRows
+		}
+
+		if _, ok := fields["tags"]; !ok {
+			return nil
+		}
+
+		return setTransactionTags(r.Context(), db, transactionID, parseTags(transaction.Tags))
+	})
+	if err != nil {
+		slog.Error("error updating transaction in database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -178,10 +361,19 @@ func (h *Handler) DeleteTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = h.db.Exec(r.Context(), queryDeleteTransaction, accountID, transactionID)
+	if !h.checkReconciled(w, r, queryIsTransactionReconciled, accountID, transactionID) {
+		return
+	}
+
+	err = h.writeIfMatch(r, []versionQuery{lockVersion(queryGetTransactionVersion, accountID, transactionID)},
+		func(db dbExecutor) error {
+			_, err := db.Exec(r.Context(), queryDeleteTransaction, accountID, transactionID, time.Now())
+
+			return err //nolint: wrapcheck
+		})
 	if err != nil {
 		slog.Error("error deleting transaction in database", "error", err)
-		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
 
 		return
 	}
@@ -190,22 +382,22 @@ func (h *Handler) DeleteTransaction(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *Handler) GetTransactions(w http.ResponseWriter, r *http.Request) { //nolint: funlen,cyclop
+func (h *Handler) GetTransactions(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	searchQuery := r.URL.Query().Get("q")
 
-	page := 0
-	limit := 50
+	accountID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing account id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
 
-	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil {
-		page = p - 1
+		return
 	}
 
-	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil {
-		limit = l
-	}
+	h.getTransactions(w, r, []uuid.UUID{accountID})
+}
 
-	accountID, err := uuid.Parse(id)
+func (h *Handler) GetAllTransactions(w http.ResponseWriter, r *http.Request) {
+	accountIDs, err := parseAccountIDs(r.URL.Query().Get("accountIds"))
 	if err != nil {
 		slog.Error("error parsing account id", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
@@ -213,28 +405,50 @@ func (h *Handler) GetTransactions(w http.ResponseWriter, r *http.Request) { //no
 		return
 	}
 
-	rows, err := h.db.Query(r.Context(), queryGetTotalTransactions, accountID, searchQuery)
+	h.getTransactions(w, r, accountIDs)
+}
+
+// getTransactions writes the filtered transactions of the given accounts, or of all accounts when empty.
+func (h *Handler) getTransactions(w http.ResponseWriter, r *http.Request, accountIDs []uuid.UUID) { //nolint: funlen
+	page, err := parsePage(r.URL.Query().Get("page"))
 	if err != nil {
-		slog.Error("error getting total transactions from database", "error", err)
-		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		slog.Error("error parsing transactions page", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
 
 		return
 	}
-	defer rows.Close()
 
-	var total int
+	limit := listLimit(r)
 
-	for rows.Next() {
-		err = rows.Scan(&total)
+	filter, err := parseTransactionFilter(r.URL.Query())
+	if err != nil {
+		slog.Error("error parsing transactions filter", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	args := append([]any{accountIDs}, filter.args()...)
+	result := map[string]interface{}{}
+
+	if filter.Total {
+		var total int
+
+		err = h.db.QueryRow(r.Context(), queryGetTotalTransactions, args...).Scan(&total)
 		if err != nil {
-			slog.Error("error scanning total transactions row from database", "error", err)
+			slog.Error("error getting total transactions from database", "error", err)
 			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
 
 			return
 		}
+
+		result["total"] = total
 	}
 
-	rows, err = h.db.Query(r.Context(), queryGetTransactions, accountID, searchQuery, page*limit, limit)
+	// One extra row is fetched to know whether a next page exists.
+	pageQuery, pageArgs := filter.page(page*limit, limit+1)
+
+	rows, err := h.db.Query(r.Context(), queryGetTransactions+pageQuery, append(args, pageArgs...)...)
 	if err != nil {
 		slog.Error("error getting transactions from database", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -248,9 +462,7 @@ func (h *Handler) GetTransactions(w http.ResponseWriter, r *http.Request) { //no
 	for rows.Next() {
 		var transaction Transaction
 
-		err := rows.Scan(&transaction.ID, &transaction.AccountID, &transaction.CategoryID, &transaction.PayeeID,
-			&transaction.Name, &transaction.Credit, &transaction.Debit, &transaction.Notes, &transaction.ClearedAt,
-			&transaction.CreatedAt, &transaction.UpdatedAt, &transaction.CategoryName, &transaction.PayeeName)
+		err := scanTransactionWithNames(rows, &transaction)
 		if err != nil {
 			slog.Error("error scanning transactions row from database", "error", err)
 			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -268,15 +480,67 @@ func (h *Handler) GetTransactions(w http.ResponseWriter, r *http.Request) { //no
 		return
 	}
 
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+
+		if filter.Sort == "date" {
+			last := transactions[limit-1]
+			result["nextCursor"] = transactionCursor{Date: &last.Date, ID: last.ID}.encode()
+		}
+	}
+
+	result["transactions"] = transactions
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
-	err = json.NewEncoder(w).Encode(map[string]interface{}{"total": total, "transactions": transactions})
+	err = json.NewEncoder(w).Encode(result)
 	if err != nil {
 		slog.Error("error encoding transactions response", "error", err)
 	}
 }
 
+func (h *Handler) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	tID := r.PathValue("tId")
+
+	accountID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing account id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	transactionID, err := uuid.Parse(tID)
+	if err != nil {
+		slog.Error("error parsing transaction id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var transaction Transaction
+
+	err = scanTransactionWithNames(h.db.QueryRow(r.Context(), queryGetTransactionWithNames, accountID, transactionID),
+		&transaction)
+	if err != nil {
+		slog.Error("error getting transaction from database", "error", err)
+		buildErrorResponse(w, err.Error(), dbErrorStatus(err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(transaction.UpdatedAt))
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(transaction)
+	if err != nil {
+		slog.Error("error encoding transaction response", "error", err)
+	}
+}
+
 func (h *Handler) ImportTransactions(w http.ResponseWriter, r *http.Request) { //nolint: funlen,cyclop
 	id := r.PathValue("id")
 
@@ -291,7 +555,8 @@ func (h *Handler) ImportTransactions(w http.ResponseWriter, r *http.Request) { /
 	var account Account
 
 	err = h.db.QueryRow(r.Context(), queryGetAccountForUsage, accountID).Scan(&account.ID, &account.Name,
-		&account.OffBudget, &account.Category, &account.Adapter, &account.CreatedAt, &account.UpdatedAt)
+		&account.OffBudget, &account.Category, &account.Adapter, &account.CreatedAt, &account.UpdatedAt,
+		&account.DeletedAt)
 	if err != nil {
 		slog.Error("error getting account from database", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -318,6 +583,8 @@ func (h *Handler) ImportTransactions(w http.ResponseWriter, r *http.Request) { /
 
 	slog.Info("uploaded file", "name", header.Filename, "size", header.Size)
 
+	tags := parseTags(strings.Split(r.FormValue("tags"), ","))
+
 	rows, err := h.getDataRows(header.Filename, file)
 	if err != nil {
 		slog.Error("error getting data rows", "error", err)
@@ -329,6 +596,8 @@ func (h *Handler) ImportTransactions(w http.ResponseWriter, r *http.Request) { /
 	slog.Info("adapter", "config", h.adapters[account.Adapter+"-"+account.Category])
 	adapterTransactions := adapters.GetTransactions(h.adapters[account.Adapter+"-"+account.Category], rows)
 	importedTransactions := 0
+	transferCandidates := 0
+	matchedScheduled := 0
 
 	getPayeeCategory, err := h.assignPayeeAndCategory(r.Context(), []Payee{})
 	if err != nil {
@@ -346,10 +615,24 @@ func (h *Handler) ImportTransactions(w http.ResponseWriter, r *http.Request) { /
 		return
 	}
 
+	defer func(ctx context.Context) {
+		if err != nil {
+			rollBackErr := tx.Rollback(ctx)
+			if rollBackErr != nil {
+				slog.Error("error rolling back database txn", "error", rollBackErr)
+			}
+		}
+	}(r.Context())
+
 	transactionTime := time.Now()
 
 	for _, adapterTransaction := range adapterTransactions {
-		transactionID, err := uuid.NewV7()
+		var (
+			transactionID uuid.UUID
+			result        pgconn.CommandTag
+		)
+
+		transactionID, err = uuid.NewV7()
 		if err != nil {
 			slog.Error("error creating transaction id", "error", err)
 			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -365,11 +648,22 @@ func (h *Handler) ImportTransactions(w http.ResponseWriter, r *http.Request) { /
 			return
 		}
 
-		payeeID, categoryID := getPayeeCategory(adapterTransaction.Remarks)
+		result, err = tx.Exec(r.Context(), queryMatchScheduledTransaction, accountID, adapterTransaction.Credit,
+			adapterTransaction.Debit, adapterTransaction.Date, adapterTransaction.Remarks, transactionTime,
+			scheduledMatchDays)
+		if err == nil && result.RowsAffected() > 0 {
+			matchedScheduled++
 
-		_, err = tx.Exec(r.Context(), queryCreateTransaction, transactionID, accountID, categoryID, payeeID,
-			adapterTransaction.Credit, adapterTransaction.Debit, "imported transaction", adapterTransaction.Remarks,
-			adapterTransaction.Date, transactionTime, transactionTime)
+			continue
+		}
+
+		if err == nil {
+			payeeID, categoryID := getPayeeCategory(adapterTransaction.Remarks)
+
+			_, err = tx.Exec(r.Context(), queryCreateTransaction, transactionID, accountID, categoryID, payeeID,
+				adapterTransaction.Credit, adapterTransaction.Debit, "imported transaction", adapterTransaction.Remarks,
+				dateOf(adapterTransaction.Date), statusCleared, adapterTransaction.Date, transactionTime, transactionTime)
+		}
 
 		if err != nil {
 			slog.Error("error inserting transaction", "error", err, "adapterTransaction", adapterTransaction)
@@ -384,21 +678,39 @@ func (h *Handler) ImportTransactions(w http.ResponseWriter, r *http.Request) { /
 
 				return
 			}
-		} else {
-			importedTransactions++
+
+			continue
 		}
-	}
 
-	defer func(ctx context.Context) {
-		if err != nil {
-			rollBackErr := tx.Rollback(ctx)
-			if rollBackErr != nil {
-				slog.Error("error rolling back database txn", "error", rollBackErr)
+		importedTransactions++
+
+		if len(tags) > 0 {
+			err = setTransactionTags(r.Context(), tx, transactionID, tags)
+			if err != nil {
+				buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+				return
 			}
 		}
-	}(r.Context())
 
-	if err := tx.Commit(r.Context()); err != nil {
+		var candidate bool
+
+		err = tx.QueryRow(r.Context(), queryHasTransferCandidate, accountID, adapterTransaction.Credit,
+			adapterTransaction.Debit, adapterTransaction.Date).Scan(&candidate)
+		if err != nil {
+			slog.Error("error getting transfer candidate", "error", err, "adapterTransaction", adapterTransaction)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		if candidate {
+			transferCandidates++
+		}
+	}
+
+	err = tx.Commit(r.Context())
+	if err != nil {
 		slog.Error("error committing database txn", "error", err)
 		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
 
@@ -408,7 +720,10 @@ func (h *Handler) ImportTransactions(w http.ResponseWriter, r *http.Request) { /
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
-	err = json.NewEncoder(w).Encode(TransactionsResult{Total: len(adapterTransactions), Imported: importedTransactions})
+	err = json.NewEncoder(w).Encode(TransactionsResult{
+		Total: len(adapterTransactions), Imported: importedTransactions, TransferCandidates: transferCandidates,
+		Scheduled: matchedScheduled,
+	})
 	if err != nil {
 		slog.Error("error encoding transactions result response", "error", err)
 	}
@@ -486,7 +801,8 @@ func (h *Handler) updateTransactions(ctx context.Context, getPayeeCategory func(
 
 		err := rows.Scan(&transaction.ID, &transaction.AccountID, &transaction.CategoryID, &transaction.PayeeID,
 			&transaction.Name, &transaction.Credit, &transaction.Debit, &transaction.Notes, &transaction.ClearedAt,
-			&transaction.CreatedAt, &transaction.UpdatedAt)
+			&transaction.CreatedAt, &transaction.UpdatedAt, &transaction.TransferID, &transaction.ReconciliationID,
+			&transaction.Date, &transaction.Status, &transaction.DeletedAt)
 		if err != nil {
 			slog.Error("error scanning transactions row from database", "error", err)
 
@@ -509,6 +825,15 @@ func (h *Handler) updateTransactions(ctx context.Context, getPayeeCategory func(
 		return fmt.Errorf("error creating database txn: %w", err)
 	}
 
+	defer func(ctx context.Context) {
+		if err != nil {
+			rollBackErr := tx.Rollback(ctx)
+			if rollBackErr != nil {
+				slog.Error("error rolling back database txn", "error", rollBackErr)
+			}
+		}
+	}(ctx)
+
 	for _, transaction := range transactions {
 		_, err = tx.Exec(ctx, "SAVEPOINT sp1")
 		if err != nil {
@@ -527,11 +852,12 @@ func (h *Handler) updateTransactions(ctx context.Context, getPayeeCategory func(
 			transaction.CategoryID = categoryID
 		}
 
+		version := transaction.UpdatedAt
 		transaction.UpdatedAt = time.Now()
 
-		_, err = h.db.Exec(ctx, queryUpdateTransaction, transaction.AccountID, transaction.CategoryID,
+		_, err = tx.Exec(ctx, queryUpdateTransaction, transaction.AccountID, transaction.CategoryID,
 			transaction.PayeeID, transaction.Credit, transaction.Debit, transaction.Name, transaction.Notes,
-			transaction.ClearedAt, transaction.UpdatedAt, transaction.ID)
+			transaction.ClearedAt, transaction.UpdatedAt, transaction.ID, version, transaction.Date, transaction.Status)
 		if err != nil {
 			slog.Error("error updating transaction", "error", err, "transaction", transaction)
 
@@ -544,16 +870,8 @@ func (h *Handler) updateTransactions(ctx context.Context, getPayeeCategory func(
 		}
 	}
 
-	defer func(ctx context.Context) {
-		if err != nil {
-			rollBackErr := tx.Rollback(ctx)
-			if rollBackErr != nil {
-				slog.Error("error rolling back database txn", "error", rollBackErr)
-			}
-		}
-	}(ctx)
-
-	if err := tx.Commit(ctx); err != nil {
+	err = tx.Commit(ctx)
+	if err != nil {
 		slog.Error("error committing database txn", "error", err)
 
 		return fmt.Errorf("error committing database txn: %w", err)
@@ -561,3 +879,12 @@ func (h *Handler) updateTransactions(ctx context.Context, getPayeeCategory func(
 
 	return nil
 }
+
+// scanTransactionWithNames scans a row selected by queryTransactionsWithNames.
+func scanTransactionWithNames(row pgx.Row, transaction *Transaction) error {
+	return row.Scan(&transaction.ID, &transaction.AccountID, &transaction.CategoryID, //nolint: wrapcheck
+		&transaction.PayeeID, &transaction.Name, &transaction.Credit, &transaction.Debit, &transaction.Notes,
+		&transaction.ClearedAt, &transaction.CreatedAt, &transaction.UpdatedAt, &transaction.TransferID,
+		&transaction.ReconciliationID, &transaction.Date, &transaction.Status, &transaction.DeletedAt,
+		&transaction.AccountName, &transaction.CategoryName, &transaction.PayeeName, &transaction.Tags)
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	uuid "github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -19,16 +20,21 @@ import (
 )
 
 var (
-	testTransactionName            = "House Party Food"
-	testCategoryID                 = uuid.MustParse("01927f3e-11b6-7a79-bbc7-affae59272ae")
-	testPayeeID                    = uuid.MustParse("01927f3e-5609-703b-b067-f9b9dd9d8ee2")
-	testAccountID                  = uuid.MustParse("01927f3e-6ecf-7091-987f-8aa23adcda09")
-	testTransactionID              = uuid.MustParse("01927f3e-6ecf-7091-987f-8aa23addda09")
-	testNullID          *uuid.UUID = nil
-	transactionRowCols             = []string{"id", "account_id", "category_id", "payee_id", "name", "credit",
-		"debit", "notes", "cleared_at", "created_at", "updated_at", "category_name", "payee_name"}
+	testTransactionName              = "House Party Food"
+	testCategoryID                   = uuid.MustParse("01927f3e-11b6-7a79-bbc7-affae59272ae")
+	testPayeeID                      = uuid.MustParse("01927f3e-5609-703b-b067-f9b9dd9d8ee2")
+	testAccountID                    = uuid.MustParse("01927f3e-6ecf-7091-987f-8aa23adcda09")
+	testTransactionID                = uuid.MustParse("01927f3e-6ecf-7091-987f-8aa23addda09")
+	testNextTransactionID            = uuid.MustParse("01927f3e-6ecf-7091-987f-8aa23addda0a")
+	testNullID            *uuid.UUID = nil
+	testCursorTime                   = time.Date(2024, 10, 18, 0, 0, 0, 0, time.UTC)
+	testTransactionDate              = time.Date(2024, 10, 18, 0, 0, 0, 0, time.UTC)
+	transactionRowCols               = []string{"id", "account_id", "category_id", "payee_id", "name", "credit",
+		"debit", "notes", "cleared_at", "created_at", "updated_at", "transfer_id", "reconciliation_id",
+		"date", "status", "deleted_at", "account_name", "category_name", "payee_name", "tags"}
 	transactionsRowCols = []string{"id", "account_id", "category_id", "payee_id", "name", "credit",
-		"debit", "notes", "cleared_at", "created_at", "updated_at"}
+		"debit", "notes", "cleared_at", "created_at", "updated_at", "transfer_id", "reconciliation_id", "date", "status",
+		"deleted_at"}
 )
 
 func TestCreateTransaction(t *testing.T) {
@@ -54,9 +60,11 @@ func TestCreateTransaction(t *testing.T) {
 				testPayeeID.String() + `","categoryId":"` + testCategoryID.String() + `","credit":4.20}`),
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
 				mock.ExpectExec("INSERT INTO transactions").WithArgs(pgxmock.AnyArg(),
 					testAccountID, &testCategoryID, &testPayeeID, 4.20, 0.0, testTransactionName, "",
-					pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+					pgxmock.AnyArg(), statusUncleared, testNullTime, pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
 			},
 			http.StatusInternalServerError, "tx is closed",
 		},
@@ -66,12 +74,44 @@ func TestCreateTransaction(t *testing.T) {
 				testPayeeID.String() + `","categoryId":"` + testCategoryID.String() + `","credit":4.20}`),
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
 				mock.ExpectExec("INSERT INTO transactions").WithArgs(pgxmock.AnyArg(),
 					testAccountID, &testCategoryID, &testPayeeID, 4.20, 0.0, testTransactionName, "",
-					pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+					pgxmock.AnyArg(), statusUncleared, testNullTime, pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
 			},
 			http.StatusCreated, testTransactionName,
 		},
+		{
+			"error setting transaction tags", http.MethodPost, "/v1/accounts/" + testAccountID.String() + "/transactions", true,
+			strings.NewReader(`{"name":"` + testTransactionName + `","credit":4.20,"tags":["vacation"," vacation ",""]}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO transactions").WithArgs(pgxmock.AnyArg(),
+					testAccountID, testNullID, testNullID, 4.20, 0.0, testTransactionName, "",
+					pgxmock.AnyArg(), statusUncleared, testNullTime, pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectExec("INSERT INTO transaction_tags").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(),
+					[]string{"vacation"}, pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "error setting transaction tags",
+		},
+		{
+			"success creating transaction with tags", http.MethodPost, "/v1/accounts/" + testAccountID.String() + "/transactions", true,
+			strings.NewReader(`{"name":"` + testTransactionName + `","credit":4.20,"tags":["vacation"," vacation ",""]}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO transactions").WithArgs(pgxmock.AnyArg(),
+					testAccountID, testNullID, testNullID, 4.20, 0.0, testTransactionName, "",
+					pgxmock.AnyArg(), statusUncleared, testNullTime, pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectExec("INSERT INTO transaction_tags").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(),
+					[]string{"vacation"}, pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusCreated, `"tags":["vacation"]`,
+		},
 	}
 	executeTests(t, tests)
 }
@@ -95,9 +135,35 @@ func TestUpdateTransaction(t *testing.T) {
 		},
 		{
 			"error due to bad request", http.MethodPatch, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String(), true, strings.NewReader("invalid-body"),
-			nil, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionsRowCols).
+					AddRow(testTransactionID, testAccountID, testNullID, testNullID, "Old name", 4.20, 0.0, "", &testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
+			},
 			http.StatusBadRequest, "invalid character",
 		},
+		{
+			"error getting split amounts from database", http.MethodPatch, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String(), true,
+			strings.NewReader(`{"credit":4.20}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionsRowCols).
+					AddRow(testTransactionID, testAccountID, testNullID, testNullID, "Old name", 4.20, 0.0, "", &testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testTransactionID).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error due to amounts not matching splits", http.MethodPatch, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String(), true,
+			strings.NewReader(`{"credit":4.20}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionsRowCols).
+					AddRow(testTransactionID, testAccountID, testNullID, testNullID, "Old name", 4.20, 0.0, "", &testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows(splitAmountCols).AddRow(2, 6.90, 0.0))
+			},
+			http.StatusBadRequest, "split amounts do not match",
+		},
 		{
 			"error updating transaction in database", http.MethodPatch, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String(), true,
 			strings.NewReader(`{"accountId":"` + testAccountID.String() + `","categoryId":"` + testCategoryID.String() +
@@ -105,10 +171,17 @@ func TestUpdateTransaction(t *testing.T) {
 				`"credit":4.20,"debit":4.20}`),
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec("UPDATE transactions").WithArgs(
+				mock.ExpectQuery("SELECT \\* FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionsRowCols).
+					AddRow(testTransactionID, testAccountID, testNullID, testNullID, "Old name", 4.20, 0.0, "", &testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows(splitAmountCols).AddRow(0, 0.0, 0.0))
+				mock.ExpectBegin()
+				mock.ExpectQuery("WITH updated").WithArgs(
 					testAccountID, &testCategoryID, &testPayeeID, 4.20, 4.20, "Some name",
-					"Some notes", pgxmock.AnyArg(), pgxmock.AnyArg(), testTransactionID,
+					"Some notes", pgxmock.AnyArg(), pgxmock.AnyArg(), testTransactionID, testNullTime,
+					testTransactionDate, statusCleared,
 				).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
 			},
 			http.StatusInternalServerError, "tx is closed",
 		},
@@ -119,13 +192,179 @@ func TestUpdateTransaction(t *testing.T) {
 				`"credit":4.20,"debit":4.20}`),
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec("UPDATE transactions").WithArgs(
+				mock.ExpectQuery("SELECT \\* FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionsRowCols).
+					AddRow(testTransactionID, testAccountID, testNullID, testNullID, "Old name", 4.20, 0.0, "", &testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows(splitAmountCols).AddRow(0, 0.0, 0.0))
+				mock.ExpectBegin()
+				mock.ExpectQuery("WITH updated").WithArgs(
 					testAccountID, &testCategoryID, &testPayeeID, 4.20, 4.20, "Some name",
-					"Some notes", pgxmock.AnyArg(), pgxmock.AnyArg(), testTransactionID,
-				).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+					"Some notes", pgxmock.AnyArg(), pgxmock.AnyArg(), testTransactionID, testNullTime,
+					testTransactionDate, statusCleared,
+				).WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectCommit()
 			},
 			http.StatusNoContent, "",
 		},
+		{
+			"success updating transaction with tags", http.MethodPatch, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String(), true,
+			strings.NewReader(`{"name":"Some name","credit":4.20,"tags":[]}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionsRowCols).
+					AddRow(testTransactionID, testAccountID, testNullID, testNullID, "Old name", 4.20, 0.0, "", &testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows(splitAmountCols).AddRow(0, 0.0, 0.0))
+				mock.ExpectBegin()
+				mock.ExpectQuery("WITH updated").WithArgs(
+					testAccountID, testNullID, testNullID, 4.20, 0.0, "Some name",
+					"", pgxmock.AnyArg(), pgxmock.AnyArg(), testTransactionID, testNullTime,
+					testTransactionDate, statusCleared,
+				).WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectExec("INSERT INTO transaction_tags").WithArgs(testTransactionID, pgxmock.AnyArg(),
+					[]string{}, pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusNoContent, "",
+		},
+		{
+			"error due to reconciled transaction", http.MethodPatch, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String(), true,
+			strings.NewReader(`{"debit":4.20}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionsRowCols).
+					AddRow(testTransactionID, testAccountID, testNullID, testNullID, "Old name", 4.20, 0.0, "", &testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			http.StatusConflict, "transaction is reconciled",
+		},
+		{
+			"success updating reconciled transaction when forced", http.MethodPatch, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String() + "?force=true", true,
+			strings.NewReader(`{"debit":4.20}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionsRowCols).
+					AddRow(testTransactionID, testAccountID, testNullID, testNullID, "Old name", 4.20, 0.0, "", &testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows(splitAmountCols).AddRow(0, 0.0, 0.0))
+				mock.ExpectBegin()
+				mock.ExpectQuery("WITH updated").WithArgs(
+					testAccountID, testNullID, testNullID, 4.20, 4.20, "Old name",
+					"", &testAccountTime, pgxmock.AnyArg(), testTransactionID, testNullTime,
+					testTransactionDate, statusCleared,
+				).WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectCommit()
+			},
+			http.StatusNoContent, "",
+		},
+		{
+			"error due to invalid status", http.MethodPatch, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String(), true,
+			strings.NewReader(`{"status":"reconciled"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionsRowCols).
+					AddRow(testTransactionID, testAccountID, testNullID, testNullID, "Old name", 4.20, 0.0, "", &testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
+			},
+			http.StatusBadRequest, "status can only become reconciled",
+		},
+		{
+			"success unclearing transaction", http.MethodPatch, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String(), true,
+			strings.NewReader(`{"status":"uncleared"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionsRowCols).
+					AddRow(testTransactionID, testAccountID, testNullID, testNullID, "Old name", 4.20, 0.0, "", &testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows(splitAmountCols).AddRow(0, 0.0, 0.0))
+				mock.ExpectBegin()
+				mock.ExpectQuery("WITH updated").WithArgs(
+					testAccountID, testNullID, testNullID, 4.20, 0.0, "Old name",
+					"", testNullTime, pgxmock.AnyArg(), testTransactionID, testNullTime,
+					testTransactionDate, statusUncleared,
+				).WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectCommit()
+			},
+			http.StatusNoContent, "",
+		},
+		{
+			"error due to transaction not found", http.MethodPatch, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String() + "", true, strings.NewReader(`{"name":"x"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusNotFound, "no rows",
+		},
+		{
+			"error due to unknown field", http.MethodPatch, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String() + "", true, strings.NewReader(`{"nmae":"x"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionsRowCols).
+					AddRow(testTransactionID, testAccountID, testNullID, testNullID, "Old name", 4.20, 0.0, "", &testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
+			},
+			http.StatusBadRequest, "unknown field",
+		},
+		{
+			"success updating only supplied fields", http.MethodPatch, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String() + "", true,
+			strings.NewReader(`{"notes":"Some notes"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionsRowCols).
+					AddRow(testTransactionID, testAccountID, testNullID, testNullID, "Old name", 4.20, 0.0, "", &testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows(splitAmountCols).AddRow(0, 0.0, 0.0))
+				mock.ExpectBegin()
+				mock.ExpectQuery("WITH updated").WithArgs(
+					testAccountID, testNullID, testNullID, 4.20, 0.0, "Old name",
+					"Some notes", &testAccountTime, pgxmock.AnyArg(), testTransactionID, testNullTime,
+					testTransactionDate, statusCleared,
+				).WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectCommit()
+			},
+			http.StatusNoContent, "",
+		},
+		{
+			"error due to stale if-match", http.MethodPatch, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String(), true,
+			strings.NewReader(`{"notes":"Some notes"}`),
+			http.Header{"If-Match": []string{`"stale"`}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionsRowCols).
+					AddRow(testTransactionID, testAccountID, testNullID, testNullID, "Old name", 4.20, 0.0, "", &testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
+			},
+			http.StatusPreconditionFailed, "resource has been modified",
+		},
+		{
+			"error due to concurrent update", http.MethodPatch, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String(), true,
+			strings.NewReader(`{"notes":"Some notes"}`),
+			http.Header{"If-Match": []string{etag(testAccountTime)}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionsRowCols).
+					AddRow(testTransactionID, testAccountID, testNullID, testNullID, "Old name", 4.20, 0.0, "", &testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows(splitAmountCols).AddRow(0, 0.0, 0.0))
+				mock.ExpectBegin()
+				mock.ExpectQuery("WITH updated").WithArgs(
+					testAccountID, testNullID, testNullID, 4.20, 0.0, "Old name",
+					"Some notes", &testAccountTime, pgxmock.AnyArg(), testTransactionID, &testAccountTime,
+					testTransactionDate, statusCleared,
+				).WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(0))
+				mock.ExpectRollback()
+			},
+			http.StatusPreconditionFailed, "resource has been modified",
+		},
+		{
+			"error due to transaction trashed meanwhile", http.MethodPatch, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String(), true,
+			strings.NewReader(`{"notes":"Some notes"}`),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionsRowCols).
+					AddRow(testTransactionID, testAccountID, testNullID, testNullID, "Old name", 4.20, 0.0, "", &testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows(splitAmountCols).AddRow(0, 0.0, 0.0))
+				mock.ExpectBegin()
+				mock.ExpectQuery("WITH updated").WithArgs(
+					testAccountID, testNullID, testNullID, 4.20, 0.0, "Old name",
+					"Some notes", &testAccountTime, pgxmock.AnyArg(), testTransactionID, testNullTime,
+					testTransactionDate, statusCleared,
+				).WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(0))
+				mock.ExpectRollback()
+			},
+			http.StatusNotFound, "no rows",
+		},
 	}
 	executeTests(t, tests)
 }
@@ -151,7 +390,10 @@ func TestDeleteTransaction(t *testing.T) {
 			"error deleting transaction in database", http.MethodDelete, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String(), true,
 			nil, nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec("DELETE FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE transactions SET deleted_at").WithArgs(testAccountID, testTransactionID, pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
 			},
 			http.StatusInternalServerError, "tx is closed",
 		},
@@ -159,10 +401,69 @@ func TestDeleteTransaction(t *testing.T) {
 			"success deleting transaction", http.MethodDelete, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String(), true,
 			nil, nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec("DELETE FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE transactions SET deleted_at").WithArgs(testAccountID, testTransactionID, pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
 			},
 			http.StatusNoContent, "",
 		},
+		{
+			"error due to reconciled transaction", http.MethodDelete, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String(), true,
+			nil, nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			http.StatusConflict, "transaction is reconciled",
+		},
+		{
+			"error due to stale if-match", http.MethodDelete, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String(), true,
+			nil,
+			http.Header{"If-Match": []string{`"stale"`}},
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT updated_at FROM transactions").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows([]string{"updated_at"}).
+					AddRow(&testAccountTime))
+				mock.ExpectRollback()
+			},
+			http.StatusPreconditionFailed, "resource has been modified",
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestGetTransaction(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String(), false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad transaction id", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions/invalid-uuid", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error due to transaction not found", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String(), true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT t.\\*").WithArgs(testAccountID, testTransactionID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusNotFound, "no rows",
+		},
+		{
+			"success getting transaction", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String(), true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT t.\\*").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionRowCols).
+					AddRow(testTransactionID, testAccountID, &testCategoryID, &testPayeeID, "Some transaction", 4.20, 4.20, "Some notes",
+						&testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime, &testAccountName, &testCategoryName,
+						&testPayeeName, []string{"vacation"}))
+			},
+			http.StatusOK, testTransactionID.String(),
+		},
 	}
 	executeTests(t, tests)
 }
@@ -178,7 +479,7 @@ func TestGetTransactions(t *testing.T) {
 			"error getting total transactions from db", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions?q=query&page=1&limit=10", true, nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectQuery("SELECT *").WithArgs(testAccountID, "query").WillReturnError(pgx.ErrNoRows)
+				mock.ExpectQuery("SELECT *").WithArgs(transactionFilterArgs("query", []string{}, "")...).WillReturnError(pgx.ErrNoRows)
 			},
 			http.StatusInternalServerError, "no rows",
 		},
@@ -186,7 +487,7 @@ func TestGetTransactions(t *testing.T) {
 			"error scanning total transactions rows from db", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions?q=query&page=1&limit=10", true, nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectQuery("SELECT *").WithArgs(testAccountID, "query").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(""))
+				mock.ExpectQuery("SELECT *").WithArgs(transactionFilterArgs("query", []string{}, "")...).WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(""))
 			},
 			http.StatusInternalServerError, "not supported",
 		},
@@ -194,8 +495,8 @@ func TestGetTransactions(t *testing.T) {
 			"error getting transactions from db", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions?q=query&page=1&limit=10", true, nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectQuery("SELECT *").WithArgs(testAccountID, "query").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
-				mock.ExpectQuery("SELECT *").WithArgs(testAccountID, "query", 0, 10).WillReturnError(pgx.ErrNoRows)
+				mock.ExpectQuery("SELECT *").WithArgs(transactionFilterArgs("query", []string{}, "")...).WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
+				mock.ExpectQuery("SELECT *").WithArgs(transactionFilterArgs("query", []string{}, "", 0, 11)...).WillReturnError(pgx.ErrNoRows)
 			},
 			http.StatusInternalServerError, "no rows",
 		},
@@ -203,9 +504,9 @@ func TestGetTransactions(t *testing.T) {
 			"error scanning transactions rows from db", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions?q=query&page=1&limit=10", true, nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectQuery("SELECT *").WithArgs(testAccountID, "query").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
-				mock.ExpectQuery("SELECT *").WithArgs(testAccountID, "query", 0, 10).WillReturnRows(pgxmock.NewRows(transactionRowCols).AddRow("invalid", "invalid", "invalid",
-					"invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid"))
+				mock.ExpectQuery("SELECT *").WithArgs(transactionFilterArgs("query", []string{}, "")...).WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
+				mock.ExpectQuery("SELECT *").WithArgs(transactionFilterArgs("query", []string{}, "", 0, 11)...).WillReturnRows(pgxmock.NewRows(transactionRowCols).AddRow("invalid", "invalid", "invalid",
+					"invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", testNullTime, "invalid", "invalid", "invalid", "invalid"))
 			},
 			http.StatusInternalServerError, "Scanning value error",
 		},
@@ -213,8 +514,8 @@ func TestGetTransactions(t *testing.T) {
 			"error reading transactions rows from db", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions?q=query&page=1&limit=10", true, nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectQuery("SELECT *").WithArgs(testAccountID, "query").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
-				mock.ExpectQuery("SELECT *").WithArgs(testAccountID, "query", 0, 10).WillReturnRows(pgxmock.NewRows(transactionRowCols).RowError(0, errors.New("some error in db")))
+				mock.ExpectQuery("SELECT *").WithArgs(transactionFilterArgs("query", []string{}, "")...).WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
+				mock.ExpectQuery("SELECT *").WithArgs(transactionFilterArgs("query", []string{}, "", 0, 11)...).WillReturnRows(pgxmock.NewRows(transactionRowCols).RowError(0, errors.New("some error in db")))
 			},
 			http.StatusInternalServerError, "some error in db",
 		},
@@ -222,22 +523,149 @@ func TestGetTransactions(t *testing.T) {
 			"success", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions?q=query&page=1&limit=10", true, nil,
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectQuery("SELECT *").WithArgs(testAccountID, "query").WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
-				mock.ExpectQuery("SELECT *").WithArgs(testAccountID, "query", 0, 10).WillReturnRows(pgxmock.NewRows(transactionRowCols).AddRow(testTransactionID,
+				mock.ExpectQuery("SELECT *").WithArgs(transactionFilterArgs("query", []string{}, "")...).WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(1))
+				mock.ExpectQuery("SELECT *").WithArgs(transactionFilterArgs("query", []string{}, "", 0, 11)...).WillReturnRows(pgxmock.NewRows(transactionRowCols).AddRow(testTransactionID,
 					testAccountID, &testCategoryID, &testPayeeID, "Some transaction", 4.20, 4.20, "Some notes",
-					&testAccountTime, testAccountTime, testAccountTime, &testCategoryName, &testPayeeName))
+					&testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime, &testAccountName, &testCategoryName, &testPayeeName, []string{"vacation"}))
 			},
 			http.StatusOK, testAccountID.String(),
 		},
+		{
+			"success filtering by tags", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions?tags=vacation,reimbursable&tagMode=all", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT *").WithArgs(transactionFilterArgs("", []string{"vacation", "reimbursable"}, "all")...).WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(0))
+				mock.ExpectQuery("SELECT *").WithArgs(transactionFilterArgs("", []string{"vacation", "reimbursable"}, "all", 0, 51)...).WillReturnRows(pgxmock.NewRows(transactionRowCols))
+			},
+			http.StatusOK, `"total":0`,
+		},
+		{
+			"success filtering and sorting", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions?from=2024-01-01&to=2024-01-31" +
+				"&minAmount=10&maxAmount=100.5&type=debit&categoryId=uncategorized&payeeId=" + testPayeeID.String() + "&cleared=true&sort=amount&order=asc", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				args := []any{[]uuid.UUID{testAccountID}, "", []string{}, "", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+					10.0, 100.5, "debit", nil, true, testPayeeID, true}
+				mock.ExpectQuery("SELECT COUNT").WithArgs(args...).WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(0))
+				mock.ExpectQuery(`ORDER BY ABS\(t.credit - t.debit\) ASC NULLS LAST, t.id ASC`).WithArgs(append(args, 0, 51)...).WillReturnRows(pgxmock.NewRows(transactionRowCols))
+			},
+			http.StatusOK, `"total":0`,
+		},
+		{
+			"success returning next cursor", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions?limit=1", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT COUNT").WithArgs(transactionFilterArgs("", []string{}, "")...).WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(2))
+				mock.ExpectQuery("SELECT t.*").WithArgs(transactionFilterArgs("", []string{}, "", 0, 2)...).WillReturnRows(pgxmock.NewRows(transactionRowCols).
+					AddRow(testTransactionID, testAccountID, nil, nil, "First", 0.0, 4.20, "", &testAccountTime, testAccountTime, testAccountTime, testNullID,
+						testNullID, testTransactionDate, statusCleared, testNullTime, &testAccountName, nil, nil, []string{}).
+					AddRow(testNextTransactionID, testAccountID, nil, nil, "Second", 0.0, 4.20, "", &testAccountTime, testAccountTime, testAccountTime, testNullID,
+						testNullID, testTransactionDate, statusCleared, testNullTime, &testAccountName, nil, nil, []string{}))
+			},
+			http.StatusOK, `"nextCursor":"` + transactionCursor{Date: &testTransactionDate, ID: testTransactionID}.encode() + `"`,
+		},
+		{
+			"success paginating with cursor", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions?limit=1&cursor=" +
+				transactionCursor{Date: &testCursorTime, ID: testTransactionID}.encode(), true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`\(t.date, t.id\) <`).WithArgs(transactionFilterArgs("", []string{}, "", testCursorTime, testTransactionID, 2)...).
+					WillReturnRows(pgxmock.NewRows(transactionRowCols).AddRow(testNextTransactionID, testAccountID, nil, nil, "Second", 0.0, 4.20, "",
+						&testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime, &testAccountName, nil, nil, []string{}))
+			},
+			http.StatusOK, `"transactions":[{"id":"` + testNextTransactionID.String(),
+		},
+		{
+			"error due to invalid filter", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions?from=yesterday", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "error parsing from date",
+		},
+		{
+			"error due to invalid sort", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions?sort=name", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "sort must be date, amount, payee or category",
+		},
+		{
+			"error due to invalid tag mode", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions?tags=vacation&tagMode=every", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "tagMode must be any or all",
+		},
 	}
 	executeTests(t, tests)
 }
 
+func TestGetAllTransactions(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, "/v1/transactions", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to invalid account id", http.MethodGet, "/v1/transactions?accountIds=invalid-uuid", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error due to invalid page", http.MethodGet, "/v1/transactions?page=0", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "page must be a positive number",
+		},
+		{
+			"error getting total transactions from db", http.MethodGet, "/v1/transactions?q=uber", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT COUNT").WithArgs(allTransactionsFilterArgs([]uuid.UUID{}, "uber")...).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success across all accounts", http.MethodGet, "/v1/transactions?q=uber&page=2&limit=10", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT COUNT").WithArgs(allTransactionsFilterArgs([]uuid.UUID{}, "uber")...).WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(11))
+				mock.ExpectQuery("LEFT JOIN accounts").WithArgs(allTransactionsFilterArgs([]uuid.UUID{}, "uber", 10, 11)...).WillReturnRows(pgxmock.NewRows(transactionRowCols).AddRow(testTransactionID,
+					testAccountID, &testCategoryID, &testPayeeID, "Uber", 0.0, 4.20, "",
+					&testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime, &testAccountName, &testCategoryName, &testPayeeName, []string{}))
+			},
+			http.StatusOK, `"accountName":"` + testAccountName + `"`,
+		},
+		{
+			"success filtering by accounts", http.MethodGet, "/v1/transactions?accountIds=" + testAccountID.String() + "," + testToAccountID.String(), true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				accountIDs := []uuid.UUID{testAccountID, testToAccountID}
+				mock.ExpectQuery("SELECT COUNT").WithArgs(allTransactionsFilterArgs(accountIDs, "")...).WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(0))
+				mock.ExpectQuery("LEFT JOIN accounts").WithArgs(allTransactionsFilterArgs(accountIDs, "", 0, 51)...).WillReturnRows(pgxmock.NewRows(transactionRowCols))
+			},
+			http.StatusOK, `"total":0`,
+		},
+		{
+			"success capping limit and skipping blank account ids", http.MethodGet, "/v1/transactions?limit=1000&accountIds=" +
+				testAccountID.String() + ",,", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				accountIDs := []uuid.UUID{testAccountID}
+				mock.ExpectQuery("SELECT COUNT").WithArgs(allTransactionsFilterArgs(accountIDs, "")...).WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(0))
+				mock.ExpectQuery("LEFT JOIN accounts").WithArgs(allTransactionsFilterArgs(accountIDs, "", 0, 501)...).WillReturnRows(pgxmock.NewRows(transactionRowCols))
+			},
+			http.StatusOK, `"total":0`,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func transactionFilterArgs(query string, tags []string, tagMode string, page ...any) []any {
+	return append([]any{[]uuid.UUID{testAccountID}, query, tags, tagMode, nil, nil, nil, nil, "", nil, false, nil, nil}, page...)
+}
+
 func TestImportTransactions(t *testing.T) {
 	sampleBytes1, ctype1 := getMockCSV(t, true)
 	sampleBytes2, ctype2 := getMockCSV(t, false)
 	sampleBytes3, ctype3 := getMockCSV(t, false)
 	sampleBytes4, ctype4 := getMockCSV(t, false)
+	sampleBytes5, ctype5 := getMockCSV(t, false)
+	sampleBytes6, ctype6 := getMockCSV(t, false)
 	tests := []testCase{
 		{
 			"error due to auth", http.MethodPut, "/v1/accounts/" + testAccountID.String() + "/transactions", false, nil,
@@ -257,7 +685,7 @@ func TestImportTransactions(t *testing.T) {
 			nil,
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(accountRowCols).AddRow(testAccountID.String(), testAccountName, &testOffBudget, testCategory,
-					testAdapter, testAccountTime, testAccountTime))
+					testAdapter, testAccountTime, testAccountTime, testNullTime))
 			},
 			http.StatusBadRequest, "request Content-Type isn't multipart/form-data",
 		},
@@ -266,7 +694,7 @@ func TestImportTransactions(t *testing.T) {
 			sampleBytes1, ctype1,
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(accountRowCols).AddRow(testAccountID.String(), testAccountName, &testOffBudget, testCategory,
-					testAdapter, testAccountTime, testAccountTime))
+					testAdapter, testAccountTime, testAccountTime, testNullTime))
 			},
 			http.StatusInternalServerError, "error opening file",
 		},
@@ -275,7 +703,7 @@ func TestImportTransactions(t *testing.T) {
 			sampleBytes2, ctype2,
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(accountRowCols).AddRow(testAccountID.String(), testAccountName, &testOffBudget, testCategory,
-					testAdapter, testAccountTime, testAccountTime))
+					testAdapter, testAccountTime, testAccountTime, testNullTime))
 				mock.ExpectQuery("SELECT *").WithArgs("").WillReturnRows(pgxmock.NewRows(payeeRowCols).
 					RowError(0, errors.New("some error in db")))
 			},
@@ -286,34 +714,78 @@ func TestImportTransactions(t *testing.T) {
 			sampleBytes3, ctype3,
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(accountRowCols).AddRow(testAccountID.String(), testAccountName, &testOffBudget, testCategory,
-					testAdapter, testAccountTime, testAccountTime))
+					testAdapter, testAccountTime, testAccountTime, testNullTime))
 				mock.ExpectQuery("SELECT *").WithArgs("").WillReturnRows(pgxmock.NewRows(payeeRowCols).
-					AddRow(testPayeeID.String(), testPayeeName, &testRules, &testCategoryID, testAccountTime, testAccountTime))
+					AddRow(testPayeeID.String(), testPayeeName, &testRules, &testCategoryID, testAccountTime, testAccountTime, testNullTime))
 				mock.ExpectBeginTx(pgx.TxOptions{})
 				mock.ExpectExec("SAVEPOINT sp1").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 1))
+				mock.ExpectExec("UPDATE transactions SET notes").WithArgs(testAccountID, 0.0, 4.20, pgxmock.AnyArg(), "John Doe",
+					pgxmock.AnyArg(), scheduledMatchDays).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
 				mock.ExpectExec("INSERT INTO transactions").WithArgs(pgxmock.AnyArg(),
 					testAccountID, testNullID, testNullID, 0.0, 4.20, "imported transaction", "John Doe",
-					pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+					pgxmock.AnyArg(), statusCleared, pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
 				mock.ExpectExec("ROLLBACK TO SAVEPOINT sp1").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 1))
+				mock.ExpectRollback()
 			},
 			http.StatusInternalServerError, "tx is closed",
 		},
 		{
-			"success", http.MethodPut, "/v1/accounts/" + testAccountID.String() + "/transactions", true,
+			"error getting transfer candidate", http.MethodPut, "/v1/accounts/" + testAccountID.String() + "/transactions", true,
 			sampleBytes4, ctype4,
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(accountRowCols).AddRow(testAccountID.String(), testAccountName, &testOffBudget, testCategory,
-					testAdapter, testAccountTime, testAccountTime))
+					testAdapter, testAccountTime, testAccountTime, testNullTime))
 				mock.ExpectQuery("SELECT *").WithArgs("").WillReturnRows(pgxmock.NewRows(payeeRowCols).
-					AddRow(testPayeeID.String(), testPayeeName, &testRules, &testCategoryID, testAccountTime, testAccountTime))
+					AddRow(testPayeeID.String(), testPayeeName, &testRules, &testCategoryID, testAccountTime, testAccountTime, testNullTime))
 				mock.ExpectBeginTx(pgx.TxOptions{})
 				mock.ExpectExec("SAVEPOINT sp1").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 1))
+				mock.ExpectExec("UPDATE transactions SET notes").WithArgs(testAccountID, 0.0, 4.20, pgxmock.AnyArg(), "John Doe",
+					pgxmock.AnyArg(), scheduledMatchDays).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
 				mock.ExpectExec("INSERT INTO transactions").WithArgs(pgxmock.AnyArg(),
 					testAccountID, testNullID, testNullID, 0.0, 4.20, "imported transaction", "John Doe",
-					pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+					pgxmock.AnyArg(), statusCleared, pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(testAccountID, 0.0, 4.20, pgxmock.AnyArg()).
+					WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success", http.MethodPut, "/v1/accounts/" + testAccountID.String() + "/transactions", true,
+			sampleBytes5, ctype5,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT *").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(accountRowCols).AddRow(testAccountID.String(), testAccountName, &testOffBudget, testCategory,
+					testAdapter, testAccountTime, testAccountTime, testNullTime))
+				mock.ExpectQuery("SELECT *").WithArgs("").WillReturnRows(pgxmock.NewRows(payeeRowCols).
+					AddRow(testPayeeID.String(), testPayeeName, &testRules, &testCategoryID, testAccountTime, testAccountTime, testNullTime))
+				mock.ExpectBeginTx(pgx.TxOptions{})
+				mock.ExpectExec("SAVEPOINT sp1").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 1))
+				mock.ExpectExec("UPDATE transactions SET notes").WithArgs(testAccountID, 0.0, 4.20, pgxmock.AnyArg(), "John Doe",
+					pgxmock.AnyArg(), scheduledMatchDays).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+				mock.ExpectExec("INSERT INTO transactions").WithArgs(pgxmock.AnyArg(),
+					testAccountID, testNullID, testNullID, 0.0, 4.20, "imported transaction", "John Doe",
+					pgxmock.AnyArg(), statusCleared, pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(testAccountID, 0.0, 4.20, pgxmock.AnyArg()).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
 				mock.ExpectCommit()
 			},
-			http.StatusOK, `"total":1,"imported":1`,
+			http.StatusOK, `"total":1,"imported":1,"transferCandidates":1`,
+		},
+		{
+			"success matching scheduled transaction", http.MethodPut, "/v1/accounts/" + testAccountID.String() + "/transactions", true,
+			sampleBytes6, ctype6,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT *").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(accountRowCols).AddRow(testAccountID.String(), testAccountName, &testOffBudget, testCategory,
+					testAdapter, testAccountTime, testAccountTime, testNullTime))
+				mock.ExpectQuery("SELECT *").WithArgs("").WillReturnRows(pgxmock.NewRows(payeeRowCols).
+					AddRow(testPayeeID.String(), testPayeeName, &testRules, &testCategoryID, testAccountTime, testAccountTime, testNullTime))
+				mock.ExpectBeginTx(pgx.TxOptions{})
+				mock.ExpectExec("SAVEPOINT sp1").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 1))
+				mock.ExpectExec("UPDATE transactions SET notes").WithArgs(testAccountID, 0.0, 4.20, pgxmock.AnyArg(), "John Doe",
+					pgxmock.AnyArg(), scheduledMatchDays).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusOK, `"total":1,"imported":0,"transferCandidates":0,"scheduled":1`,
 		},
 	}
 	executeTests(t, tests)
@@ -372,7 +844,7 @@ func TestUpdateTransactions(t *testing.T) {
 			"error scanning transactions row",
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WillReturnRows(pgxmock.NewRows(transactionsRowCols).AddRow("invalid", "invalid", "invalid",
-					"invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid"))
+					"invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", testNullTime))
 			},
 			func(_ string) (*uuid.UUID, *uuid.UUID) {
 				return nil, nil
@@ -394,7 +866,7 @@ func TestUpdateTransactions(t *testing.T) {
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WillReturnRows(pgxmock.NewRows(transactionsRowCols).AddRow(testTransactionID,
 					testAccountID, &testCategoryID, &testPayeeID, "Some transaction", 4.20, 4.20, "Some notes",
-					&testAccountTime, testAccountTime, testAccountTime))
+					&testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
 				mock.ExpectBeginTx(pgx.TxOptions{}).WillReturnError(errors.New("some db error"))
 			},
 			func(_ string) (*uuid.UUID, *uuid.UUID) {
@@ -407,9 +879,10 @@ func TestUpdateTransactions(t *testing.T) {
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WillReturnRows(pgxmock.NewRows(transactionsRowCols).AddRow(testTransactionID,
 					testAccountID, &testCategoryID, &testPayeeID, "Some transaction", 4.20, 4.20, "Some notes",
-					&testAccountTime, testAccountTime, testAccountTime))
+					&testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
 				mock.ExpectBeginTx(pgx.TxOptions{})
 				mock.ExpectExec("SAVEPOINT sp1").WillReturnError(errors.New("some db error"))
+				mock.ExpectRollback()
 			},
 			func(_ string) (*uuid.UUID, *uuid.UUID) {
 				return nil, nil
@@ -421,13 +894,15 @@ func TestUpdateTransactions(t *testing.T) {
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WillReturnRows(pgxmock.NewRows(transactionsRowCols).AddRow(testTransactionID,
 					testAccountID, &testCategoryID, &testPayeeID, "Some transaction", 4.20, 4.20, "Some notes",
-					&testAccountTime, testAccountTime, testAccountTime))
+					&testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
 				mock.ExpectBeginTx(pgx.TxOptions{})
 				mock.ExpectExec("SAVEPOINT sp1").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 1))
 				mock.ExpectExec("UPDATE transactions").WithArgs(
-					testAccountID, &testCategoryID, &testPayeeID, 4.20, 4.20, "Some name",
-					"Some notes", pgxmock.AnyArg(), pgxmock.AnyArg(), testTransactionID,
+					testAccountID, &testCategoryID, &testPayeeID, 4.20, 4.20, "Some transaction",
+					"Some notes", pgxmock.AnyArg(), pgxmock.AnyArg(), testTransactionID, testAccountTime,
+					testTransactionDate, statusCleared,
 				).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectExec("ROLLBACK TO SAVEPOINT sp1").WillReturnResult(pgxmock.NewResult("ROLLBACK", 0))
 				mock.ExpectRollback()
 			},
 			func(_ string) (*uuid.UUID, *uuid.UUID) {
@@ -440,14 +915,16 @@ func TestUpdateTransactions(t *testing.T) {
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WillReturnRows(pgxmock.NewRows(transactionsRowCols).AddRow(testTransactionID,
 					testAccountID, &testCategoryID, &testPayeeID, "Some transaction", 4.20, 4.20, "Some notes",
-					&testAccountTime, testAccountTime, testAccountTime))
+					&testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
 				mock.ExpectBeginTx(pgx.TxOptions{})
 				mock.ExpectExec("SAVEPOINT sp1").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 1))
 				mock.ExpectExec("UPDATE transactions").WithArgs(
 					testAccountID, &testCategoryID, &testPayeeID, 4.20, 4.20, "Some transaction",
-					"Some notes", pgxmock.AnyArg(), pgxmock.AnyArg(), testTransactionID,
+					"Some notes", pgxmock.AnyArg(), pgxmock.AnyArg(), testTransactionID, testAccountTime,
+					testTransactionDate, statusCleared,
 				).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 				mock.ExpectCommit().WillReturnError(errors.New("some db error"))
+				mock.ExpectRollback()
 			},
 			func(_ string) (*uuid.UUID, *uuid.UUID) {
 				return &testPayeeID, &testCategoryID
@@ -459,12 +936,13 @@ func TestUpdateTransactions(t *testing.T) {
 			func(mock pgxmock.PgxPoolIface) {
 				mock.ExpectQuery("SELECT *").WillReturnRows(pgxmock.NewRows(transactionsRowCols).AddRow(testTransactionID,
 					testAccountID, &testCategoryID, &testPayeeID, "Some transaction", 4.20, 4.20, "Some notes",
-					&testAccountTime, testAccountTime, testAccountTime))
+					&testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime))
 				mock.ExpectBeginTx(pgx.TxOptions{})
 				mock.ExpectExec("SAVEPOINT sp1").WillReturnResult(pgxmock.NewResult("SAVEPOINT", 1))
 				mock.ExpectExec("UPDATE transactions").WithArgs(
 					testAccountID, &testCategoryID, &testPayeeID, 4.20, 4.20, "Some transaction",
-					"Some notes", pgxmock.AnyArg(), pgxmock.AnyArg(), testTransactionID,
+					"Some notes", pgxmock.AnyArg(), pgxmock.AnyArg(), testTransactionID, testAccountTime,
+					testTransactionDate, statusCleared,
 				).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 				mock.ExpectCommit()
 			},
@@ -492,6 +970,12 @@ func TestUpdateTransactions(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 			}
+
+			require.NoError(t, mockDB.ExpectationsWereMet())
 		})
 	}
 }
+
+func allTransactionsFilterArgs(accountIDs []uuid.UUID, query string, page ...any) []any {
+	return append([]any{accountIDs, query, []string{}, "", nil, nil, nil, nil, "", nil, false, nil, nil}, page...)
+}
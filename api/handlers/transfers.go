@@ -0,0 +1,527 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Transfer model.
+type Transfer struct {
+	ID            uuid.UUID  `json:"id"`
+	FromAccountID uuid.UUID  `json:"fromAccountId"`
+	ToAccountID   uuid.UUID  `json:"toAccountId"`
+	Amount        float64    `json:"amount"`
+	Name          string     `json:"name"`
+	Notes         string     `json:"notes,omitempty"`
+	Date          time.Time  `json:"date"`
+	Status        string     `json:"status"`
+	ClearedAt     *time.Time `json:"clearedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// TransferLink model, linking an existing transaction to the transaction of another account as a transfer.
+type TransferLink struct {
+	TransactionID uuid.UUID `json:"transactionId"`
+	TransferID    uuid.UUID `json:"transferId"`
+}
+
+var (
+	errTransferSameAccount = errors.New("transfer accounts must be different")
+	errTransferAmount      = errors.New("transfer amount must be positive")
+	errTransferNotFound    = errors.New("transfer not found")
+	errTransferAccount     = errors.New("transfer accounts must exist and not be trashed")
+	errTransferLink        = errors.New("transactions must be unlinked, in different accounts and with opposite amounts")
+)
+
+const (
+	queryCreateTransferTransaction = `INSERT INTO transactions (id, account_id, credit, debit, name, notes,` +
+		` cleared_at, created_at, updated_at, transfer_id, date, status)` +
+		` VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+	// queryCountTransferAccounts counts the accounts of a transfer that exist outside of the trash.
+	queryCountTransferAccounts = `SELECT COUNT(*) FROM accounts WHERE id IN ($1, $2) AND deleted_at IS NULL`
+	// queryLockTransferLegs locks the transactions of a transfer, the source leg, which is debited, first.
+	queryLockTransferLegs = `SELECT id FROM transactions WHERE transfer_id=$1 AND deleted_at IS NULL` +
+		` ORDER BY debit DESC, id FOR UPDATE`
+	queryUpdateTransferLeg = `UPDATE transactions SET account_id=$2, credit=$3, debit=$4, name=$5, notes=$6,` +
+		` cleared_at=$7, updated_at=$8, date=$9, status=$10, reconciliation_id=NULL WHERE id=$1`
+	queryDeleteTransfer = `UPDATE transactions SET deleted_at=$2 WHERE transfer_id=$1 AND deleted_at IS NULL`
+	// queryHasTransferCandidate checks for an unlinked transaction from another account than $1, dated $4, that
+	// could be the other side of a transaction crediting $2 and debiting $3.
+	queryHasTransferCandidate = `SELECT EXISTS (SELECT 1 FROM transactions WHERE account_id <> $1` +
+		` AND transfer_id IS NULL AND deleted_at IS NULL AND credit = $3 AND debit = $2 AND date = $4::date)`
+	// queryGetTransferCandidates returns the unlinked transactions t from other accounts that could be the other side
+	// of the unlinked transaction $2 of account $1: opposite amounts on the same day.
+	queryGetTransferCandidates = queryTransactionsWithNames + ` JOIN transactions AS o ON o.account_id=$1` +
+		` AND o.id=$2 AND o.transfer_id IS NULL AND o.deleted_at IS NULL WHERE t.account_id <> $1` +
+		` AND t.transfer_id IS NULL AND t.deleted_at IS NULL AND t.credit = o.debit AND t.debit = o.credit` +
+		` AND t.date = o.date ORDER BY t.id`
+	// queryLinkTransfer pairs the transaction $2 of account $1 with the transaction $3 of another account as a
+	// transfer, as long as neither is linked yet and their amounts are opposite.
+	queryLinkTransfer = `UPDATE transactions SET transfer_id=$4, updated_at=$5 WHERE id IN ($2, $3)` +
+		` AND transfer_id IS NULL AND deleted_at IS NULL AND EXISTS (SELECT 1 FROM transactions AS t` +
+		` JOIN transactions AS o ON o.id=$3 AND o.account_id <> t.account_id AND o.credit = t.debit` +
+		` AND o.debit = t.credit WHERE t.account_id=$1 AND t.id=$2)`
+)
+
+func (h *Handler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	var transfer Transfer
+
+	err := json.NewDecoder(r.Body).Decode(&transfer)
+	if err != nil {
+		slog.Error("error decoding create transfer request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	err = validateTransfer(transfer)
+	if err != nil {
+		slog.Error("error validating transfer", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	transfer.ID, err = uuid.NewV7()
+	if err != nil {
+		slog.Error("error creating transfer id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	transfer.CreatedAt = time.Now()
+	transfer.UpdatedAt = transfer.CreatedAt
+	transfer.Date = transactionDate(transfer.Date, transfer.CreatedAt)
+
+	transfer.Status, transfer.ClearedAt, err = transactionClearing(transfer.Status, transfer.ClearedAt, "",
+		transfer.CreatedAt)
+	if err != nil {
+		slog.Error("error validating transfer status", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	err = h.createTransfer(r.Context(), transfer)
+	if err != nil {
+		slog.Error("error creating transfer", "error", err)
+		buildErrorResponse(w, err.Error(), transferErrorStatus(err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	err = json.NewEncoder(w).Encode(transfer)
+	if err != nil {
+		slog.Error("error encoding transfer response", "error", err)
+	}
+}
+
+func (h *Handler) UpdateTransfer(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	transferID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing transfer id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var transfer Transfer
+
+	err = json.NewDecoder(r.Body).Decode(&transfer)
+	if err != nil {
+		slog.Error("error decoding update transfer request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	err = validateTransfer(transfer)
+	if err != nil {
+		slog.Error("error validating transfer", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	transfer.UpdatedAt = time.Now()
+	transfer.Date = transactionDate(transfer.Date, transfer.UpdatedAt)
+
+	transfer.Status, transfer.ClearedAt, err = transactionClearing(transfer.Status, transfer.ClearedAt, "",
+		transfer.UpdatedAt)
+	if err != nil {
+		slog.Error("error validating transfer status", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	if !h.checkReconciled(w, r, queryIsTransferReconciled, transferID) {
+		return
+	}
+
+	err = h.updateTransfer(r.Context(), transferID, transfer)
+	if err != nil {
+		slog.Error("error updating transfer in database", "error", err)
+		buildErrorResponse(w, err.Error(), transferErrorStatus(err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) DeleteTransfer(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	transferID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing transfer id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	if !h.checkReconciled(w, r, queryIsTransferReconciled, transferID) {
+		return
+	}
+
+	_, err = h.db.Exec(r.Context(), queryDeleteTransfer, transferID, time.Now())
+	if err != nil {
+		slog.Error("error deleting transfer in database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTransferCandidates returns the transactions that could be the other side of a transaction, for the client to
+// confirm one with LinkTransfer.
+func (h *Handler) GetTransferCandidates(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	tID := r.PathValue("tId")
+
+	accountID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing account id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	transactionID, err := uuid.Parse(tID)
+	if err != nil {
+		slog.Error("error parsing transaction id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	rows, err := h.db.Query(r.Context(), queryGetTransferCandidates, accountID, transactionID)
+	if err != nil {
+		slog.Error("error getting transfer candidates from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+	defer rows.Close()
+
+	transactions := []Transaction{}
+
+	for rows.Next() {
+		var transaction Transaction
+
+		err := scanTransactionWithNames(rows, &transaction)
+		if err != nil {
+			slog.Error("error scanning transfer candidates row from database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		transactions = append(transactions, transaction)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error reading transfer candidates rows from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(transactions)
+	if err != nil {
+		slog.Error("error encoding transfer candidates response", "error", err)
+	}
+}
+
+// LinkTransfer links a transaction to the transaction of another account given in the body, making them a transfer.
+func (h *Handler) LinkTransfer(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	tID := r.PathValue("tId")
+
+	accountID, err := uuid.Parse(id)
+	if err != nil {
+		slog.Error("error parsing account id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	transactionID, err := uuid.Parse(tID)
+	if err != nil {
+		slog.Error("error parsing transaction id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var link TransferLink
+
+	err = json.NewDecoder(r.Body).Decode(&link)
+	if err != nil {
+		slog.Error("error decoding link transfer request", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	link.TransferID, err = uuid.NewV7()
+	if err != nil {
+		slog.Error("error creating transfer id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	err = h.linkTransfer(r.Context(), accountID, transactionID, link)
+	if err != nil {
+		slog.Error("error linking transfer", "error", err)
+		buildErrorResponse(w, err.Error(), transferErrorStatus(err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	err = json.NewEncoder(w).Encode(link)
+	if err != nil {
+		slog.Error("error encoding transfer link response", "error", err)
+	}
+}
+
+func (h *Handler) linkTransfer(ctx context.Context, accountID, transactionID uuid.UUID, link TransferLink) error {
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error creating database txn: %w", err)
+	}
+
+	defer func(ctx context.Context) {
+		if err != nil {
+			rollBackErr := tx.Rollback(ctx)
+			if rollBackErr != nil {
+				slog.Error("error rolling back database txn", "error", rollBackErr)
+			}
+		}
+	}(ctx)
+
+	result, err := tx.Exec(ctx, queryLinkTransfer, accountID, transactionID, link.TransactionID, link.TransferID,
+		time.Now())
+	if err != nil {
+		return fmt.Errorf("error linking transfer transactions: %w", err)
+	}
+
+	// Both transactions are linked, or neither, when one of them was linked in the meantime.
+	if result.RowsAffected() != 2 { //nolint: mnd
+		err = errTransferLink
+
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("error committing database txn: %w", err)
+	}
+
+	return nil
+}
+
+func (h *Handler) createTransfer(ctx context.Context, transfer Transfer) error {
+	fromTransactionID, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("error creating transaction id: %w", err)
+	}
+
+	toTransactionID, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("error creating transaction id: %w", err)
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		slog.Error("error creating database txn", "error", err)
+
+		return fmt.Errorf("error creating database txn: %w", err)
+	}
+
+	defer func(ctx context.Context) {
+		if err != nil {
+			rollBackErr := tx.Rollback(ctx)
+			if rollBackErr != nil {
+				slog.Error("error rolling back database txn", "error", rollBackErr)
+			}
+		}
+	}(ctx)
+
+	err = checkTransferAccounts(ctx, tx, transfer)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, queryCreateTransferTransaction, fromTransactionID, transfer.FromAccountID, 0.0,
+		transfer.Amount, transfer.Name, transfer.Notes, transfer.ClearedAt, transfer.CreatedAt, transfer.UpdatedAt,
+		transfer.ID, transfer.Date, transfer.Status)
+	if err != nil {
+		slog.Error("error inserting transfer source transaction", "error", err)
+
+		return fmt.Errorf("error inserting transfer source transaction: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, queryCreateTransferTransaction, toTransactionID, transfer.ToAccountID, transfer.Amount,
+		0.0, transfer.Name, transfer.Notes, transfer.ClearedAt, transfer.CreatedAt, transfer.UpdatedAt, transfer.ID,
+		transfer.Date, transfer.Status)
+	if err != nil {
+		slog.Error("error inserting transfer destination transaction", "error", err)
+
+		return fmt.Errorf("error inserting transfer destination transaction: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		slog.Error("error committing database txn", "error", err)
+
+		return fmt.Errorf("error committing database txn: %w", err)
+	}
+
+	return nil
+}
+
+// updateTransfer rewrites both legs of a transfer, moving them to the accounts of the transfer if they changed.
+func (h *Handler) updateTransfer(ctx context.Context, transferID uuid.UUID, transfer Transfer) error {
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error creating database txn: %w", err)
+	}
+
+	defer func(ctx context.Context) {
+		if err != nil {
+			rollBackErr := tx.Rollback(ctx)
+			if rollBackErr != nil {
+				slog.Error("error rolling back database txn", "error", rollBackErr)
+			}
+		}
+	}(ctx)
+
+	err = checkTransferAccounts(ctx, tx, transfer)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, queryLockTransferLegs, transferID)
+	if err != nil {
+		return fmt.Errorf("error locking transfer transactions: %w", err)
+	}
+
+	legs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return fmt.Errorf("error locking transfer transactions: %w", err)
+	}
+
+	if len(legs) != 2 { //nolint: mnd
+		err = errTransferNotFound
+
+		return err
+	}
+
+	_, err = tx.Exec(ctx, queryUpdateTransferLeg, legs[0], transfer.FromAccountID, 0.0, transfer.Amount,
+		transfer.Name, transfer.Notes, transfer.ClearedAt, transfer.UpdatedAt, transfer.Date, transfer.Status)
+	if err != nil {
+		return fmt.Errorf("error updating transfer source transaction: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, queryUpdateTransferLeg, legs[1], transfer.ToAccountID, transfer.Amount, 0.0,
+		transfer.Name, transfer.Notes, transfer.ClearedAt, transfer.UpdatedAt, transfer.Date, transfer.Status)
+	if err != nil {
+		return fmt.Errorf("error updating transfer destination transaction: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("error committing database txn: %w", err)
+	}
+
+	return nil
+}
+
+// checkTransferAccounts returns errTransferAccount unless both accounts of the transfer exist outside of the trash.
+func checkTransferAccounts(ctx context.Context, db dbExecutor, transfer Transfer) error {
+	var count int
+
+	err := db.QueryRow(ctx, queryCountTransferAccounts, transfer.FromAccountID, transfer.ToAccountID).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("error getting transfer accounts: %w", err)
+	}
+
+	if count != 2 { //nolint: mnd
+		return errTransferAccount
+	}
+
+	return nil
+}
+
+func transferErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errTransferAccount):
+		return http.StatusBadRequest
+	case errors.Is(err, errTransferNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errTransferLink):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func validateTransfer(transfer Transfer) error {
+	if transfer.FromAccountID == transfer.ToAccountID {
+		return errTransferSameAccount
+	}
+
+	if transfer.Amount <= 0 {
+		return errTransferAmount
+	}
+
+	return nil
+}
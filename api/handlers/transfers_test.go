@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+var (
+	testToAccountID  = uuid.MustParse("01927f3e-6ecf-7091-987f-8aa23adcda10")
+	testTransferID   = uuid.MustParse("01927f3e-6ecf-7091-987f-8aa23adcda11")
+	testTransferBody = `{"fromAccountId":"` + testAccountID.String() + `","toAccountId":"` + testToAccountID.String() +
+		`","amount":4.20,"name":"Card payment"}`
+)
+
+func TestCreateTransfer(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodPost, "/v1/transfers", false, strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad request", http.MethodPost, "/v1/transfers", true, strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusBadRequest, "invalid character",
+		},
+		{
+			"error due to same accounts", http.MethodPost, "/v1/transfers", true,
+			strings.NewReader(`{"fromAccountId":"` + testAccountID.String() + `","toAccountId":"` + testAccountID.String() + `","amount":4.20}`),
+			nil, nil,
+			http.StatusBadRequest, "transfer accounts must be different",
+		},
+		{
+			"error due to invalid amount", http.MethodPost, "/v1/transfers", true,
+			strings.NewReader(`{"fromAccountId":"` + testAccountID.String() + `","toAccountId":"` + testToAccountID.String() + `","amount":-1}`),
+			nil, nil,
+			http.StatusBadRequest, "transfer amount must be positive",
+		},
+		{
+			"error creating database txn", http.MethodPost, "/v1/transfers", true, strings.NewReader(testTransferBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin().WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "error creating database txn",
+		},
+		{
+			"error due to trashed account", http.MethodPost, "/v1/transfers", true, strings.NewReader(testTransferBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testAccountID, testToAccountID).WillReturnRows(
+					pgxmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectRollback()
+			},
+			http.StatusBadRequest, "transfer accounts must exist",
+		},
+		{
+			"error inserting source transaction", http.MethodPost, "/v1/transfers", true, strings.NewReader(testTransferBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testAccountID, testToAccountID).WillReturnRows(
+					pgxmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectExec("INSERT INTO transactions").WithArgs(pgxmock.AnyArg(), testAccountID, 0.0, 4.20, "Card payment", "",
+					pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), statusUncleared).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "error inserting transfer source transaction",
+		},
+		{
+			"error inserting destination transaction", http.MethodPost, "/v1/transfers", true, strings.NewReader(testTransferBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testAccountID, testToAccountID).WillReturnRows(
+					pgxmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectExec("INSERT INTO transactions").WithArgs(pgxmock.AnyArg(), testAccountID, 0.0, 4.20, "Card payment", "",
+					pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), statusUncleared).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectExec("INSERT INTO transactions").WithArgs(pgxmock.AnyArg(), testToAccountID, 4.20, 0.0, "Card payment", "",
+					pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), statusUncleared).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "error inserting transfer destination transaction",
+		},
+		{
+			"success creating transfer", http.MethodPost, "/v1/transfers", true, strings.NewReader(testTransferBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testAccountID, testToAccountID).WillReturnRows(
+					pgxmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectExec("INSERT INTO transactions").WithArgs(pgxmock.AnyArg(), testAccountID, 0.0, 4.20, "Card payment", "",
+					pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), statusUncleared).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectExec("INSERT INTO transactions").WithArgs(pgxmock.AnyArg(), testToAccountID, 4.20, 0.0, "Card payment", "",
+					pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), statusUncleared).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusCreated, testToAccountID.String(),
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestUpdateTransfer(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodPatch, "/v1/transfers/invalid-uuid", false, strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad transfer id", http.MethodPatch, "/v1/transfers/invalid-uuid", true, strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error due to bad request", http.MethodPatch, "/v1/transfers/" + testTransferID.String(), true, strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusBadRequest, "invalid character",
+		},
+		{
+			"error due to same accounts", http.MethodPatch, "/v1/transfers/" + testTransferID.String(), true,
+			strings.NewReader(`{"fromAccountId":"` + testAccountID.String() + `","toAccountId":"` + testAccountID.String() + `","amount":4.20}`),
+			nil, nil,
+			http.StatusBadRequest, "transfer accounts must be different",
+		},
+		{
+			"error due to trashed account", http.MethodPatch, "/v1/transfers/" + testTransferID.String(), true, strings.NewReader(testTransferBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(testTransferID).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testAccountID, testToAccountID).WillReturnRows(
+					pgxmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectRollback()
+			},
+			http.StatusBadRequest, "transfer accounts must exist",
+		},
+		{
+			"error due to transfer not found", http.MethodPatch, "/v1/transfers/" + testTransferID.String(), true, strings.NewReader(testTransferBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(testTransferID).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testAccountID, testToAccountID).WillReturnRows(
+					pgxmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectQuery("SELECT id FROM transactions").WithArgs(testTransferID).WillReturnRows(
+					pgxmock.NewRows([]string{"id"}).AddRow(testTransactionID))
+				mock.ExpectRollback()
+			},
+			http.StatusNotFound, "transfer not found",
+		},
+		{
+			"error updating transfer in database", http.MethodPatch, "/v1/transfers/" + testTransferID.String(), true, strings.NewReader(testTransferBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(testTransferID).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testAccountID, testToAccountID).WillReturnRows(
+					pgxmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectQuery("SELECT id FROM transactions").WithArgs(testTransferID).WillReturnRows(
+					pgxmock.NewRows([]string{"id"}).AddRow(testTransactionID).AddRow(testNextTransactionID))
+				mock.ExpectExec("UPDATE transactions").WithArgs(testTransactionID, testAccountID, 0.0, 4.20, "Card payment", "",
+					pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), statusUncleared).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectExec("UPDATE transactions").WithArgs(testNextTransactionID, testToAccountID, 4.20, 0.0, "Card payment", "",
+					pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), statusUncleared).WillReturnError(pgx.ErrTxClosed)
+				mock.ExpectRollback()
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success moving transfer to other accounts", http.MethodPatch, "/v1/transfers/" + testTransferID.String(), true, strings.NewReader(testTransferBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(testTransferID).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT COUNT").WithArgs(testAccountID, testToAccountID).WillReturnRows(
+					pgxmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectQuery("SELECT id FROM transactions").WithArgs(testTransferID).WillReturnRows(
+					pgxmock.NewRows([]string{"id"}).AddRow(testTransactionID).AddRow(testNextTransactionID))
+				mock.ExpectExec("UPDATE transactions").WithArgs(testTransactionID, testAccountID, 0.0, 4.20, "Card payment", "",
+					pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), statusUncleared).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectExec("UPDATE transactions").WithArgs(testNextTransactionID, testToAccountID, 4.20, 0.0, "Card payment", "",
+					pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), statusUncleared).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+			http.StatusNoContent, "",
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestDeleteTransfer(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodDelete, "/v1/transfers/invalid-uuid", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to bad transfer id", http.MethodDelete, "/v1/transfers/invalid-uuid", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error deleting transfer in database", http.MethodDelete, "/v1/transfers/" + testTransferID.String(), true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(testTransferID).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectExec("UPDATE transactions SET deleted_at").WithArgs(testTransferID, pgxmock.AnyArg()).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error due to reconciled transfer", http.MethodDelete, "/v1/transfers/" + testTransferID.String(), true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(testTransferID).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			http.StatusConflict, "transaction is reconciled",
+		},
+		{
+			"success deleting transfer", http.MethodDelete, "/v1/transfers/" + testTransferID.String(), true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(testTransferID).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectExec("UPDATE transactions SET deleted_at").WithArgs(testTransferID, pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+			},
+			http.StatusNoContent, "",
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestGetTransferCandidates(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to bad transaction id", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions/invalid-uuid/transfers", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error getting transfer candidates from database", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String() + "/transfers", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("JOIN transactions AS o").WithArgs(testAccountID, testTransactionID).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success getting transfer candidates", http.MethodGet, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String() + "/transfers", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("JOIN transactions AS o").WithArgs(testAccountID, testTransactionID).WillReturnRows(pgxmock.NewRows(transactionRowCols).
+					AddRow(testNextTransactionID, testToAccountID, testNullID, testNullID, "Card payment", 4.20, 0.0, "",
+						&testAccountTime, testAccountTime, testAccountTime, testNullID, testNullID, testTransactionDate, statusCleared, testNullTime, &testAccountName, nil,
+						nil, []string{}))
+			},
+			http.StatusOK, `[{"id":"` + testNextTransactionID.String() + `"`,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestLinkTransfer(t *testing.T) {
+	testLinkBody := `{"transactionId":"` + testNextTransactionID.String() + `"}`
+
+	tests := []testCase{
+		{
+			"error due to bad request", http.MethodPost, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String() + "/transfers", true,
+			strings.NewReader("invalid-body"),
+			nil, nil,
+			http.StatusBadRequest, "invalid character",
+		},
+		{
+			"error due to transactions that cannot be linked", http.MethodPost, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String() + "/transfers", true,
+			strings.NewReader(testLinkBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE transactions SET transfer_id").WithArgs(testAccountID, testTransactionID, testNextTransactionID,
+					pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectRollback()
+			},
+			http.StatusConflict, "transactions must be unlinked",
+		},
+		{
+			"success linking transfer", http.MethodPost, "/v1/accounts/" + testAccountID.String() + "/transactions/" + testTransactionID.String() + "/transfers", true,
+			strings.NewReader(testLinkBody),
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE transactions SET transfer_id").WithArgs(testAccountID, testTransactionID, testNextTransactionID,
+					pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+				mock.ExpectCommit()
+			},
+			http.StatusCreated, `"transactionId":"` + testNextTransactionID.String() + `","transferId"`,
+		},
+	}
+	executeTests(t, tests)
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	uuid "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// TrashItem model.
+type TrashItem struct {
+	Kind      string    `json:"kind"`
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deletedAt"`
+	PurgeAt   time.Time `json:"purgeAt"`
+}
+
+// trashKind holds the queries restoring an entity of a kind from the trash. Children deleted along with their parent
+// share its deletion time, which is how a restore finds them.
+type trashKind struct {
+	// queryGetDeleted returns when the entity was deleted and whether its parent is in the trash too.
+	queryGetDeleted string
+	// queryRestore restores the entity $1 along with its children deleted at $2.
+	queryRestore string
+}
+
+// uniqueViolation is the Postgres error code for unique constraint violations.
+const uniqueViolation = "23505"
+
+var (
+	errTrashKind          = errors.New("kind must be one of accounts, groups, categories, payees or transactions")
+	errNotInTrash         = errors.New("not found in trash")
+	errTrashParentDeleted = errors.New("parent is in the trash, restore it first")
+)
+
+var trashKinds = map[string]trashKind{
+	"accounts": {
+		queryGetDeleted: `SELECT deleted_at, false FROM accounts WHERE id=$1 AND deleted_at IS NOT NULL`,
+		queryRestore: `WITH restored AS (UPDATE transactions SET deleted_at=NULL WHERE account_id=$1 AND` +
+			` deleted_at=$2) UPDATE accounts SET deleted_at=NULL WHERE id=$1`,
+	},
+	"groups": {
+		queryGetDeleted: `SELECT deleted_at, false FROM groups WHERE id=$1 AND deleted_at IS NOT NULL`,
+		queryRestore: `WITH restored AS (UPDATE categories SET deleted_at=NULL WHERE group_id=$1 AND` +
+			` deleted_at=$2) UPDATE groups SET deleted_at=NULL WHERE id=$1`,
+	},
+	"categories": {
+		queryGetDeleted: `SELECT c.deleted_at, g.deleted_at IS NOT NULL FROM categories AS c LEFT JOIN groups AS g` +
+			` ON g.id = c.group_id WHERE c.id=$1 AND c.deleted_at IS NOT NULL`,
+		queryRestore: `UPDATE categories SET deleted_at=NULL WHERE id=$1 AND deleted_at=$2`,
+	},
+	"payees": {
+		queryGetDeleted: `SELECT deleted_at, false FROM payees WHERE id=$1 AND deleted_at IS NOT NULL`,
+		queryRestore:    `UPDATE payees SET deleted_at=NULL WHERE id=$1 AND deleted_at=$2`,
+	},
+	"transactions": {
+		queryGetDeleted: `SELECT t.deleted_at, a.deleted_at IS NOT NULL FROM transactions AS t JOIN accounts AS a` +
+			` ON a.id = t.account_id WHERE t.id=$1 AND t.deleted_at IS NOT NULL`,
+		queryRestore: `UPDATE transactions SET deleted_at=NULL WHERE (id=$1 OR transfer_id IN (SELECT transfer_id` +
+			` FROM transactions WHERE id=$1)) AND deleted_at=$2`,
+	},
+}
+
+// queryGetTrash lists the deleted entities of kind $1 (all when empty), leaving out children deleted along with
+// their parent.
+const queryGetTrash = `SELECT * FROM (SELECT 'accounts' AS kind, id, name, deleted_at FROM accounts` +
+	` WHERE deleted_at IS NOT NULL UNION ALL SELECT 'groups', id, name, deleted_at FROM groups` +
+	` WHERE deleted_at IS NOT NULL UNION ALL SELECT 'categories', c.id, COALESCE(c.name, ''), c.deleted_at` +
+	` FROM categories AS c WHERE c.deleted_at IS NOT NULL AND NOT EXISTS (SELECT 1 FROM groups AS g` +
+	` WHERE g.id = c.group_id AND g.deleted_at = c.deleted_at) UNION ALL SELECT 'payees', id, name, deleted_at` +
+	` FROM payees WHERE deleted_at IS NOT NULL UNION ALL SELECT 'transactions', t.id, COALESCE(t.name, ''),` +
+	` t.deleted_at FROM transactions AS t WHERE t.deleted_at IS NOT NULL AND NOT EXISTS (SELECT 1` +
+	` FROM accounts AS a WHERE a.id = t.account_id AND a.deleted_at = t.deleted_at)) AS trash` +
+	` WHERE ($1 = '' OR kind = $1) ORDER BY deleted_at DESC, id`
+
+func (h *Handler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	kind := r.URL.Query().Get("kind")
+
+	if _, ok := trashKinds[kind]; kind != "" && !ok {
+		slog.Error("error parsing trash kind", "error", errTrashKind)
+		buildErrorResponse(w, errTrashKind.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	rows, err := h.db.Query(r.Context(), queryGetTrash, kind)
+	if err != nil {
+		slog.Error("error getting trash from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+	defer rows.Close()
+
+	items := []TrashItem{}
+
+	for rows.Next() {
+		var item TrashItem
+
+		err := rows.Scan(&item.Kind, &item.ID, &item.Name, &item.DeletedAt)
+		if err != nil {
+			slog.Error("error scanning trash row from database", "error", err)
+			buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		item.PurgeAt = item.DeletedAt.Add(h.cfg.TrashRetention)
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("error reading trash rows from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(map[string]interface{}{"total": len(items), "items": items})
+	if err != nil {
+		slog.Error("error encoding trash response", "error", err)
+	}
+}
+
+// RestoreTrash brings an entity back from the trash, along with the children that were deleted with it.
+func (h *Handler) RestoreTrash(w http.ResponseWriter, r *http.Request) {
+	kind, ok := trashKinds[r.PathValue("kind")]
+	if !ok {
+		slog.Error("error parsing trash kind", "error", errTrashKind)
+		buildErrorResponse(w, errTrashKind.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		slog.Error("error parsing trash id", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var (
+		deletedAt     time.Time
+		parentDeleted bool
+	)
+
+	err = h.db.QueryRow(r.Context(), kind.queryGetDeleted, id).Scan(&deletedAt, &parentDeleted)
+	if errors.Is(err, pgx.ErrNoRows) {
+		slog.Error("error getting trash from database", "error", errNotInTrash)
+		buildErrorResponse(w, errNotInTrash.Error(), http.StatusNotFound)
+
+		return
+	}
+
+	if err != nil {
+		slog.Error("error getting trash from database", "error", err)
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	if parentDeleted {
+		slog.Error("error restoring from trash", "error", errTrashParentDeleted)
+		buildErrorResponse(w, errTrashParentDeleted.Error(), http.StatusConflict)
+
+		return
+	}
+
+	_, err = h.db.Exec(r.Context(), kind.queryRestore, id, deletedAt)
+	if err != nil {
+		slog.Error("error restoring from trash in database", "error", err)
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			buildErrorResponse(w, err.Error(), http.StatusConflict)
+
+			return
+		}
+
+		buildErrorResponse(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+}
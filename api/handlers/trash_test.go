@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+var (
+	trashRowCols   = []string{"kind", "id", "name", "deleted_at"}
+	trashDeletedAt = []string{"deleted_at", "parent_deleted"}
+)
+
+func TestGetTrash(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodGet, "/v1/trash", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to unknown kind", http.MethodGet, "/v1/trash?kind=tags", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "kind must be one of",
+		},
+		{
+			"error getting trash from database", http.MethodGet, "/v1/trash", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM").WithArgs("").WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error reading trash rows from database", http.MethodGet, "/v1/trash", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM").WithArgs("").WillReturnRows(pgxmock.NewRows(trashRowCols).
+					RowError(0, errors.New("some error in db")))
+			},
+			http.StatusInternalServerError, "some error in db",
+		},
+		{
+			"success getting trash of a kind", http.MethodGet, "/v1/trash?kind=accounts", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT \\* FROM").WithArgs("accounts").WillReturnRows(pgxmock.NewRows(trashRowCols).
+					AddRow("accounts", testAccountID, testAccountName, testAccountTime))
+			},
+			http.StatusOK, `"kind":"accounts","id":"` + testAccountID.String() + `"`,
+		},
+	}
+	executeTests(t, tests)
+}
+
+func TestRestoreTrash(t *testing.T) {
+	tests := []testCase{
+		{
+			"error due to auth", http.MethodPost, "/v1/trash/accounts/" + testAccountID.String() + "/restore", false, nil,
+			nil, nil,
+			http.StatusUnauthorized, "Unauthorized",
+		},
+		{
+			"error due to unknown kind", http.MethodPost, "/v1/trash/tags/" + testTagID.String() + "/restore", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "kind must be one of",
+		},
+		{
+			"error due to bad id", http.MethodPost, "/v1/trash/accounts/invalid-uuid/restore", true, nil,
+			nil, nil,
+			http.StatusBadRequest, "invalid UUID",
+		},
+		{
+			"error due to not in trash", http.MethodPost, "/v1/trash/payees/" + testPayeeID.String() + "/restore", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT deleted_at").WithArgs(testPayeeID).WillReturnError(pgx.ErrNoRows)
+			},
+			http.StatusNotFound, "not found in trash",
+		},
+		{
+			"error getting trash from database", http.MethodPost, "/v1/trash/payees/" + testPayeeID.String() + "/restore", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT deleted_at").WithArgs(testPayeeID).WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"error due to parent in trash", http.MethodPost, "/v1/trash/transactions/" + testTransactionID.String() + "/restore", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT t.deleted_at").WithArgs(testTransactionID).WillReturnRows(pgxmock.NewRows(trashDeletedAt).
+					AddRow(testAccountTime, true))
+			},
+			http.StatusConflict, "parent is in the trash",
+		},
+		{
+			"error due to name taken", http.MethodPost, "/v1/trash/payees/" + testPayeeID.String() + "/restore", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT deleted_at").WithArgs(testPayeeID).WillReturnRows(pgxmock.NewRows(trashDeletedAt).
+					AddRow(testAccountTime, false))
+				mock.ExpectExec("UPDATE payees SET deleted_at=NULL").WithArgs(testPayeeID, testAccountTime).
+					WillReturnError(&pgconn.PgError{Code: uniqueViolation, Message: "duplicate key value"})
+			},
+			http.StatusConflict, "duplicate key value",
+		},
+		{
+			"error restoring in database", http.MethodPost, "/v1/trash/payees/" + testPayeeID.String() + "/restore", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT deleted_at").WithArgs(testPayeeID).WillReturnRows(pgxmock.NewRows(trashDeletedAt).
+					AddRow(testAccountTime, false))
+				mock.ExpectExec("UPDATE payees SET deleted_at=NULL").WithArgs(testPayeeID, testAccountTime).
+					WillReturnError(pgx.ErrTxClosed)
+			},
+			http.StatusInternalServerError, "tx is closed",
+		},
+		{
+			"success restoring account with its transactions", http.MethodPost, "/v1/trash/accounts/" + testAccountID.String() + "/restore", true, nil,
+			nil,
+			func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT deleted_at").WithArgs(testAccountID).WillReturnRows(pgxmock.NewRows(trashDeletedAt).
+					AddRow(testAccountTime, false))
+				mock.ExpectExec("UPDATE accounts SET deleted_at=NULL").WithArgs(testAccountID, testAccountTime).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			http.StatusNoContent, "",
+		},
+	}
+	executeTests(t, tests)
+}
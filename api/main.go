@@ -13,6 +13,7 @@ import (
 	"vitta/config"
 	"vitta/database"
 	"vitta/handlers"
+	"vitta/storage"
 )
 
 func main() {
@@ -42,7 +43,19 @@ func main() {
 		os.Exit(1)
 	}
 
-	handler := handlers.New(cfg, db, adapters)
+	store, err := storage.NewLocal(cfg.AttachmentsPath)
+	if err != nil {
+		slog.Error("error initializing attachments storage", "error", err)
+		os.Exit(1)
+	}
+
+	handler := handlers.New(cfg, db, adapters, store)
+
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+
+	go handlers.NewScheduler(cfg, db).Run(schedulerCtx)
+	go handlers.NewPurger(cfg, db, store).Run(schedulerCtx)
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Port),
@@ -69,6 +82,8 @@ func main() {
 	<-shutdownSignal
 	slog.Info("stopping http server")
 
+	stopScheduler()
+
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
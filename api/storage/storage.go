@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// Storage for attachment contents.
+type Storage interface {
+	Put(ctx context.Context, key string, content io.Reader) (int64, error)
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Local storage on the filesystem.
+type Local struct {
+	root string
+}
+
+var errInvalidKey = errors.New("invalid storage key")
+
+func NewLocal(root string) (*Local, error) {
+	err := os.MkdirAll(root, 0o750) //nolint: mnd
+	if err != nil {
+		slog.Error("error creating storage directory", "error", err)
+
+		return nil, fmt.Errorf("error creating storage directory: %w", err)
+	}
+
+	return &Local{root: root}, nil
+}
+
+func (l *Local) Put(_ context.Context, key string, content io.Reader) (int64, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return 0, err
+	}
+
+	file, err := os.CreateTemp(l.root, ".upload-*")
+	if err != nil {
+		return 0, fmt.Errorf("error creating file: %w", err)
+	}
+
+	size, err := io.Copy(file, content)
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+
+		return 0, fmt.Errorf("error writing file: %w", err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		os.Remove(file.Name())
+
+		return 0, fmt.Errorf("error closing file: %w", err)
+	}
+
+	err = os.Rename(file.Name(), path)
+	if err != nil {
+		os.Remove(file.Name())
+
+		return 0, fmt.Errorf("error storing file: %w", err)
+	}
+
+	return size, nil
+}
+
+func (l *Local) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+
+	return file, nil
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error deleting file: %w", err)
+	}
+
+	return nil
+}
+
+func (l *Local) path(key string) (string, error) {
+	if key == "" || key != filepath.Base(key) || key[0] == '.' {
+		return "", fmt.Errorf("%w: %q", errInvalidKey, key)
+	}
+
+	return filepath.Join(l.root, key), nil
+}
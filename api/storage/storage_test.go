@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLocal(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "attachments")
+
+	local, err := NewLocal(root)
+	require.NoError(t, err)
+	assert.NotNil(t, local)
+	assert.DirExists(t, root)
+
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, []byte("content"), 0o600))
+
+	_, err = NewLocal(filepath.Join(file, "attachments"))
+	assert.ErrorContains(t, err, "error creating storage directory")
+}
+
+func TestLocal(t *testing.T) {
+	local, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	ctx := context.TODO()
+
+	size, err := local.Put(ctx, "receipt", strings.NewReader("some receipt"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), size)
+
+	reader, err := local.Get(ctx, "receipt")
+	require.NoError(t, err)
+
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	assert.Equal(t, "some receipt", string(content))
+
+	require.NoError(t, local.Delete(ctx, "receipt"))
+	require.NoError(t, local.Delete(ctx, "receipt"))
+
+	_, err = local.Get(ctx, "receipt")
+	assert.ErrorContains(t, err, "error opening file")
+}
+
+func TestLocalInvalidKeys(t *testing.T) {
+	local, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	ctx := context.TODO()
+
+	for _, key := range []string{"", "../receipt", "nested/receipt", ".hidden"} {
+		_, err = local.Put(ctx, key, strings.NewReader("content"))
+		assert.ErrorIs(t, err, errInvalidKey)
+
+		_, err = local.Get(ctx, key)
+		assert.ErrorIs(t, err, errInvalidKey)
+
+		err = local.Delete(ctx, key)
+		assert.ErrorIs(t, err, errInvalidKey)
+	}
+}
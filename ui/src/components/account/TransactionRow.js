@@ -29,7 +29,7 @@ const TransactionRow = ({
   const handleCheckboxChange = async () => {
     const updatedTransaction = {
       ...localTransaction,
-      clearedAt: localTransaction.clearedAt ? null : new Date().toISOString()
+      status: localTransaction.status === 'uncleared' ? 'cleared' : 'uncleared'
     };
     setLocalTransaction(updatedTransaction);
 
@@ -149,7 +149,7 @@ const TransactionRow = ({
       </Td>
       <Td padding="0.6rem">
         <Checkbox
-          isChecked={!!localTransaction.clearedAt}
+          isChecked={localTransaction.status !== 'uncleared'}
           onChange={handleCheckboxChange}
           onBlur={handleSaveChanges}
         />
@@ -25,6 +25,15 @@ import { GoKebabHorizontal } from 'react-icons/go';
 import LoadingBudgets from '../LoadingBudgets';
 import { formatCurrency } from '../../utils/formatCurrency';
 
+// Placeholder row offering to add a category to a group without any.
+const EMPTY_CATEGORY = {
+  categoryId: null,
+  categoryName: null,
+  budgeted: 0,
+  spent: 0,
+  available: 0
+};
+
 const BudgetsTable = ({
   groupedBudgetsArray,
   openGroups,
@@ -167,7 +176,7 @@ const BudgetsTable = ({
                     {formatCurrency(group.spent)}
                   </Td>
                   <Td width="20%" fontWeight="medium">
-                    {formatCurrency(group.budgeted + group.spent)}
+                    {formatCurrency(group.available)}
                   </Td>
                 </Tr>
                 {/* Collapsible Categories */}
@@ -177,7 +186,10 @@ const BudgetsTable = ({
                       <Box>
                         <Table size="sm">
                           <Tbody>
-                            {group.categories.map(category => (
+                            {(group.categories.length
+                              ? group.categories
+                              : [EMPTY_CATEGORY]
+                            ).map(category => (
                               <Tr key={category.categoryId}>
                                 <Td
                                   width="40%"
@@ -278,9 +290,7 @@ const BudgetsTable = ({
                                   {formatCurrency(category.spent)}
                                 </Td>
                                 <Td width="20%">
-                                  {formatCurrency(
-                                    category.budgeted + category.spent
-                                  )}
+                                  {formatCurrency(category.available)}
                                 </Td>
                               </Tr>
                             ))}
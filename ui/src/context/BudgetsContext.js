@@ -4,7 +4,9 @@ import { fetchBudgets, addBudget } from '../services/budgetsService';
 export const BudgetsContext = createContext();
 
 export const BudgetsProvider = ({ children }) => {
-  const [budgets, setBudgets] = useState([]);
+  const [groups, setGroups] = useState([]);
+  const [totals, setTotals] = useState(null);
+  const [summary, setSummary] = useState(null);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState(null);
 
@@ -12,7 +14,9 @@ export const BudgetsProvider = ({ children }) => {
     try {
       setLoading(true);
       const data = await fetchBudgets(year, month);
-      setBudgets(data);
+      setGroups(data.groups);
+      setTotals(data.totals);
+      setSummary(data.summary);
       return { success: true };
     } catch (err) {
       setError(err);
@@ -24,8 +28,7 @@ export const BudgetsProvider = ({ children }) => {
 
   const createBudget = async budgetData => {
     try {
-      const newBudget = await addBudget(budgetData);
-      setBudgets(prevBudgets => [...prevBudgets, newBudget]);
+      await addBudget(budgetData);
       return { success: true };
     } catch (err) {
       setError(err);
@@ -37,7 +40,9 @@ export const BudgetsProvider = ({ children }) => {
     <BudgetsContext.Provider
       // eslint-disable-next-line react/jsx-no-constructed-context-values
       value={{
-        budgets,
+        groups,
+        totals,
+        summary,
         getBudgets,
         createBudget,
         loading,
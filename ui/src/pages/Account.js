@@ -54,7 +54,7 @@ const Account = () => {
     // eslint-disable-next-line react-hooks/exhaustive-deps
   }, [accountId]);
 
-  const { name, balance, category } = currentAccount || {};
+  const { name, clearedBalance, category } = currentAccount || {};
 
   const handleSearch = query => {
     updateSearchQuery(query);
@@ -122,7 +122,7 @@ const Account = () => {
     <Box>
       <AccountHeader
         accountName={name}
-        accountBalance={balance}
+        accountBalance={clearedBalance}
         accountCategory={category}
         formatCurrency={formatCurrency}
         primaryColor={primaryColor}
@@ -1,4 +1,4 @@
-import React, { useState, useEffect, useMemo, useCallback } from 'react';
+import React, { useState, useEffect, useCallback } from 'react';
 import { Box, useTheme, useToast } from '@chakra-ui/react';
 import { useBugdets, useGroups, useCategories } from '../context';
 import {
@@ -17,7 +17,7 @@ const Budgets = () => {
   const toast = useToast();
   const primaryColor = theme.colors.primary;
 
-  const { budgets, loading, error, getBudgets, createBudget } = useBugdets();
+  const { groups, loading, error, getBudgets, createBudget } = useBugdets();
   const { createGroup, updateGroup, deleteGroup } = useGroups();
   const { createCategory, updateCategory, deleteCategory } = useCategories();
 
@@ -29,7 +29,7 @@ const Budgets = () => {
 
   // State variables for managing groups, categories, and UI controls
   const [openGroups, setOpenGroups] = useState(
-    budgets.reduce((acc, item) => ({ ...acc, [item.groupId]: true }), {})
+    groups.reduce((acc, item) => ({ ...acc, [item.groupId]: true }), {})
   );
   const [selectedYear, setSelectedYear] = useState(currentYear);
   const [selectedMonth, setSelectedMonth] = useState(new Date().getMonth() + 1);
@@ -71,65 +71,46 @@ const Budgets = () => {
 
   // Set initial open groups based on fetched budgets
   useEffect(() => {
-    if (budgets.length) {
-      const initialOpenGroups = budgets.reduce(
+    if (groups.length) {
+      const initialOpenGroups = groups.reduce(
         (acc, item) => ({ ...acc, [item.groupId]: true }),
         {}
       );
       setOpenGroups(initialOpenGroups);
     }
-  }, [budgets]);
+  }, [groups]);
 
   // Toggle group open/closed state
   const toggleGroup = useCallback(groupId => {
     setOpenGroups(prev => ({ ...prev, [groupId]: !prev[groupId] }));
   }, []);
 
-  // Group and categorize budgets
-  const groupedBudgetsArray = useMemo(() => {
-    const grouped = budgets.reduce((acc, item) => {
-      const { groupId, groupName, groupNotes, budgeted, spent } = item;
-      if (!acc[groupId]) {
-        acc[groupId] = {
-          groupName,
-          groupNotes,
-          groupId,
-          categories: [],
-          budgeted: 0,
-          spent: 0
-        };
-      }
-      acc[groupId].categories.push(item);
-      acc[groupId].budgeted += budgeted;
-      acc[groupId].spent += spent;
-      return acc;
-    }, {});
-    return Object.values(grouped);
-  }, [budgets]);
-
   // function to check duplicate group name
   const isDuplicateGroup = useCallback(
     (groupName, groupId = null) => {
-      return budgets.some(
-        budget =>
-          budget.groupName === groupName.trim() &&
-          (groupId === null || budget.groupId !== groupId) // Exclude the current group if editing
+      return groups.some(
+        group =>
+          group.groupName === groupName.trim() &&
+          (groupId === null || group.groupId !== groupId) // Exclude the current group if editing
       );
     },
-    [budgets]
+    [groups]
   );
 
   // function to check duplicate category name within a group
   const isDuplicateCategory = useCallback(
     (categoryName, groupId, categoryId = null) => {
-      return budgets.some(
-        budget =>
-          budget.groupId === groupId &&
-          budget.categoryName === categoryName.trim() &&
-          (categoryId === null || budget.id !== categoryId) // Exclude the current category if editing
+      return groups.some(
+        group =>
+          group.groupId === groupId &&
+          group.categories.some(
+            category =>
+              category.categoryName === categoryName.trim() &&
+              (categoryId === null || category.categoryId !== categoryId) // Exclude the current category if editing
+          )
       );
     },
-    [budgets]
+    [groups]
   );
 
   // Handlers for form changes and submissions
@@ -560,7 +541,7 @@ const Budgets = () => {
       {/* Budgets Table */}
       {!error && (
         <BudgetsTable
-          groupedBudgetsArray={groupedBudgetsArray}
+          groupedBudgetsArray={groups}
           openGroups={openGroups}
           toggleGroup={toggleGroup}
           setHoveredRow={setHoveredRow}